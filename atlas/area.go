@@ -0,0 +1,53 @@
+package atlas
+
+import (
+	"math"
+
+	"github.com/go-spatial/tegola"
+)
+
+//	polygonArea returns the area of a polygon or multipolygon's exterior rings less the area of
+//	their holes. false is returned if the geometry is not polygonal
+func polygonArea(geo tegola.Geometry) (float64, bool) {
+	switch g := geo.(type) {
+	case tegola.Polygon:
+		var area float64
+		for i, ring := range g.Sublines() {
+			a := ringArea(ring.Subpoints())
+			if i == 0 {
+				area += a
+				continue
+			}
+			area -= a
+		}
+		return math.Max(area, 0), true
+
+	case tegola.MultiPolygon:
+		var area float64
+		for _, p := range g.Polygons() {
+			a, _ := polygonArea(p)
+			area += a
+		}
+		return area, true
+
+	default:
+		return 0, false
+	}
+}
+
+//	ringArea returns the unsigned area of the ring using the shoelace formula. the ring is
+//	implicitly closed
+func ringArea(pts []tegola.Point) float64 {
+	if len(pts) < 3 {
+		return 0
+	}
+
+	var a float64
+	prev := pts[len(pts)-1]
+	for _, pt := range pts {
+		a += (prev.X() * pt.Y()) - (pt.X() * prev.Y())
+		prev = pt
+	}
+
+	return math.Abs(a / 2)
+}
@@ -2,11 +2,14 @@ package atlas
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/provider"
 )
 
 //	DefaultAtlas is instanitated for convenience
@@ -24,8 +27,21 @@ type Atlas struct {
 	maps map[string]Map
 	//	holds a reference to the cache backend
 	cacher cache.Interface
+	//	named providers which can be shared by multiple layers
+	providers map[string]provider.Tiler
+	//	the MVT extent for maps without a TileExtent. 0 uses tegola.DefaultExtent
+	tileExtent uint64
+	//	optional cache of the features read from the registered providers
+	featureCache *featureCache
+	//	optional retries of the failed queries of the registered providers
+	providerRetry *providerRetry
+	//	optional name of the map looked up in place of unknown map names
+	defaultMap string
+	//	optional receiver of the render, cache and provider query events
+	metrics Metrics
 }
 
+//	AllMaps returns a copy of all the maps registered with the atlas sorted by name
 func (a *Atlas) AllMaps() []Map {
 	a.RLock()
 	defer a.RUnlock()
@@ -38,37 +54,60 @@ func (a *Atlas) AllMaps() []Map {
 		copy(layers, m.Layers)
 		m.Layers = layers
 
-		maps = append(maps, m)
+		maps = append(maps, a.applyTileExtent(m))
 	}
 
+	//	map iteration order is random. sort by name so callers get stable output
+	sort.Slice(maps, func(i, j int) bool {
+		return maps[i].Name < maps[j].Name
+	})
+
 	return maps
 }
 
 //	SeedMapTile will generate a tile and persist it to the
 //	configured cache backend
 func (a *Atlas) SeedMapTile(ctx context.Context, m Map, z, x, y uint64) error {
+	_, err := a.SeedMapTileWithResult(ctx, m, z, x, y)
+	return err
+}
+
+//	SeedMapTileWithResult will generate a tile and persist it to the configured cache
+//	backend, returning the encoded tile. a tile omitted by the map (see Map.OmitEmptyTiles)
+//	is returned as nil and not cached. the tiles of a map with a simplification override (see
+//	Map.OverrideSimplify) or filtered by time (see Map.FilterFeaturesByTime) are returned but not
+//	cached, the cache holds the tiles simplified with the layers' own simplification and unfiltered
+func (a *Atlas) SeedMapTileWithResult(ctx context.Context, m Map, z, x, y uint64) ([]byte, error) {
 	//	confirm we have a cache backend
 	if a.cacher == nil {
-		return ErrMissingCache
+		return nil, ErrMissingCache
 	}
 
+	//	normalize the tile coordinates for the map's tile scheme
+	z, x, y = m.ToXYZ(z, x, y)
+
 	tile := slippy.NewTile(z, x, y, float64(m.TileBuffer), m.SRID)
 
 	//	encode the tile
 	b, err := m.Encode(ctx, tile)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+	if m.simplify != nil || m.timeWindow != nil {
+		return b, nil
 	}
 
 	//	cache key
-	key := cache.Key{
-		MapName: m.Name,
-		Z:       int(z),
-		X:       int(x),
-		Y:       int(y),
+	key := m.cacheKey(z, x, y)
+
+	if err = a.cacher.Set(&key, b); err != nil {
+		return nil, err
 	}
 
-	return a.cacher.Set(&key, b)
+	return b, nil
 }
 
 //	PurgeMapTile will purge a map tile from the configured cache backend
@@ -77,25 +116,66 @@ func (a *Atlas) PurgeMapTile(m Map, tile *tegola.Tile) error {
 		return ErrMissingCache
 	}
 
+	//	normalize the tile coordinates for the map's tile scheme
+	z, x, y := m.ToXYZ(uint64(tile.Z), uint64(tile.X), uint64(tile.Y))
+
 	//	cache key
-	key := cache.Key{
-		MapName: m.Name,
-		Z:       tile.Z,
-		X:       tile.X,
-		Y:       tile.Y,
-	}
+	key := m.cacheKey(z, x, y)
 
 	return a.cacher.Purge(&key)
 }
 
-// Map looks up a Map by name and returns a copy of the Map
+//	PurgeMap will purge all of a map's tiles from the configured cache backend. an
+//	ErrMapNotFound is returned if the map is not registered, the default map is not used,
+//	and an ErrPurgeUnsupported if the cache backend can not enumerate the map's tiles
+func (a *Atlas) PurgeMap(mapName string) error {
+	if a.cacher == nil {
+		return ErrMissingCache
+	}
+
+	if mapName == "" {
+		return ErrMissingMapName
+	}
+
+	a.RLock()
+	_, ok := a.maps[mapName]
+	a.RUnlock()
+	if !ok {
+		return ErrMapNotFound{
+			Name: mapName,
+		}
+	}
+
+	purger, ok := a.cacher.(cache.MapPurger)
+	if !ok {
+		return ErrPurgeUnsupported{
+			MapName: mapName,
+		}
+	}
+
+	return purger.PurgeMap(mapName)
+}
+
+// Map looks up a Map by name and returns a copy of the Map. when a default map is set (see
+// SetDefaultMap) it's returned for names which are not registered
 func (a *Atlas) Map(mapName string) (Map, error) {
+	m, _, err := a.LookupMap(mapName)
+	return m, err
+}
+
+//	LookupMap looks up a Map by name and returns a copy of the Map. when the name is not registered
+//	and a default map is set (see SetDefaultMap) the default map is returned with fallback set
+func (a *Atlas) LookupMap(mapName string) (m Map, fallback bool, err error) {
 	a.RLock()
 	defer a.RUnlock()
 
 	m, ok := a.maps[mapName]
+	if !ok && a.defaultMap != "" {
+		m, ok = a.maps[a.defaultMap]
+		fallback = ok
+	}
 	if !ok {
-		return Map{}, ErrMapNotFound{
+		return Map{}, false, ErrMapNotFound{
 			Name: mapName,
 		}
 	}
@@ -105,19 +185,259 @@ func (a *Atlas) Map(mapName string) (Map, error) {
 	copy(layers, m.Layers)
 	m.Layers = layers
 
-	return m, nil
+	return a.applyTileExtent(m), fallback, nil
+}
+
+//	SetDefaultMap sets the name of the map returned by Map and LookupMap for names which are not
+//	registered. the map doesn't need to be registered yet. an empty name removes the default
+func (a *Atlas) SetDefaultMap(mapName string) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.defaultMap = mapName
 }
 
-//	AddMap registers a map by name. if the map already exists it will be overwritten
-func (a *Atlas) AddMap(m Map) {
+//	SetTileExtent sets the MVT extent, the number of units along each edge of a tile, that maps
+//	without a TileExtent are encoded with. the extent must be a power of two.
+func (a *Atlas) SetTileExtent(extent uint) error {
+	//	a power of two has a single bit set
+	if extent == 0 || extent&(extent-1) != 0 {
+		return ErrInvalidTileExtent{
+			Extent: extent,
+		}
+	}
+
 	a.Lock()
 	defer a.Unlock()
 
+	a.tileExtent = uint64(extent)
+
+	return nil
+}
+
+//	SetFeatureCache caches the features read from the registered providers, keyed by provider, layer,
+//	tile extent and zoom, so layers of different maps querying the same provider layer for the same
+//	tile share a single query. at most maxEntries results are kept, each for at most ttl. a maxEntries
+//	or ttl of 0 disables the cache. the cache applies to maps added after it is set, and to layers
+//	without a SQLFilter.
+func (a *Atlas) SetFeatureCache(maxEntries int, ttl time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+
+	if maxEntries <= 0 || ttl <= 0 {
+		a.featureCache = nil
+		return
+	}
+
+	a.featureCache = newFeatureCache(maxEntries, ttl)
+}
+
+//	SetProviderRetry retries the failed queries of the registered providers up to maxAttempts times
+//	in total, waiting backoff before the first retry and doubling the wait before each following
+//	retry. each query is canceled after attemptTimeout, 0 for no per query deadline. the request's
+//	context still bounds all the attempts. a maxAttempts of 1 or less disables retries. the retries
+//	apply to maps added after they are set.
+func (a *Atlas) SetProviderRetry(maxAttempts int, backoff, attemptTimeout time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+
+	if maxAttempts <= 1 {
+		a.providerRetry = nil
+		return
+	}
+
+	a.providerRetry = &providerRetry{
+		maxAttempts:    maxAttempts,
+		backoff:        backoff,
+		attemptTimeout: attemptTimeout,
+	}
+}
+
+//	applyTileExtent sets the map's TileExtent to the atlas's tile extent if the map does not set one.
+//	the caller must hold the lock.
+func (a *Atlas) applyTileExtent(m Map) Map {
+	if m.TileExtent == 0 {
+		m.TileExtent = a.tileExtent
+	}
+
+	return m
+}
+
+//	AddMap registers a map by name. if the map already exists it will be overwritten.
+//	layers referencing a provider by ProviderName are resolved to the registered provider instance.
+//	an error is returned if a layer references a provider that has not been registered, if a
+//	layer's SQLFilter is invalid or not supported by its provider, or if a layer's Version is not 1 or 2.
+func (a *Atlas) AddMap(m Map) error {
+	//	the providers are sampled before the lock is taken
+	m = a.detectGeomTypes(m)
+
+	a.Lock()
+	defer a.Unlock()
+
+	m, err := a.resolveMap(m)
+	if err != nil {
+		return err
+	}
+
 	if a.maps == nil {
 		a.maps = map[string]Map{}
 	}
 
 	a.maps[m.Name] = m
+
+	return nil
+}
+
+//	Reload replaces all the maps registered with the atlas in a single operation. the maps are
+//	validated and their layers resolved as with AddMap. if any map fails validation an error
+//	is returned and the previously registered maps are left untouched. the providers of the replaced
+//	maps which are no longer referenced are closed as with RemoveMap, registered providers are not closed.
+func (a *Atlas) Reload(maps []Map) error {
+	//	the providers are sampled before the lock is taken
+	detected := make([]Map, len(maps))
+	for i := range maps {
+		detected[i] = a.detectGeomTypes(maps[i])
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	resolved := make(map[string]Map, len(detected))
+	for i := range detected {
+		if detected[i].Name == "" {
+			return ErrMissingMapName
+		}
+
+		if _, ok := resolved[detected[i].Name]; ok {
+			return ErrDuplicateMapName{
+				Name: detected[i].Name,
+			}
+		}
+
+		m, err := a.resolveMap(detected[i])
+		if err != nil {
+			return err
+		}
+
+		resolved[m.Name] = m
+	}
+
+	replaced := make([]Map, 0, len(a.maps))
+	for _, m := range a.maps {
+		replaced = append(replaced, m)
+	}
+
+	a.maps = resolved
+	a.closeUnreferenced(replaced)
+
+	return nil
+}
+
+//	resolveMap returns a copy of the map with the layers referencing a provider by ProviderName
+//	resolved to the registered provider instance, which is served through the provider retries and
+//	the feature cache if they are set. the layers' SQL filters and versions are validated. the caller
+//	must hold the lock.
+func (a *Atlas) resolveMap(m Map) (Map, error) {
+	m.metrics = a.metrics
+
+	//	the namespace is the hash of the map's config, before the map is filtered for a request
+	m.cacheNamespace = ""
+	if m.HashCacheKeys {
+		m.cacheNamespace = m.Hash()
+	}
+
+	//	make an explict copy of the layers so we don't modify the caller's map
+	layers := make([]Layer, len(m.Layers))
+	copy(layers, m.Layers)
+	m.Layers = layers
+
+	for i := range m.Layers {
+		if m.Layers[i].ProviderName == "" {
+			continue
+		}
+
+		p, ok := a.providers[m.Layers[i].ProviderName]
+		if !ok {
+			return Map{}, ErrProviderNotFound{
+				Name: m.Layers[i].ProviderName,
+			}
+		}
+
+		//	the tiles of providers serving encoded tiles are cached by the tile cache
+		if _, ok := p.(provider.MVTTiler); ok {
+			m.Layers[i].Provider = p
+			continue
+		}
+
+		//	cache misses are retried too
+		if a.providerRetry != nil {
+			p = newRetryingProvider(p, *a.providerRetry)
+		}
+
+		m.Layers[i].Provider = p
+
+		//	filtered layers bypass the feature cache as the filter is not part of the cache key
+		if a.featureCache != nil && m.Layers[i].SQLFilter == "" {
+			m.Layers[i].Provider = cachingProvider{
+				Tiler: p,
+				name:  m.Layers[i].ProviderName,
+				cache: a.featureCache,
+			}
+		}
+	}
+
+	for i := range m.Layers {
+		if v := m.Layers[i].Version; v > 2 {
+			return Map{}, ErrInvalidLayerVersion{
+				LayerName: m.Layers[i].MVTName(),
+				Version:   v,
+			}
+		}
+
+		if m.Layers[i].SQLFilter == "" {
+			continue
+		}
+
+		if err := provider.ValidateSQLFilter(m.Layers[i].SQLFilter); err != nil {
+			return Map{}, err
+		}
+
+		if _, ok := m.Layers[i].Provider.(provider.SQLFilterer); !ok {
+			return Map{}, ErrSQLFilterUnsupported{
+				LayerName: m.Layers[i].MVTName(),
+			}
+		}
+	}
+
+	return m, nil
+}
+
+//	RegisterProvider registers a provider by name so it can be shared by multiple layers.
+//	if a provider with the same name already exists it will be overwritten
+func (a *Atlas) RegisterProvider(name string, p provider.Tiler) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.providers == nil {
+		a.providers = map[string]provider.Tiler{}
+	}
+
+	a.providers[name] = p
+}
+
+//	Provider looks up a registered provider by name
+func (a *Atlas) Provider(name string) (provider.Tiler, error) {
+	a.RLock()
+	defer a.RUnlock()
+
+	p, ok := a.providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound{
+			Name: name,
+		}
+	}
+
+	return p, nil
 }
 
 //	GetCache returns the registered cache if one is registered, otherwise nil
@@ -130,6 +450,26 @@ func (a *Atlas) SetCache(c cache.Interface) {
 	a.cacher = c
 }
 
+//	SetFeatureCache sets the provider feature cache of DefaultAtlas. see Atlas.SetFeatureCache
+func SetFeatureCache(maxEntries int, ttl time.Duration) {
+	DefaultAtlas.SetFeatureCache(maxEntries, ttl)
+}
+
+//	SetProviderRetry sets the provider query retries of DefaultAtlas. see Atlas.SetProviderRetry
+func SetProviderRetry(maxAttempts int, backoff, attemptTimeout time.Duration) {
+	DefaultAtlas.SetProviderRetry(maxAttempts, backoff, attemptTimeout)
+}
+
+//	SetTileExtent sets the default MVT extent of the maps registered with DefaultAtlas. see Atlas.SetTileExtent
+func SetTileExtent(extent uint) error {
+	return DefaultAtlas.SetTileExtent(extent)
+}
+
+//	SetDefaultMap sets the map DefaultAtlas returns for unknown map names. see Atlas.SetDefaultMap
+func SetDefaultMap(mapName string) {
+	DefaultAtlas.SetDefaultMap(mapName)
+}
+
 //	AllMaps returns all registered maps in DefaultAtlas
 func AllMaps() []Map {
 	return DefaultAtlas.AllMaps()
@@ -141,8 +481,28 @@ func GetMap(mapName string) (Map, error) {
 }
 
 //	AddMap registers a map by name with DefaultAtlas. if the map already exists it will be overwritten
-func AddMap(m Map) {
-	DefaultAtlas.AddMap(m)
+func AddMap(m Map) error {
+	return DefaultAtlas.AddMap(m)
+}
+
+//	Reload replaces all the maps registered with DefaultAtlas. see Atlas.Reload
+func Reload(maps []Map) error {
+	return DefaultAtlas.Reload(maps)
+}
+
+//	RemoveMap unregisters the map by name from DefaultAtlas. see Atlas.RemoveMap
+func RemoveMap(mapName string) error {
+	return DefaultAtlas.RemoveMap(mapName)
+}
+
+//	RegisterProvider registers a provider by name with DefaultAtlas. if the provider already exists it will be overwritten
+func RegisterProvider(name string, p provider.Tiler) {
+	DefaultAtlas.RegisterProvider(name, p)
+}
+
+//	GetProvider returns a registered provider by name from DefaultAtlas. if the provider does not exist it will return an error
+func GetProvider(name string) (provider.Tiler, error) {
+	return DefaultAtlas.Provider(name)
 }
 
 //	GetCache returns the registered cache for DefaultAtlas, if one is registered, otherwise nil
@@ -161,8 +521,44 @@ func SeedMapTile(ctx context.Context, m Map, z, x, y uint64) error {
 	return DefaultAtlas.SeedMapTile(ctx, m, z, x, y)
 }
 
+//	SeedMapTileWithResult will generate a tile and persist it to the configured cache
+//	backend for the DefaultAtlas, returning the encoded tile
+func SeedMapTileWithResult(ctx context.Context, m Map, z, x, y uint64) ([]byte, error) {
+	return DefaultAtlas.SeedMapTileWithResult(ctx, m, z, x, y)
+}
+
+//	RenderTileWithInfo returns the map's tile from the cache backend of DefaultAtlas, encoding it on a
+//	cache miss, along with how the tile was produced. see Atlas.RenderTileWithInfo
+func RenderTileWithInfo(ctx context.Context, m Map, z, x, y uint64) ([]byte, RenderInfo, error) {
+	return DefaultAtlas.RenderTileWithInfo(ctx, m, z, x, y)
+}
+
+//	SeedMapTiles will generate the tiles of the map covering the WGS84 bounds
+//	for each of the zooms and persist them to the configured cache backend
+//	for the DefaultAtlas
+func SeedMapTiles(ctx context.Context, m Map, bounds [4]float64, zooms []uint64, order SeedOrder) error {
+	return DefaultAtlas.SeedMapTiles(ctx, m, bounds, zooms, order)
+}
+
+//	SeedTiles will generate the listed tiles of the named map registered with DefaultAtlas and persist
+//	them to the configured cache backend. see Atlas.SeedTiles
+func SeedTiles(ctx context.Context, mapName string, tiles []TileCoord, concurrency int) error {
+	return DefaultAtlas.SeedTiles(ctx, mapName, tiles, concurrency)
+}
+
+//	HealthCheck checks the providers of the DefaultAtlas. see Atlas.HealthCheck
+func HealthCheck(ctx context.Context) map[string]error {
+	return DefaultAtlas.HealthCheck(ctx)
+}
+
 //	PurgeMapTile will purge a map tile from the configured cache backend
 //	for the DefaultAtlas
 func PurgeMapTile(m Map, tile *tegola.Tile) error {
 	return DefaultAtlas.PurgeMapTile(m, tile)
 }
+
+//	PurgeMap will purge all of a map's tiles from the configured cache backend
+//	for the DefaultAtlas
+func PurgeMap(mapName string) error {
+	return DefaultAtlas.PurgeMap(mapName)
+}
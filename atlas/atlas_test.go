@@ -1,8 +1,24 @@
 package atlas_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
 	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/test"
 )
 
@@ -50,3 +66,1306 @@ var testMap = atlas.Map{
 		testLayer3,
 	},
 }
+
+func TestAtlasRegisterProvider(t *testing.T) {
+	a := &atlas.Atlas{}
+
+	p := &test.TileProvider{}
+	a.RegisterProvider("shared", p)
+
+	m := atlas.NewWebMercatorMap("shared-provider")
+	m.Layers = []atlas.Layer{
+		{
+			Name:              "layer1",
+			ProviderLayerName: "test-layer",
+			ProviderName:      "shared",
+		},
+		{
+			Name:              "layer2",
+			ProviderLayerName: "test-layer",
+			ProviderName:      "shared",
+		},
+	}
+
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+
+	out, err := a.Map(m.Name)
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+
+	for i, l := range out.Layers {
+		tp, ok := l.Provider.(*test.TileProvider)
+		if !ok || tp != p {
+			t.Errorf("layer (%v) provider, expected the registered instance %p got %p", i, p, l.Provider)
+		}
+	}
+
+	//	the caller's map should not be modified
+	if m.Layers[0].Provider != nil {
+		t.Errorf("expected the caller's layers to be unmodified")
+	}
+
+	// unknown provider
+	m.Name = "unknown-provider"
+	m.Layers[1].ProviderName = "unknown"
+
+	err = a.AddMap(m)
+	if _, ok := err.(atlas.ErrProviderNotFound); !ok {
+		t.Fatalf("expected ErrProviderNotFound got %v", err)
+	}
+
+	if _, err = a.Map(m.Name); err == nil {
+		t.Errorf("expected map (%v) to not be registered", m.Name)
+	}
+}
+
+func TestAtlasAllMapsSorted(t *testing.T) {
+	a := &atlas.Atlas{}
+
+	for _, name := range []string{"b", "a", "c"} {
+		if err := a.AddMap(atlas.NewWebMercatorMap(name)); err != nil {
+			t.Fatalf("err adding map (%v): %v", name, err)
+		}
+	}
+
+	expected := []string{"a", "b", "c"}
+
+	// run a few times as map iteration order is random
+	for i := 0; i < 10; i++ {
+		maps := a.AllMaps()
+		if len(maps) != len(expected) {
+			t.Fatalf("maps length, expected %v got %v", len(expected), len(maps))
+		}
+
+		for j := range maps {
+			if maps[j].Name != expected[j] {
+				t.Fatalf("map (%v) name, expected %v got %v", j, expected[j], maps[j].Name)
+			}
+		}
+	}
+}
+
+func TestAtlasDefaultMap(t *testing.T) {
+	type tcase struct {
+		defaultMap string
+		lookup     string
+
+		expectedName     string
+		expectedFallback bool
+		expectedErr      error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+		for _, name := range []string{"osm", "fallback"} {
+			if err := a.AddMap(atlas.NewWebMercatorMap(name)); err != nil {
+				t.Fatalf("err adding map (%v): %v", name, err)
+			}
+		}
+		a.SetDefaultMap(tc.defaultMap)
+
+		m, fallback, err := a.LookupMap(tc.lookup)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if m.Name != tc.expectedName {
+			t.Errorf("map name, expected %v got %v", tc.expectedName, m.Name)
+		}
+		if fallback != tc.expectedFallback {
+			t.Errorf("fallback, expected %v got %v", tc.expectedFallback, fallback)
+		}
+
+		// Map resolves the same map
+		m, err = a.Map(tc.lookup)
+		if err != tc.expectedErr {
+			t.Fatalf("Map error, expected %v got %v", tc.expectedErr, err)
+		}
+		if m.Name != tc.expectedName {
+			t.Errorf("Map name, expected %v got %v", tc.expectedName, m.Name)
+		}
+	}
+
+	tests := map[string]tcase{
+		"registered": {
+			defaultMap:   "fallback",
+			lookup:       "osm",
+			expectedName: "osm",
+		},
+		"unknown with default": {
+			defaultMap:       "fallback",
+			lookup:           "unknown",
+			expectedName:     "fallback",
+			expectedFallback: true,
+		},
+		"unknown without default": {
+			lookup:      "unknown",
+			expectedErr: atlas.ErrMapNotFound{Name: "unknown"},
+		},
+		"default not registered": {
+			defaultMap:  "missing",
+			lookup:      "unknown",
+			expectedErr: atlas.ErrMapNotFound{Name: "unknown"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestAtlasReload(t *testing.T) {
+	a := &atlas.Atlas{}
+	a.RegisterProvider("shared", &test.TileProvider{})
+
+	for _, name := range []string{"a", "b"} {
+		if err := a.AddMap(atlas.NewWebMercatorMap(name)); err != nil {
+			t.Fatalf("err adding map (%v): %v", name, err)
+		}
+	}
+
+	mapNames := func() (names []string) {
+		for _, m := range a.AllMaps() {
+			names = append(names, m.Name)
+		}
+		return names
+	}
+
+	layerMap := func(name, providerName string) atlas.Map {
+		m := atlas.NewWebMercatorMap(name)
+		m.Layers = []atlas.Layer{
+			{
+				Name:              "layer",
+				ProviderLayerName: "test-layer",
+				ProviderName:      providerName,
+			},
+		}
+		return m
+	}
+
+	type tcase struct {
+		maps          []atlas.Map
+		expectedErr   error
+		expectedNames []string
+	}
+
+	// the cases are run in order against the same atlas
+	tests := []struct {
+		name string
+		tcase
+	}{
+		{
+			name: "invalid provider",
+			tcase: tcase{
+				maps:          []atlas.Map{layerMap("c", "shared"), layerMap("d", "unknown")},
+				expectedErr:   atlas.ErrProviderNotFound{Name: "unknown"},
+				expectedNames: []string{"a", "b"},
+			},
+		},
+		{
+			name: "duplicate name",
+			tcase: tcase{
+				maps:          []atlas.Map{layerMap("c", "shared"), layerMap("c", "shared")},
+				expectedErr:   atlas.ErrDuplicateMapName{Name: "c"},
+				expectedNames: []string{"a", "b"},
+			},
+		},
+		{
+			name: "missing name",
+			tcase: tcase{
+				maps:          []atlas.Map{layerMap("", "shared")},
+				expectedErr:   atlas.ErrMissingMapName,
+				expectedNames: []string{"a", "b"},
+			},
+		},
+		{
+			name: "valid",
+			tcase: tcase{
+				maps:          []atlas.Map{layerMap("d", "shared"), layerMap("c", "shared")},
+				expectedNames: []string{"c", "d"},
+			},
+		},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		err := a.Reload(tc.maps)
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+
+		if names := mapNames(); !reflect.DeepEqual(names, tc.expectedNames) {
+			t.Errorf("maps, expected %v got %v", tc.expectedNames, names)
+		}
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc.tcase)
+		})
+	}
+
+	//	the reloaded layers are resolved to the registered provider
+	m, err := a.Map("c")
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+	if m.Layers[0].Provider == nil {
+		t.Errorf("expected the layer provider to be resolved")
+	}
+}
+
+type closeProvider struct {
+	test.TileProvider
+	closed int
+}
+
+func (p *closeProvider) Close() error {
+	p.closed++
+	return nil
+}
+
+func TestAtlasRemoveMap(t *testing.T) {
+	shared, own, replaced := &closeProvider{}, &closeProvider{}, &closeProvider{}
+
+	a := &atlas.Atlas{}
+	a.RegisterProvider("shared", shared)
+	a.RegisterProvider("replaced", replaced)
+
+	layerMap := func(name string, l atlas.Layer) atlas.Map {
+		m := atlas.NewWebMercatorMap(name)
+		l.Name = "layer"
+		m.Layers = []atlas.Layer{l}
+		return m
+	}
+
+	maps := []atlas.Map{
+		layerMap("a", atlas.Layer{ProviderName: "shared"}),
+		layerMap("b", atlas.Layer{ProviderName: "shared"}),
+		layerMap("c", atlas.Layer{Provider: own}),
+		layerMap("d", atlas.Layer{ProviderName: "replaced"}),
+	}
+	for _, m := range maps {
+		if err := a.AddMap(m); err != nil {
+			t.Fatalf("err adding map (%v): %v", m.Name, err)
+		}
+	}
+
+	type tcase struct {
+		remove      string
+		reload      []atlas.Map
+		expectedErr error
+		// the expected number of times the shared, own and replaced providers are closed
+		expectedClosed [3]int
+	}
+
+	// the cases are run in order against the same atlas
+	tests := []struct {
+		name string
+		tcase
+	}{
+		{
+			name: "unknown map",
+			tcase: tcase{
+				remove:      "unknown",
+				expectedErr: atlas.ErrMapNotFound{Name: "unknown"},
+			},
+		},
+		{
+			name: "provider referenced by another map",
+			tcase: tcase{
+				remove: "a",
+			},
+		},
+		{
+			name: "last reference to a registered provider",
+			tcase: tcase{
+				remove: "b",
+			},
+		},
+		{
+			name: "unregistered provider",
+			tcase: tcase{
+				remove:         "c",
+				expectedClosed: [3]int{0, 1, 0},
+			},
+		},
+		{
+			name: "reload with a new provider instance",
+			tcase: tcase{
+				reload:         []atlas.Map{layerMap("d", atlas.Layer{Provider: &closeProvider{}})},
+				expectedClosed: [3]int{0, 1, 0},
+			},
+		},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		var err error
+		if tc.reload != nil {
+			err = a.Reload(tc.reload)
+		} else {
+			err = a.RemoveMap(tc.remove)
+		}
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+
+		closed := [3]int{shared.closed, own.closed, replaced.closed}
+		if closed != tc.expectedClosed {
+			t.Errorf("closed, expected %v got %v", tc.expectedClosed, closed)
+		}
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc.tcase)
+		})
+	}
+
+	// the registered providers are left registered and new maps can reference them
+	for _, name := range []string{"shared", "replaced"} {
+		if _, err := a.Provider(name); err != nil {
+			t.Errorf("provider (%v), expected it to be registered got %v", name, err)
+		}
+		if err := a.AddMap(layerMap("e", atlas.Layer{ProviderName: name})); err != nil {
+			t.Errorf("err adding map referencing provider (%v): %v", name, err)
+		}
+	}
+}
+
+func TestAtlasAddMapSQLFilter(t *testing.T) {
+	type tcase struct {
+		provider    provider.Tiler
+		filter      string
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+		a.RegisterProvider("provider", tc.provider)
+
+		m := atlas.NewWebMercatorMap("filtered")
+		m.Layers = []atlas.Layer{
+			{
+				Name:              "layer",
+				ProviderLayerName: "test-layer",
+				ProviderName:      "provider",
+				SQLFilter:         tc.filter,
+			},
+		}
+
+		err := a.AddMap(m)
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+	}
+
+	tests := map[string]tcase{
+		"valid": {
+			provider: &filteringProvider{},
+			filter:   "type = 'road'",
+		},
+		"no filter": {
+			provider: &test.TileProvider{},
+		},
+		"invalid filter": {
+			provider:    &filteringProvider{},
+			filter:      "1 = 1; DROP TABLE roads",
+			expectedErr: provider.ErrInvalidSQLFilter{Filter: "1 = 1; DROP TABLE roads", Reason: "statement terminator"},
+		},
+		"unsupported provider": {
+			provider:    &test.TileProvider{},
+			filter:      "type = 'road'",
+			expectedErr: atlas.ErrSQLFilterUnsupported{LayerName: "layer"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestAtlasAddMapLayerVersion(t *testing.T) {
+	type tcase struct {
+		version     uint
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+
+		m := atlas.NewWebMercatorMap("versioned")
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "layer",
+				Provider: &test.TileProvider{},
+				Version:  tc.version,
+			},
+		}
+
+		err := a.AddMap(m)
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+	}
+
+	tests := map[string]tcase{
+		"default":   {},
+		"version 1": {version: 1},
+		"version 2": {version: 2},
+		"version 3": {
+			version:     3,
+			expectedErr: atlas.ErrInvalidLayerVersion{LayerName: "layer", Version: 3},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestAtlasDetectGeomType(t *testing.T) {
+	type tcase struct {
+		provider provider.Tiler
+		detect   bool
+		geomType geom.Geometry
+		expected geom.Geometry
+	}
+
+	line := func(id uint64) provider.Feature {
+		return provider.Feature{
+			ID:       id,
+			Geometry: geom.LineString{{0, 0}, {1, 1}},
+			SRID:     tegola.WebMercator,
+		}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+
+		m := atlas.NewWebMercatorMap("detected")
+		m.Layers = []atlas.Layer{
+			{
+				Name:           "layer",
+				Provider:       tc.provider,
+				GeomType:       tc.geomType,
+				DetectGeomType: tc.detect,
+			},
+		}
+
+		if err := a.AddMap(m); err != nil {
+			t.Fatalf("err adding map: %v", err)
+		}
+		m, err := a.Map("detected")
+		if err != nil {
+			t.Fatalf("err fetching map: %v", err)
+		}
+
+		if !reflect.DeepEqual(m.Layers[0].GeomType, tc.expected) {
+			t.Errorf("geom type, expected %#v got %#v", tc.expected, m.Layers[0].GeomType)
+		}
+	}
+
+	tests := map[string]tcase{
+		"points": {
+			provider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{1, 1}},
+			detect:   true,
+			expected: geom.Point{},
+		},
+		"predominant type": {
+			provider: featuresProvider{features: []provider.Feature{
+				{ID: 1, Geometry: geom.Point{1, 1}, SRID: tegola.WebMercator},
+				line(2),
+				line(3),
+			}},
+			detect:   true,
+			expected: geom.LineString{},
+		},
+		"no features": {
+			provider: featuresProvider{},
+			detect:   true,
+		},
+		"configured geom type": {
+			provider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{1, 1}},
+			detect:   true,
+			geomType: geom.Polygon{},
+			expected: geom.Polygon{},
+		},
+		"detection off": {
+			provider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{1, 1}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// samplingProvider returns many points on each TileFeatures call and records how they were read
+type samplingProvider struct {
+	calls int
+	// the features read by fn on the last call
+	read int
+	// the last call's context had a deadline
+	deadline bool
+}
+
+func (*samplingProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *samplingProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.calls++
+	_, p.deadline = ctx.Deadline()
+
+	for p.read = 0; p.read < 10*atlas.GeomTypeSampleSize; p.read++ {
+		if err := fn(&provider.Feature{ID: uint64(p.read), Geometry: geom.Point{1, 1}, SRID: tegola.WebMercator}); err != nil {
+			p.read++
+			return err
+		}
+	}
+	return nil
+}
+
+func TestAtlasDetectGeomTypeRegisteredProvider(t *testing.T) {
+	p := &samplingProvider{}
+
+	a := &atlas.Atlas{}
+	a.RegisterProvider("points", p)
+	a.SetFeatureCache(10, time.Minute)
+	a.SetProviderRetry(3, time.Millisecond, time.Second)
+
+	m := atlas.NewWebMercatorMap("detected")
+	m.Layers = []atlas.Layer{
+		{
+			Name:              "points",
+			ProviderLayerName: "points",
+			ProviderName:      "points",
+			DetectGeomType:    true,
+		},
+	}
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	m, err := a.Map("detected")
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+
+	if !reflect.DeepEqual(m.Layers[0].GeomType, geom.Point{}) {
+		t.Errorf("geom type, expected %#v got %#v", geom.Point{}, m.Layers[0].GeomType)
+	}
+	// the sample stops reading once it's full, within a deadline
+	if p.read != atlas.GeomTypeSampleSize {
+		t.Errorf("features read, expected %v got %v", atlas.GeomTypeSampleSize, p.read)
+	}
+	if !p.deadline {
+		t.Errorf("deadline, expected the sample to be read with a deadline")
+	}
+
+	// the sample is not read through the feature cache, the world tile is queried again
+	if _, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 0, tegola.WebMercator)); err != nil {
+		t.Fatalf("err encoding tile: %v", err)
+	}
+	if p.calls != 2 {
+		t.Errorf("provider queries, expected 2 got %v", p.calls)
+	}
+}
+
+func TestAtlasSetTileExtent(t *testing.T) {
+	a := &atlas.Atlas{}
+
+	for _, extent := range []uint{0, 500} {
+		err := a.SetTileExtent(extent)
+		if expected := (atlas.ErrInvalidTileExtent{Extent: extent}); err != expected {
+			t.Errorf("extent (%v) error, expected %v got %v", extent, expected, err)
+		}
+	}
+
+	if err := a.SetTileExtent(512); err != nil {
+		t.Fatalf("err setting tile extent: %v", err)
+	}
+
+	const half = 20037508.34 / 2
+
+	m := atlas.NewWebMercatorMap("extent")
+	m.Layers = []atlas.Layer{
+		{
+			Name:         "polygons",
+			Provider:     polygonProvider{polygon: geom.Polygon{{{-half, -half}, {half, -half}, {half, half}, {-half, half}}}},
+			DontSimplify: true,
+		},
+	}
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+
+	m, err := a.Map("extent")
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+
+	out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("err encoding tile: %v", err)
+	}
+
+	var vt vectorTile.Tile
+	if err = proto.Unmarshal(out, &vt); err != nil {
+		t.Fatalf("err unmarshalling tile: %v", err)
+	}
+
+	if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+		t.Fatalf("expected a single layer with a single feature got %v", vt.Layers)
+	}
+
+	if extent := vt.Layers[0].GetExtent(); extent != 512 {
+		t.Errorf("layer extent, expected 512 got %v", extent)
+	}
+
+	expected := [2][2]int64{{128, 128}, {384, 384}}
+	if ext := featurePixelExtent(vt.Layers[0].Features[0]); ext != expected {
+		t.Errorf("feature extent, expected %v got %v", expected, ext)
+	}
+}
+
+func TestAtlasFeatureCache(t *testing.T) {
+	type tcase struct {
+		maxEntries int
+		ttl        time.Duration
+		// the tiles encoded by each map in turn
+		tiles []*slippy.Tile
+		// the expected number of provider queries
+		expected int
+	}
+
+	tile1 := slippy.NewTile(1, 0, 0, 64, tegola.WebMercator)
+	tile2 := slippy.NewTile(1, 1, 0, 64, tegola.WebMercator)
+
+	fn := func(t *testing.T, tc tcase) {
+		p := &countingProvider{calls: map[string]int{}}
+
+		a := &atlas.Atlas{}
+		a.RegisterProvider("shared", p)
+		a.SetFeatureCache(tc.maxEntries, tc.ttl)
+
+		// two maps with a layer on the same provider layer
+		for _, name := range []string{"a", "b"} {
+			m := atlas.NewWebMercatorMap(name)
+			m.Layers = []atlas.Layer{
+				{
+					Name:              name + "-roads",
+					ProviderLayerName: "roads",
+					ProviderName:      "shared",
+				},
+			}
+			if err := a.AddMap(m); err != nil {
+				t.Fatalf("err adding map (%v): %v", name, err)
+			}
+		}
+
+		for _, tile := range tc.tiles {
+			for _, m := range a.AllMaps() {
+				if _, err := m.Encode(context.Background(), tile); err != nil {
+					t.Fatalf("err encoding map (%v): %v", m.Name, err)
+				}
+			}
+		}
+
+		if p.calls["roads"] != tc.expected {
+			t.Errorf("provider queries, expected %v got %v", tc.expected, p.calls["roads"])
+		}
+	}
+
+	tests := map[string]tcase{
+		"no cache": {
+			tiles:    []*slippy.Tile{tile1},
+			expected: 2,
+		},
+		"same tile": {
+			maxEntries: 10,
+			ttl:        time.Minute,
+			tiles:      []*slippy.Tile{tile1, tile1},
+			expected:   1,
+		},
+		"different tiles": {
+			maxEntries: 10,
+			ttl:        time.Minute,
+			tiles:      []*slippy.Tile{tile1, tile2},
+			expected:   2,
+		},
+		"evicted": {
+			maxEntries: 1,
+			ttl:        time.Minute,
+			tiles:      []*slippy.Tile{tile1, tile2, tile1},
+			expected:   3,
+		},
+		"expired": {
+			maxEntries: 10,
+			ttl:        time.Nanosecond,
+			tiles:      []*slippy.Tile{tile1},
+			expected:   2,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// tagsProvider returns a single feature with new tags on each TileFeatures call
+type tagsProvider struct {
+	calls int
+}
+
+func (*tagsProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *tagsProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.calls++
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{0, 0},
+		SRID:     tegola.WebMercator,
+		Tags:     map[string]interface{}{"name": "a"},
+	})
+}
+
+func TestAtlasFeatureCacheTags(t *testing.T) {
+	p := &tagsProvider{}
+
+	a := &atlas.Atlas{}
+	a.RegisterProvider("tags", p)
+	a.SetFeatureCache(10, time.Minute)
+
+	m := atlas.NewWebMercatorMap("tags")
+	m.Layers = []atlas.Layer{
+		{
+			Name:              "points",
+			ProviderLayerName: "points",
+			ProviderName:      "tags",
+		},
+	}
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	m, err := a.Map("tags")
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+	cached := m.Layers[0].Provider
+
+	// the tags of each read, which are modified once read
+	read := func(tile *slippy.Tile, fnErr error) ([]map[string]interface{}, error) {
+		var tags []map[string]interface{}
+		err := cached.TileFeatures(context.Background(), "points", tile, func(f *provider.Feature) error {
+			tags = append(tags, map[string]interface{}{})
+			for k, v := range f.Tags {
+				tags[len(tags)-1][k] = v
+			}
+
+			delete(f.Tags, "name")
+			f.Tags["modified"] = true
+			return fnErr
+		})
+		return tags, err
+	}
+
+	expected := []map[string]interface{}{{"name": "a"}}
+
+	tile := slippy.NewTile(1, 0, 0, 64, tegola.WebMercator)
+	for i := 0; i < 3; i++ {
+		tags, err := read(tile, nil)
+		if err != nil {
+			t.Fatalf("[%v] err reading features: %v", i, err)
+		}
+		if !reflect.DeepEqual(tags, expected) {
+			t.Errorf("[%v] tags, expected %v got %v", i, expected, tags)
+		}
+	}
+	if p.calls != 1 {
+		t.Errorf("provider queries, expected 1 got %v", p.calls)
+	}
+
+	// the features of a read fn stops part way are not cached
+	errStop := errors.New("stop")
+	tile = slippy.NewTile(1, 1, 0, 64, tegola.WebMercator)
+	if _, err := read(tile, errStop); err != errStop {
+		t.Fatalf("error, expected %v got %v", errStop, err)
+	}
+	if _, err := read(tile, nil); err != nil {
+		t.Fatalf("err reading features: %v", err)
+	}
+	if p.calls != 3 {
+		t.Errorf("provider queries, expected 3 got %v", p.calls)
+	}
+}
+
+// flakyProvider fails the first failures TileFeatures calls
+type flakyProvider struct {
+	failures int
+	calls    int
+}
+
+func (*flakyProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *flakyProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.calls++
+
+	// the features streamed before the failure must not be encoded
+	if err := fn(&provider.Feature{ID: uint64(p.calls), Geometry: geom.Point{0, 0}, SRID: tegola.WebMercator}); err != nil {
+		return err
+	}
+
+	if p.calls <= p.failures {
+		return fmt.Errorf("call %v failed", p.calls)
+	}
+
+	return nil
+}
+
+func TestAtlasProviderRetry(t *testing.T) {
+	type tcase struct {
+		failures    int
+		maxAttempts int
+		backoff     time.Duration
+		canceled    bool
+		// the expected number of provider queries
+		expectedCalls int
+		// if the layer is expected to be encoded. the layer is dropped when every query fails
+		expectedLayer bool
+		expectedErr   error
+	}
+
+	tile := slippy.NewTile(1, 0, 0, 64, tegola.WebMercator)
+
+	fn := func(t *testing.T, tc tcase) {
+		p := &flakyProvider{failures: tc.failures}
+
+		a := &atlas.Atlas{}
+		a.RegisterProvider("flaky", p)
+		a.SetProviderRetry(tc.maxAttempts, tc.backoff, time.Second)
+
+		m := atlas.NewWebMercatorMap("retry")
+		m.Layers = []atlas.Layer{
+			{
+				Name:              "points",
+				ProviderLayerName: "points",
+				ProviderName:      "flaky",
+			},
+		}
+		if err := a.AddMap(m); err != nil {
+			t.Fatalf("err adding map: %v", err)
+		}
+
+		m, err := a.Map("retry")
+		if err != nil {
+			t.Fatalf("err fetching map: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if tc.canceled {
+			cancel()
+		}
+
+		out, err := m.Encode(ctx, tile)
+		if p.calls != tc.expectedCalls {
+			t.Errorf("provider queries, expected %v got %v", tc.expectedCalls, p.calls)
+		}
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if err != nil {
+			return
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+		if !tc.expectedLayer {
+			if len(vt.Layers) != 0 {
+				t.Errorf("expected no layers got %v", vt.Layers)
+			}
+			return
+		}
+		if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+			t.Fatalf("expected a single layer with a single feature got %v", vt.Layers)
+		}
+		// only the features of the successful query are encoded
+		if id := vt.Layers[0].Features[0].GetId(); id != uint64(tc.expectedCalls) {
+			t.Errorf("feature id, expected %v got %v", tc.expectedCalls, id)
+		}
+	}
+
+	tests := map[string]tcase{
+		"succeeds after retries": {
+			failures:      2,
+			maxAttempts:   3,
+			backoff:       time.Millisecond,
+			expectedCalls: 3,
+			expectedLayer: true,
+		},
+		"attempts used up": {
+			failures:      2,
+			maxAttempts:   2,
+			backoff:       time.Millisecond,
+			expectedCalls: 2,
+		},
+		"no retries": {
+			failures:      2,
+			maxAttempts:   1,
+			expectedCalls: 1,
+		},
+		"canceled": {
+			failures:      2,
+			maxAttempts:   3,
+			backoff:       time.Hour,
+			canceled:      true,
+			expectedCalls: 1,
+			expectedErr:   context.Canceled,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestAtlasEncodeFeatures(t *testing.T) {
+	a := &atlas.Atlas{}
+
+	m := atlas.NewWebMercatorMap("features")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "points",
+			Provider: &test.TileProvider{},
+		},
+		{
+			Name:     "unused",
+			Provider: &test.TileProvider{},
+		},
+	}
+
+	features := map[string][]provider.Feature{
+		"points": {
+			{
+				ID:       7,
+				Geometry: geom.Point{0, 0},
+				SRID:     tegola.WebMercator,
+				Tags: map[string]interface{}{
+					"name": "center",
+				},
+			},
+		},
+	}
+
+	out, err := a.EncodeFeatures(m, 0, 0, 0, features)
+	if err != nil {
+		t.Fatalf("err encoding features: %v", err)
+	}
+
+	var vt vectorTile.Tile
+	if err = proto.Unmarshal(out, &vt); err != nil {
+		t.Fatalf("err unmarshalling tile: %v", err)
+	}
+
+	if len(vt.Layers) != 1 || vt.Layers[0].GetName() != "points" {
+		t.Fatalf("expected only the points layer got %v", vt.Layers)
+	}
+	if len(vt.Layers[0].Features) != 1 {
+		t.Fatalf("features, expected 1 got %v", len(vt.Layers[0].Features))
+	}
+
+	f := vt.Layers[0].Features[0]
+	if f.GetId() != 7 {
+		t.Errorf("feature id, expected 7 got %v", f.GetId())
+	}
+	if f.GetType() != vectorTile.Tile_POINT {
+		t.Errorf("feature type, expected %v got %v", vectorTile.Tile_POINT, f.GetType())
+	}
+
+	// the center of the tile
+	expected := [2][2]int64{{2048, 2048}, {2048, 2048}}
+	if ext := featurePixelExtent(f); ext != expected {
+		t.Errorf("feature position, expected %v got %v", expected, ext)
+	}
+
+	if keys := vt.Layers[0].Keys; !reflect.DeepEqual(keys, []string{"name"}) {
+		t.Errorf("keys, expected [name] got %v", keys)
+	}
+
+	// the features are matched on the layers' MVT names
+	_, err = a.EncodeFeatures(m, 0, 0, 0, map[string][]provider.Feature{"missing": nil})
+	if expectedErr := (atlas.ErrLayerNotFound{MapName: "features", LayerName: "missing"}); err != expectedErr {
+		t.Errorf("error, expected %v got %v", expectedErr, err)
+	}
+}
+
+func TestAtlasSeedMapTileWithResult(t *testing.T) {
+	type tcase struct {
+		cache bool
+		// the map omits its empty tiles and the layer has no features
+		omitEmpty   bool
+		z, x, y     uint64
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+		if tc.cache {
+			a.SetCache(memory.New())
+		}
+
+		m := atlas.NewWebMercatorMap("seeded")
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "layer1",
+				Provider: &test.TileProvider{},
+			},
+		}
+		if tc.omitEmpty {
+			m.OmitEmptyTiles = true
+			m.Layers[0].Provider = emptyTileProvider{}
+		}
+
+		b, err := a.SeedMapTileWithResult(context.Background(), m, tc.z, tc.x, tc.y)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if tc.expectedErr != nil {
+			return
+		}
+
+		key := cache.Key{MapName: "seeded", Z: int(tc.z), X: int(tc.x), Y: int(tc.y)}
+
+		//	the omitted tile is not cached
+		if tc.omitEmpty {
+			if b != nil {
+				t.Errorf("expected no tile got %v bytes", len(b))
+			}
+			if _, hit, _ := a.GetCache().Get(&key); hit {
+				t.Errorf("expected the omitted tile not to be cached")
+			}
+			return
+		}
+
+		if len(b) == 0 {
+			t.Fatalf("expected an encoded tile")
+		}
+
+		//	the returned tile is the cached tile
+		cached, hit, err := a.GetCache().Get(&key)
+		if err != nil {
+			t.Fatalf("err reading cache: %v", err)
+		}
+		if !hit {
+			t.Fatalf("expected the tile to be cached")
+		}
+		if !bytes.Equal(b, cached) {
+			t.Errorf("tile, expected %v got %v", cached, b)
+		}
+	}
+
+	tests := map[string]tcase{
+		"seed": {
+			cache: true,
+			z:     2,
+			x:     1,
+			y:     3,
+		},
+		"omitted empty tile": {
+			cache:     true,
+			omitEmpty: true,
+			z:         2,
+			x:         1,
+			y:         3,
+		},
+		"missing cache": {
+			expectedErr: atlas.ErrMissingCache,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestAtlasPurgeMap(t *testing.T) {
+	keys := func(mapName string) (keys []cache.Key) {
+		for z := 0; z < 3; z++ {
+			keys = append(keys, cache.Key{MapName: mapName, Z: z, X: z, Y: z})
+		}
+		//	a layer scoped key
+		return append(keys, cache.Key{MapName: mapName, LayerName: "layer", Z: 1})
+	}
+
+	type tcase struct {
+		cacher      cache.Interface
+		mapName     string
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+		a.SetCache(tc.cacher)
+
+		for _, name := range []string{"a", "b"} {
+			if err := a.AddMap(atlas.NewWebMercatorMap(name)); err != nil {
+				t.Fatalf("err adding map (%v): %v", name, err)
+			}
+		}
+		//	the default map doesn't stand in for unregistered maps
+		a.SetDefaultMap("a")
+
+		for _, name := range []string{"a", "b", "c"} {
+			for _, k := range keys(name) {
+				k := k
+				if err := tc.cacher.Set(&k, []byte(name)); err != nil {
+					t.Fatalf("err setting key (%v): %v", k, err)
+				}
+			}
+		}
+
+		err := a.PurgeMap(tc.mapName)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+
+		for _, name := range []string{"a", "b", "c"} {
+			//	the other map's tiles, and all tiles on error, should remain
+			expectedHit := name != tc.mapName || tc.expectedErr != nil
+
+			for _, k := range keys(name) {
+				k := k
+				_, hit, err := tc.cacher.Get(&k)
+				if err != nil {
+					t.Fatalf("err getting key (%v): %v", k, err)
+				}
+				if hit != expectedHit {
+					t.Errorf("key (%v) hit, expected %v got %v", k, expectedHit, hit)
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"purge map": {
+			cacher:  memory.New(),
+			mapName: "a",
+		},
+		"unsupported cache": {
+			//	embedding the interface hides the memory cache's PurgeMap
+			cacher:      struct{ cache.Interface }{memory.New()},
+			mapName:     "a",
+			expectedErr: atlas.ErrPurgeUnsupported{MapName: "a"},
+		},
+		"missing map name": {
+			cacher:      memory.New(),
+			expectedErr: atlas.ErrMissingMapName,
+		},
+		"map not registered": {
+			cacher:      memory.New(),
+			mapName:     "c",
+			expectedErr: atlas.ErrMapNotFound{Name: "c"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// healthCheckProvider is a test provider which reports the configured health check error
+type healthCheckProvider struct {
+	test.TileProvider
+	err error
+}
+
+func (p *healthCheckProvider) HealthCheck(ctx context.Context) error { return p.err }
+
+func TestAtlasHealthCheck(t *testing.T) {
+	errUnreachable := errors.New("unreachable")
+
+	a := &atlas.Atlas{}
+	a.RegisterProvider("healthy", &healthCheckProvider{})
+	a.RegisterProvider("failing", &healthCheckProvider{err: errUnreachable})
+	a.RegisterProvider("unchecked", &test.TileProvider{})
+
+	m := atlas.NewWebMercatorMap("health")
+	m.Layers = []atlas.Layer{
+		{
+			Name:         "registered",
+			ProviderName: "failing",
+		},
+		{
+			Name:     "unregistered",
+			Provider: &healthCheckProvider{err: errUnreachable},
+		},
+	}
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+
+	expected := map[string]error{
+		"healthy":             nil,
+		"failing":             errUnreachable,
+		"unchecked":           nil,
+		"health/unregistered": errUnreachable,
+	}
+
+	output := a.HealthCheck(context.Background())
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("health check, expected %v got %v", expected, output)
+	}
+}
+
+//	run with -race. the checks of the checked providers run while the unchecked ones are reported
+func TestAtlasHealthCheckConcurrent(t *testing.T) {
+	errUnreachable := errors.New("unreachable")
+
+	a := &atlas.Atlas{}
+	expected := map[string]error{}
+	for i := 0; i < 50; i++ {
+		checked := fmt.Sprintf("checked-%v", i)
+		a.RegisterProvider(checked, &healthCheckProvider{err: errUnreachable})
+		expected[checked] = errUnreachable
+
+		unchecked := fmt.Sprintf("unchecked-%v", i)
+		a.RegisterProvider(unchecked, &test.TileProvider{})
+		expected[unchecked] = nil
+	}
+
+	for i := 0; i < 10; i++ {
+		output := a.HealthCheck(context.Background())
+		if !reflect.DeepEqual(output, expected) {
+			t.Fatalf("[%v] health check, expected %v got %v", i, expected, output)
+		}
+	}
+}
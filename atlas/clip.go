@@ -0,0 +1,98 @@
+package atlas
+
+import (
+	"context"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/internal/convert"
+	"github.com/go-spatial/tegola/maths/points"
+	"github.com/go-spatial/tegola/maths/validate"
+)
+
+//	clipGeometry intersects a geometry with the clip extent. a nil geometry
+//	is returned when the geometry lies entirely outside of the extent
+func clipGeometry(ctx context.Context, geo tegola.Geometry, extent geom.BoundingBox) (tegola.Geometry, error) {
+	switch g := geo.(type) {
+	case tegola.Point:
+		if !extent.Contains([2]float64{g.X(), g.Y()}) {
+			return nil, nil
+		}
+		return g, nil
+
+	case tegola.MultiPoint:
+		var mp basic.MultiPoint
+		for _, pt := range g.Points() {
+			if extent.Contains([2]float64{pt.X(), pt.Y()}) {
+				mp = append(mp, basic.Point{pt.X(), pt.Y()})
+			}
+		}
+		if len(mp) == 0 {
+			return nil, nil
+		}
+		return mp, nil
+
+	case tegola.LineString, tegola.MultiLine, tegola.Polygon, tegola.MultiPolygon:
+		ext := points.Extent(extent)
+
+		clipped, err := validate.CleanGeometry(ctx, geo, &ext)
+		if err != nil {
+			return nil, err
+		}
+
+		//	check if anything is left after clipping
+		switch c := clipped.(type) {
+		case tegola.MultiLine:
+			if len(c.Lines()) == 0 {
+				return nil, nil
+			}
+		case tegola.MultiPolygon:
+			if len(c.Polygons()) == 0 {
+				return nil, nil
+			}
+		}
+
+		return clipped, nil
+
+	default:
+		return geo, nil
+	}
+}
+
+//	clipToTile cuts the geometry at the tile's buffered extent using the geom package's clip routines.
+//	a nil geometry is returned when the geometry lies entirely outside of the extent. geometries the
+//	geom package can't represent are returned unclipped
+func clipToTile(geo tegola.Geometry, extent geom.BoundingBox) (tegola.Geometry, error) {
+	g, err := convert.ToGeom(geo)
+	if err != nil {
+		return geo, nil
+	}
+
+	clipped, err := geom.Clip(g, extent)
+	if err != nil {
+		return nil, err
+	}
+	if clipped == nil {
+		return nil, nil
+	}
+
+	return convert.ToTegola(clipped)
+}
+
+//	oversized reports if the geometry's bounding box is wider or taller than ratio times the extent.
+//	geometries the geom package can't represent are not oversized
+func oversized(geo tegola.Geometry, extent geom.BoundingBox, ratio float64) bool {
+	g, err := convert.ToGeom(geo)
+	if err != nil {
+		return false
+	}
+
+	bbox, err := geom.BBoxOf(g)
+	if err != nil {
+		return false
+	}
+
+	return bbox.MaxX()-bbox.MinX() > ratio*(extent.MaxX()-extent.MinX()) ||
+		bbox.MaxY()-bbox.MinY() > ratio*(extent.MaxY()-extent.MinY())
+}
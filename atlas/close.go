@@ -0,0 +1,92 @@
+package atlas
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	RemoveMap unregisters the map by name. the providers set on the map's layers which implement io.Closer,
+//	such as the gpkg provider, are closed if no other map references them. providers registered with
+//	RegisterProvider are left registered and open, they're closed by whoever registered them. copies
+//	of the map returned before it's removed keep its providers, the renders of a copy whose provider
+//	is closed fail with the provider's error
+func (a *Atlas) RemoveMap(mapName string) error {
+	a.Lock()
+	defer a.Unlock()
+
+	m, ok := a.maps[mapName]
+	if !ok {
+		return ErrMapNotFound{
+			Name: mapName,
+		}
+	}
+
+	delete(a.maps, mapName)
+	a.closeUnreferenced([]Map{m})
+
+	return nil
+}
+
+//	closeUnreferenced closes the providers of the layers of the maps which are not referenced by the
+//	registered maps. the registered providers, and the layer providers wrapping them, are never closed.
+//	close errors are logged. the caller must hold the lock.
+func (a *Atlas) closeUnreferenced(maps []Map) {
+	referenced := map[io.Closer]struct{}{}
+	for _, p := range a.providers {
+		if c, ok := providerCloser(p); ok {
+			referenced[c] = struct{}{}
+		}
+	}
+	for _, m := range a.maps {
+		for _, l := range m.Layers {
+			if c, ok := providerCloser(l.Provider); ok {
+				referenced[c] = struct{}{}
+			}
+		}
+	}
+
+	for _, m := range maps {
+		for _, l := range m.Layers {
+			c, ok := providerCloser(l.Provider)
+			if !ok {
+				continue
+			}
+			if _, ok := referenced[c]; ok {
+				continue
+			}
+			//	the provider can be shared by several layers of the maps so it's only closed once
+			referenced[c] = struct{}{}
+
+			if err := c.Close(); err != nil {
+				log.Errorf("map (%v) layer (%v): err closing provider: %v", m.Name, l.MVTName(), err)
+			}
+		}
+	}
+}
+
+//	providerCloser returns the provider wrapped by the atlas's retries and feature cache as an
+//	io.Closer, if it implements it. providers which can't be compared, and so can't be tracked, are
+//	never closed
+func providerCloser(p provider.Tiler) (io.Closer, bool) {
+	c, ok := unwrapProvider(p).(io.Closer)
+	if !ok || !reflect.TypeOf(c).Comparable() {
+		return nil, false
+	}
+
+	return c, true
+}
+
+//	unwrapProvider returns the provider wrapped by the atlas's retries and feature cache
+func unwrapProvider(p provider.Tiler) provider.Tiler {
+	switch w := p.(type) {
+	case cachingProvider:
+		return unwrapProvider(w.Tiler)
+	case retryingProvider:
+		return unwrapProvider(w.Tiler)
+	default:
+		return p
+	}
+}
@@ -0,0 +1,37 @@
+package atlas
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+//	featureHash hashes a feature's geometry and tags so duplicate features can be detected. the
+//	feature ID is not hashed as overlapping sources assign their own IDs. tags are hashed in key
+//	order so the hash doesn't depend on map iteration order.
+func featureHash(f *mvt.Feature) (uint64, error) {
+	//	clone the geometry into the basic types so equal geometries from different
+	//	tegola.Geometry implementations hash the same
+	g, err := basic.CloneGeometry(f.Geometry)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%T%v", g.Geometry, g.Geometry)
+
+	keys := make([]string, 0, len(f.Tags))
+	for k := range f.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(h, "\x00%q=%T%v", k, f.Tags[k], f.Tags[k])
+	}
+
+	return h.Sum64(), nil
+}
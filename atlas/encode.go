@@ -0,0 +1,64 @@
+package atlas
+
+import (
+	"context"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	EncodeFeatures encodes the tile at z, x, y (addressed using the map's Scheme) from the given features
+//	instead of querying the layers' providers. layerFeatures is keyed by the layers' MVT names and only
+//	the layers with features are encoded. the features go through the same reprojection, clipping and
+//	encoding as provider features, using the atlas's tile extent if the map does not set one.
+func (a *Atlas) EncodeFeatures(m Map, z, x, y uint, layerFeatures map[string][]provider.Feature) ([]byte, error) {
+	a.RLock()
+	m = a.applyTileExtent(m)
+	a.RUnlock()
+
+	//	make an explict copy of the layers so we don't modify the caller's map
+	layers := make([]Layer, len(m.Layers))
+	copy(layers, m.Layers)
+	m.Layers = layers
+
+	names := make([]string, 0, len(layerFeatures))
+	for name := range layerFeatures {
+		names = append(names, name)
+	}
+
+	for i := range m.Layers {
+		features, ok := layerFeatures[m.Layers[i].MVTName()]
+		if !ok {
+			continue
+		}
+
+		m.Layers[i].Provider = staticProvider{features: features}
+		//	the features are served as is so there is no query to filter
+		m.Layers[i].SQLFilter = ""
+	}
+
+	return m.RenderTileLayers(context.Background(), uint64(z), uint64(x), uint64(y), names)
+}
+
+//	EncodeFeatures encodes a tile from the given features using DefaultAtlas. see Atlas.EncodeFeatures
+func EncodeFeatures(m Map, z, x, y uint, layerFeatures map[string][]provider.Feature) ([]byte, error) {
+	return DefaultAtlas.EncodeFeatures(m, z, x, y, layerFeatures)
+}
+
+//	staticProvider serves the same features for every tile
+type staticProvider struct {
+	features []provider.Feature
+}
+
+func (staticProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p staticProvider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	for i := range p.features {
+		//	copy the feature so fn can't modify the caller's feature
+		f := p.features[i]
+		if err := fn(&f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,11 +3,16 @@ package atlas
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
 	ErrMissingCache = errors.New("atlas: missing cache")
 	ErrMissingTile  = errors.New("atlas: missing tile")
+	//	ErrMissingMapName is returned when a map without a name is reloaded or purged
+	ErrMissingMapName = errors.New("atlas: missing map name")
+	//	ErrMissingBounds is returned when the tiles of nil bounds are requested
+	ErrMissingBounds = errors.New("atlas: missing bounds")
 )
 
 type ErrMapNotFound struct {
@@ -17,3 +22,128 @@ type ErrMapNotFound struct {
 func (e ErrMapNotFound) Error() string {
 	return fmt.Sprintf("atlas: map (%v) not found", e.Name)
 }
+
+type ErrPurgeUnsupported struct {
+	MapName string
+}
+
+func (e ErrPurgeUnsupported) Error() string {
+	return fmt.Sprintf("atlas: cache does not support purging map (%v)", e.MapName)
+}
+
+type ErrLayerNotFound struct {
+	MapName   string
+	LayerName string
+}
+
+func (e ErrLayerNotFound) Error() string {
+	return fmt.Sprintf("atlas: map (%v) layer (%v) not found", e.MapName, e.LayerName)
+}
+
+type ErrProviderNotFound struct {
+	Name string
+}
+
+func (e ErrProviderNotFound) Error() string {
+	return fmt.Sprintf("atlas: provider (%v) not found", e.Name)
+}
+
+type ErrDuplicateMapName struct {
+	Name string
+}
+
+func (e ErrDuplicateMapName) Error() string {
+	return fmt.Sprintf("atlas: duplicate map name (%v)", e.Name)
+}
+
+type ErrSQLFilterUnsupported struct {
+	LayerName string
+}
+
+func (e ErrSQLFilterUnsupported) Error() string {
+	return fmt.Sprintf("atlas: layer (%v) has a sql filter but its provider does not support sql filters", e.LayerName)
+}
+
+type ErrInvalidLayerVersion struct {
+	LayerName string
+	Version   uint
+}
+
+func (e ErrInvalidLayerVersion) Error() string {
+	return fmt.Sprintf("atlas: layer (%v) has an invalid MVT version (%v), expected 1 or 2", e.LayerName, e.Version)
+}
+
+//	ErrInvalidCoordinates is returned when a feature of a layer with FailOnInvalidCoordinates has NaN
+//	or infinite coordinates
+type ErrInvalidCoordinates struct {
+	LayerName string
+	FeatureID uint64
+}
+
+func (e ErrInvalidCoordinates) Error() string {
+	return fmt.Sprintf("atlas: layer (%v) feature %v has NaN or infinite coordinates", e.LayerName, e.FeatureID)
+}
+
+type ErrInvalidTileExtent struct {
+	Extent uint
+}
+
+func (e ErrInvalidTileExtent) Error() string {
+	return fmt.Sprintf("atlas: tile extent (%v) is not a power of two", e.Extent)
+}
+
+//	ErrProviderPanic is returned when a provider panics while its features are read for a tile
+type ErrProviderPanic struct {
+	LayerName string
+	//	the value recovered from the panic
+	Value interface{}
+	//	the stack of the panicking goroutine
+	Stack []byte
+}
+
+func (e ErrProviderPanic) Error() string {
+	return fmt.Sprintf("atlas: provider of layer (%v) panicked: %v", e.LayerName, e.Value)
+}
+
+//	ErrInvalidTimeWindow is returned for a time window which can not be parsed
+type ErrInvalidTimeWindow struct {
+	Value string
+}
+
+func (e ErrInvalidTimeWindow) Error() string {
+	return fmt.Sprintf("atlas: invalid time window (%v), expected RFC 3339 timestamps in the form start/end", e.Value)
+}
+
+//	ErrInvalidTile is returned for tile coordinates outside of the tile grid
+type ErrInvalidTile struct {
+	Tile TileCoord
+}
+
+func (e ErrInvalidTile) Error() string {
+	return fmt.Sprintf("atlas: invalid tile (z: %v, x: %v, y: %v)", e.Tile.Z, e.Tile.X, e.Tile.Y)
+}
+
+//	ErrInvalidZoomRange is returned for a zoom range whose min zoom is greater than its max zoom
+//	or whose max zoom is greater than MaxZoom
+type ErrInvalidZoomRange struct {
+	MinZoom, MaxZoom uint
+}
+
+func (e ErrInvalidZoomRange) Error() string {
+	return fmt.Sprintf("atlas: invalid zoom range (min: %v, max: %v)", e.MinZoom, e.MaxZoom)
+}
+
+//	ErrSeedTiles reports the tiles which failed to seed and the error of each
+type ErrSeedTiles struct {
+	Tiles []TileCoord
+	Errs  []error
+}
+
+func (e ErrSeedTiles) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i := range e.Errs {
+		msgs[i] = fmt.Sprintf("(z: %v, x: %v, y: %v): %v", e.Tiles[i].Z, e.Tiles[i].X, e.Tiles[i].Y, e.Errs[i])
+	}
+
+	return fmt.Sprintf("atlas: %v tiles failed to seed: %v", len(e.Errs), strings.Join(msgs, "; "))
+}
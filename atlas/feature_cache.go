@@ -0,0 +1,160 @@
+package atlas
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	featureCacheKey identifies the features a provider layer returns for a tile
+type featureCacheKey struct {
+	provider string
+	layer    string
+	extent   [2][2]float64
+	zoom     uint64
+}
+
+type featureCacheEntry struct {
+	key      featureCacheKey
+	features []provider.Feature
+	expires  time.Time
+}
+
+//	featureCache is a size and time limited, least recently used cache of provider query results
+type featureCache struct {
+	sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	//	the most recently used entry is at the front
+	order   *list.List
+	entries map[featureCacheKey]*list.Element
+}
+
+func newFeatureCache(maxEntries int, ttl time.Duration) *featureCache {
+	return &featureCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    map[featureCacheKey]*list.Element{},
+	}
+}
+
+func (c *featureCache) get(key featureCacheKey) ([]provider.Feature, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*featureCacheEntry)
+	if !time.Now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.features, true
+}
+
+func (c *featureCache) set(key featureCacheKey, features []provider.Feature) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry := &featureCacheEntry{
+		key:      key,
+		features: features,
+		expires:  time.Now().Add(c.ttl),
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+
+	//	evict the least recently used entries
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*featureCacheEntry).key)
+	}
+}
+
+//	cachingProvider serves a registered provider's features from the atlas's feature cache
+type cachingProvider struct {
+	provider.Tiler
+	//	the name the provider is registered under
+	name  string
+	cache *featureCache
+}
+
+//	SRID returns the SRID the wrapped provider declares for the layer, 0 if it doesn't implement provider.SRIDer
+func (p cachingProvider) SRID(layer string) uint64 {
+	if s, ok := p.Tiler.(provider.SRIDer); ok {
+		return s.SRID(layer)
+	}
+	return 0
+}
+
+//	TileFeatures streams the cached features of the layer for the tile to fn. on a cache miss the
+//	features are streamed to fn as they are read from the provider and are cached once all of them
+//	are read. the features are not cached if the provider or fn fails part way. fn is passed a copy
+//	of the feature and its tags, so fn can modify them, but shares the geometry with the cache so
+//	the geometry must not be modified in place
+func (p cachingProvider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	extent, _ := tile.BufferedExtent()
+	z, _, _ := tile.ZXY()
+
+	key := featureCacheKey{
+		provider: p.name,
+		layer:    layer,
+		extent:   extent,
+		zoom:     z,
+	}
+
+	features, ok := p.cache.get(key)
+	if !ok {
+		err := p.Tiler.TileFeatures(ctx, layer, tile, func(f *provider.Feature) error {
+			//	the cached feature is recorded before fn can modify the feature
+			features = append(features, copyFeature(f))
+			return fn(f)
+		})
+		if err != nil {
+			return err
+		}
+
+		p.cache.set(key, features)
+		return nil
+	}
+
+	for i := range features {
+		f := copyFeature(&features[i])
+		if err := fn(&f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//	copyFeature returns a copy of the feature with its own tags. the geometry is shared
+func copyFeature(f *provider.Feature) provider.Feature {
+	c := *f
+	if f.Tags != nil {
+		c.Tags = make(map[string]interface{}, len(f.Tags))
+		for k, v := range f.Tags {
+			c.Tags[k] = v
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,122 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	GeomTypeSampleSize is the number of features read to detect the geometry type of a layer with
+//	DetectGeomType
+const GeomTypeSampleSize = 100
+
+//	GeomTypeDetectTimeout bounds the time taken to read the sample of a layer with DetectGeomType
+const GeomTypeDetectTimeout = 30 * time.Second
+
+//	errSampleFull stops reading the features once the sample is full
+var errSampleFull = errors.New("atlas: geometry type sample is full")
+
+//	geomTypes are the geometries set as the detected GeomType, keyed by the name of their type
+var geomTypes = map[string]geom.Geometry{
+	"Point":              geom.Point{},
+	"MultiPoint":         geom.MultiPoint{},
+	"LineString":         geom.LineString{},
+	"MultiLineString":    geom.MultiLineString{},
+	"Polygon":            geom.Polygon{},
+	"MultiPolygon":       geom.MultiPolygon{},
+	"GeometryCollection": geom.Collection{},
+}
+
+//	detectGeomType reads a sample of the layer's features in the world tile and returns an empty
+//	geometry of the predominant type of the sample. ties go to the type read first. nil is returned
+//	if the sample has no features of a known type
+func detectGeomType(ctx context.Context, l Layer, srid uint64) (geom.Geometry, error) {
+	var (
+		counts = map[string]int{}
+		order  []string
+		read   int
+	)
+
+	err := l.Provider.TileFeatures(ctx, l.ProviderLayerName, slippy.NewTile(0, 0, 0, 0, srid), func(f *provider.Feature) error {
+		if name := geomTypeName(f.Geometry); name != "" {
+			if counts[name] == 0 {
+				order = append(order, name)
+			}
+			counts[name]++
+		}
+
+		read++
+		if read >= GeomTypeSampleSize {
+			return errSampleFull
+		}
+		return nil
+	})
+	if err != nil && err != errSampleFull {
+		return nil, err
+	}
+
+	var predominant string
+	for _, name := range order {
+		if counts[name] > counts[predominant] {
+			predominant = name
+		}
+	}
+
+	return geomTypes[predominant], nil
+}
+
+//	detectGeomTypes returns a copy of the map with the GeomType of the layers with DetectGeomType and
+//	no GeomType detected. layers referencing a provider by ProviderName are sampled from the registered
+//	provider, not through the provider retries and the feature cache, so the sample is not cached. the
+//	providers are read without holding the lock and each sample is bounded by GeomTypeDetectTimeout.
+//	a layer whose type can't be detected is left without a GeomType
+func (a *Atlas) detectGeomTypes(m Map) Map {
+	//	make an explict copy of the layers so we don't modify the caller's map
+	layers := make([]Layer, len(m.Layers))
+	copy(layers, m.Layers)
+	m.Layers = layers
+
+	for i := range m.Layers {
+		l := m.Layers[i]
+		if !l.DetectGeomType || l.GeomType != nil {
+			continue
+		}
+
+		if l.ProviderName != "" {
+			a.RLock()
+			l.Provider = a.providers[l.ProviderName]
+			a.RUnlock()
+		}
+		if l.Provider == nil {
+			continue
+		}
+		l.Provider = unwrapProvider(l.Provider)
+
+		//	the features of providers serving encoded tiles are not decoded
+		if _, ok := l.Provider.(provider.MVTTiler); ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), GeomTypeDetectTimeout)
+		g, err := detectGeomType(ctx, l, m.SRID)
+		cancel()
+		if err != nil {
+			log.Warnf("map (%v) layer (%v): unable to detect the geometry type: %v", m.Name, l.MVTName(), err)
+			continue
+		}
+		if g == nil {
+			log.Warnf("map (%v) layer (%v): unable to detect the geometry type: no features in the sample", m.Name, l.MVTName())
+			continue
+		}
+
+		log.Infof("map (%v) layer (%v): detected geometry type %v", m.Name, l.MVTName(), geomTypeName(g))
+		m.Layers[i].GeomType = g
+	}
+
+	return m
+}
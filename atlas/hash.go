@@ -0,0 +1,85 @@
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+//	hashedMap is the part of a Map's config which changes how its tiles are encoded
+type hashedMap struct {
+	Map
+	Layers []hashedLayer
+}
+
+//	hashedLayer is a Layer whose provider, geometry type and simplifier are identified by their type.
+//	the Provider and GeomType fields replace the fields of the Layer
+type hashedLayer struct {
+	Layer
+	Provider       string
+	GeomType       string
+	SimplifierType string
+}
+
+//	Hash returns a hash of the map's config which changes when the config changes how the map's
+//	tiles are encoded, such as a layer's name, zoom range, default tags, filter or simplification.
+//	the fields which don't change the tiles, such as the Attribution, Center or TileURLs, are left
+//	out. the providers are identified by their name and type, so a change of a provider's config
+//	(i.e. the SQL of a provider layer) does not change the hash
+func (m Map) Hash() string {
+	hm := hashedMap{
+		Map:    m,
+		Layers: make([]hashedLayer, len(m.Layers)),
+	}
+
+	//	the fields which don't change the encoded tiles
+	hm.Attribution = ""
+	hm.Bounds = [4]float64{}
+	hm.Center = [3]float64{}
+	hm.Scheme = ""
+	hm.LayerConcurrency = 0
+	hm.TileURLs = nil
+	hm.HashCacheKeys = false
+
+	for i, l := range m.Layers {
+		hm.Layers[i] = hashedLayer{
+			Layer:          l,
+			Provider:       fmt.Sprintf("%T", l.Provider),
+			GeomType:       fmt.Sprintf("%T", l.GeomType),
+			SimplifierType: fmt.Sprintf("%T", l.Simplifier),
+		}
+	}
+
+	h := fnv.New64a()
+
+	//	map keys are encoded in sorted order so the hash of the same config is always the same
+	b, err := json.Marshal(hm)
+	if err != nil {
+		log.Warnf("unable to encode the config of map (%v) for its hash: %v", m.Name, err)
+		fmt.Fprintf(h, "%v", hm)
+	} else {
+		h.Write(b)
+	}
+
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+//	CacheNamespace returns the namespace the map's tiles are cached under, the Hash of the map when
+//	it was added to the atlas if HashCacheKeys is set. empty for maps which aren't namespaced
+func (m Map) CacheNamespace() string {
+	return m.cacheNamespace
+}
+
+//	cacheKey returns the cache key of the map's tile at the XYZ tile coordinates
+func (m Map) cacheKey(z, x, y uint64) cache.Key {
+	return cache.Key{
+		MapName:   m.Name,
+		Namespace: m.cacheNamespace,
+		Z:         int(z),
+		X:         int(x),
+		Y:         int(y),
+	}
+}
@@ -0,0 +1,121 @@
+package atlas_test
+
+import (
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/mvt"
+)
+
+func TestMapHash(t *testing.T) {
+	type tcase struct {
+		// changes the config of the map
+		change func(m *atlas.Map)
+		// the hash is expected to change
+		expected bool
+	}
+
+	newMap := func() atlas.Map {
+		m := atlas.NewWebMercatorMap("hashed")
+		m.Attribution = "tegola"
+		m.Layers = []atlas.Layer{
+			{
+				Name:              "roads",
+				ProviderLayerName: "roads",
+				ProviderName:      "postgis",
+				MinZoom:           4,
+				MaxZoom:           12,
+				DefaultTags:       map[string]interface{}{"class": "road", "lanes": 2},
+				SQLFilter:         "highway IS NOT NULL",
+			},
+		}
+		return m
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := newMap()
+		hash := m.Hash()
+
+		// the hash of the same config is always the same
+		if again := newMap().Hash(); again != hash {
+			t.Fatalf("hash, expected %v for the same config got %v", hash, again)
+		}
+
+		tc.change(&m)
+		if changed := m.Hash() != hash; changed != tc.expected {
+			t.Errorf("hash changed, expected %v got %v", tc.expected, changed)
+		}
+	}
+
+	tests := map[string]tcase{
+		"simplification": {
+			change:   func(m *atlas.Map) { m.Layers[0].DontSimplify = true },
+			expected: true,
+		},
+		"simplifier": {
+			change:   func(m *atlas.Map) { m.Layers[0].Simplifier = mvt.DouglasPeucker{} },
+			expected: true,
+		},
+		"zoom range": {
+			change:   func(m *atlas.Map) { m.Layers[0].MaxZoom = 14 },
+			expected: true,
+		},
+		"default tags": {
+			change:   func(m *atlas.Map) { m.Layers[0].DefaultTags["lanes"] = 4 },
+			expected: true,
+		},
+		"filter": {
+			change:   func(m *atlas.Map) { m.Layers[0].SQLFilter = "highway = 'primary'" },
+			expected: true,
+		},
+		"layer name": {
+			change:   func(m *atlas.Map) { m.Layers[0].Name = "streets" },
+			expected: true,
+		},
+		"attribution": {
+			change: func(m *atlas.Map) { m.Attribution = "© tegola contributors" },
+		},
+		"center": {
+			change: func(m *atlas.Map) { m.Center = [3]float64{23.7, 37.9, 10} },
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestHashCacheKeys(t *testing.T) {
+	a := &atlas.Atlas{}
+
+	m := atlas.NewWebMercatorMap("hashed")
+	m.HashCacheKeys = true
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+
+	added, err := a.Map("hashed")
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+
+	// the namespace is the hash of the map as it was added, it's kept by copies of the map
+	if ns := added.FilterLayersByZoom(10).CacheNamespace(); ns != m.Hash() {
+		t.Errorf("cache namespace, expected %v got %v", m.Hash(), ns)
+	}
+
+	// maps are not namespaced by default
+	m.Name, m.HashCacheKeys = "unhashed", false
+	if err = a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	if added, err = a.Map("unhashed"); err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+	if ns := added.CacheNamespace(); ns != "" {
+		t.Errorf("cache namespace, expected none got %v", ns)
+	}
+}
@@ -0,0 +1,66 @@
+package atlas
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	HealthCheck runs the health check of each provider implementing provider.HealthChecker concurrently.
+//	the results are keyed by the registered provider name. layer providers which were not registered by
+//	name are keyed by "map name/layer name". providers which do not implement provider.HealthChecker
+//	are reported as healthy with a nil error.
+func (a *Atlas) HealthCheck(ctx context.Context) map[string]error {
+	checks := map[string]provider.Tiler{}
+
+	a.RLock()
+	for name, p := range a.providers {
+		checks[name] = p
+	}
+	for _, m := range a.maps {
+		for _, l := range m.Layers {
+			//	registered providers are already checked
+			if l.ProviderName != "" {
+				continue
+			}
+			checks[fmt.Sprintf("%v/%v", m.Name, l.MVTName())] = l.Provider
+		}
+	}
+	a.RUnlock()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(checks))
+	)
+
+	//	every provider is reported. the entries are added before any check starts so the running
+	//	checks are the only writers of the map
+	for name := range checks {
+		results[name] = nil
+	}
+
+	for name, p := range checks {
+		hc, ok := p.(provider.HealthChecker)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, hc provider.HealthChecker) {
+			defer wg.Done()
+
+			err := hc.HealthCheck(ctx)
+
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, hc)
+	}
+
+	wg.Wait()
+
+	return results
+}
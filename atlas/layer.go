@@ -1,7 +1,11 @@
 package atlas
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/mvt"
 	"github.com/go-spatial/tegola/provider"
 )
 
@@ -11,21 +15,202 @@ type Layer struct {
 	ProviderLayerName string
 	MinZoom           int
 	MaxZoom           int
+	//	optional. render the layer at every zoom, ignoring MinZoom and MaxZoom. useful for background
+	//	layers which should appear in every tile of the map
+	AlwaysRender bool
 	//	instantiated provider
 	Provider provider.Tiler
+	//	optional. the name of a provider registered with the atlas. when set, the registered
+	//	provider is assigned to Provider when the map is added to the atlas
+	ProviderName string
+	//	optional. the SRID assumed for features the provider returns without an SRID (i.e. a gpkg
+	//	geometry header with an srs_id of 0). features are reprojected from this SRID to the map's SRID
+	SRID uint64
 	//	default tags to include when encoding the layer. provider tags take precedence
 	DefaultTags map[string]interface{}
-	GeomType    geom.Geometry
+	//	optional. default tags keyed by zoom. the set with the highest zoom at or below the requested
+	//	zoom is merged over DefaultTags. provider tags take precedence
+	DefaultTagsByZoom map[uint]map[string]interface{}
+	GeomType          geom.Geometry
 	//	DontSimplify indicates wheather feature simplification should be applied.
 	//	We use a negative in the name so the default is to simplify
 	DontSimplify bool
+	//	optional. the algorithm used to simplify the layer's geometries. defaults to mvt.DefaultSimplifier
+	Simplifier mvt.Simplifier
+	//	optional. how the layer's coordinates are converted to the tile's integer grid. defaults to
+	//	mvt.QuantizeTruncate. mvt.QuantizeNearest keeps the shared edges of adjacent features together
+	Quantization mvt.Quantization
+	//	optional. the version of the MVT spec the layer is encoded with, 1 or 2. defaults to 2. some
+	//	older clients only read version 1 layers
+	Version uint
+	//	optional. the grid size, in the provider's units, coordinates are snapped to before they are
+	//	reprojected. snapping removes the sub unit differences between the coordinates of adjacent
+	//	tiles which render as hairline seams. 0 disables snapping
+	SnapGrid float64
+	//	optional. the maximum segment length, in the provider's units, of features which are reprojected.
+	//	longer segments are split before reprojecting so they follow the path they take in the
+	//	provider's projection. 0 disables densification
+	Densify float64
+	//	optional. repair polygon rings before encoding. explicit closing points are removed and
+	//	rings with fewer than 3 distinct points are dropped, along with the polygon if it's the
+	//	exterior ring. repairs are logged
+	RepairGeometry bool
+	//	optional. drop features with the same geometry and tags as a feature already encoded in the
+	//	tile. useful when overlapping sources return the same feature more than once
+	Dedupe bool
+	//	optional. polygons with an area, in tile units (see Map.TileExtent) at the requested zoom, below
+	//	MinArea are dropped. the area of a feature scales with the zoom so small polygons are dropped at
+	//	low zooms and reappear as the zoom increases. 0 disables the threshold
+	MinArea float64
+	//	optional. the name of a tag used to assign the layer's features to other MVT layers. features
+	//	with a SplitByField value found in SplitLayers are encoded in the named MVT layer, all other
+	//	features in the layer itself. this lets a single provider query produce several MVT layers
+	SplitByField string
+	//	optional. the MVT layer names keyed by the SplitByField value of the features they contain
+	SplitLayers map[string]string
+	//	optional. a SQL predicate (i.e. "highway IS NOT NULL") added to the WHERE clause of the tile
+	//	query. the Provider must implement provider.SQLFilterer
+	SQLFilter string
+	//	optional. the name of a tag holding the feature's timestamp, as an RFC 3339 string or seconds
+	//	since the Unix epoch. when the map is filtered by a TimeWindow (see Map.FilterFeaturesByTime)
+	//	only the features with a timestamp within the window are encoded
+	TimeAttribute string
+	//	optional. fail the layer when a feature has NaN or infinite coordinates, such as decoded from a
+	//	corrupt geometry. by default these features are logged and dropped from the layer
+	FailOnInvalidCoordinates bool
+	//	optional. reverse the vertex order of line strings before encoding. renderers drawing direction
+	//	arrows, i.e. for one way streets, follow the vertex order of the lines
+	ReverseLines bool
+	//	optional. the draw order of the layer in the tile. layers are encoded in increasing ZIndex, the
+	//	first drawn at the bottom. layers with the same ZIndex keep the order they have in the map
+	ZIndex int
+	//	optional. features with a bounding box wider or taller than OversizeRatio times the tile's
+	//	buffered extent are skipped instead of clipped to the tile, i.e. an ocean polygon at high zooms
+	//	where clipping it in every tile is expensive. 0 clips every feature
+	OversizeRatio float64
+	//	optional. when GeomType is nil, detect it from a sample of the layer's features read when the
+	//	map is added to the atlas. the predominant geometry type of the sample is set as the GeomType
+	DetectGeomType bool
+	//	optional. overrides Name as the name the layer is encoded with, i.e. to alias the layer for a
+	//	single request on a copy of the map
+	OutputName string
+	//	optional. new names for the provider's tags keyed by the provider's name, i.e. "osm_highway" to
+	//	"highway". tags not listed keep their name. a renamed tag colliding with another tag of the
+	//	feature is dropped and logged. SplitByField refers to the renamed tags
+	FieldRename map[string]string
+}
+
+//	LayerInfo describes a layer using only its definition so it can be listed without querying the layer's provider
+type LayerInfo struct {
+	Name string
+	//	the name of the layer's geometry type (i.e. "Point"). empty if the layer's GeomType is not set
+	GeomType string
+	MinZoom  uint
+	MaxZoom  uint
+}
+
+//	geomTypeName returns the name of the geometry's type. empty if the type is unknown
+func geomTypeName(g geom.Geometry) string {
+	switch g.(type) {
+	case geom.Point:
+		return "Point"
+	case geom.MultiPoint:
+		return "MultiPoint"
+	case geom.Line, geom.LineString:
+		return "LineString"
+	case geom.MultiLineString:
+		return "MultiLineString"
+	case geom.Polygon:
+		return "Polygon"
+	case geom.MultiPolygon:
+		return "MultiPolygon"
+	case geom.Collection:
+		return "GeometryCollection"
+	default:
+		return ""
+	}
 }
 
 //	MVTName will return the value that will be encoded in the Name field when the layer is encoded as MVT
 func (l *Layer) MVTName() string {
+	if l.OutputName != "" {
+		return l.OutputName
+	}
+
 	if l.Name != "" {
 		return l.Name
 	}
 
 	return l.ProviderLayerName
 }
+
+//	splitLayerName returns the name of the MVT layer SplitByField and SplitLayers assign a feature
+//	with the given tags to. empty if the feature stays in the layer
+func (l *Layer) splitLayerName(tags map[string]interface{}) string {
+	if l.SplitByField == "" {
+		return ""
+	}
+
+	v, ok := tags[l.SplitByField]
+	if !ok {
+		return ""
+	}
+
+	return l.SplitLayers[fmt.Sprint(v)]
+}
+
+//	renameTags adds the tags of src listed in FieldRename to dst under their new names. the tags are
+//	renamed in sorted order so the same tag is kept for every feature. the names of the tags dropped
+//	as they collide with a tag already in dst are returned
+func (l *Layer) renameTags(src, dst map[string]interface{}) (collisions []string) {
+	names := make([]string, 0, len(l.FieldRename))
+	for k := range l.FieldRename {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		v, ok := src[k]
+		if !ok {
+			continue
+		}
+
+		name := l.FieldRename[k]
+		if _, ok := dst[name]; ok {
+			collisions = append(collisions, k)
+			continue
+		}
+		dst[name] = v
+	}
+
+	return collisions
+}
+
+//	DefaultTagsForZoom returns the default tags to apply to the layer's features at the given zoom.
+//	the DefaultTagsByZoom set with the highest zoom at or below the given zoom takes precedence over DefaultTags.
+func (l *Layer) DefaultTagsForZoom(zoom uint) map[string]interface{} {
+	var (
+		zoomTags map[string]interface{}
+		found    bool
+		setZoom  uint
+	)
+	for z, tags := range l.DefaultTagsByZoom {
+		if z <= zoom && (!found || z > setZoom) {
+			zoomTags, setZoom, found = tags, z, true
+		}
+	}
+
+	if !found {
+		return l.DefaultTags
+	}
+
+	tags := make(map[string]interface{}, len(l.DefaultTags)+len(zoomTags))
+	for k, v := range l.DefaultTags {
+		tags[k] = v
+	}
+	for k, v := range zoomTags {
+		tags[k] = v
+	}
+
+	return tags
+}
@@ -1,6 +1,7 @@
 package atlas_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/go-spatial/tegola/atlas"
@@ -19,6 +20,14 @@ func TestLayerMVTName(t *testing.T) {
 			layer:    testLayer2,
 			expected: "test-layer-2-name",
 		},
+		{
+			layer: atlas.Layer{
+				Name:              "internal",
+				ProviderLayerName: "provider-layer",
+				OutputName:        "alias",
+			},
+			expected: "alias",
+		},
 	}
 
 	for i, tc := range testcases {
@@ -28,3 +37,82 @@ func TestLayerMVTName(t *testing.T) {
 		}
 	}
 }
+
+func TestLayerDefaultTagsForZoom(t *testing.T) {
+	layer := atlas.Layer{
+		DefaultTags: map[string]interface{}{
+			"class":  "default",
+			"source": "osm",
+		},
+		DefaultTagsByZoom: map[uint]map[string]interface{}{
+			0: {
+				"class": "coarse",
+			},
+			10: {
+				"class": "detailed",
+			},
+		},
+	}
+
+	type tcase struct {
+		layer    atlas.Layer
+		zoom     uint
+		expected map[string]interface{}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		output := tc.layer.DefaultTagsForZoom(tc.zoom)
+		if !reflect.DeepEqual(output, tc.expected) {
+			t.Errorf("default tags, expected %v got %v", tc.expected, output)
+		}
+	}
+
+	tests := map[string]tcase{
+		"z5": {
+			layer: layer,
+			zoom:  5,
+			expected: map[string]interface{}{
+				"class":  "coarse",
+				"source": "osm",
+			},
+		},
+		"z15": {
+			layer: layer,
+			zoom:  15,
+			expected: map[string]interface{}{
+				"class":  "detailed",
+				"source": "osm",
+			},
+		},
+		"no zoom sets": {
+			layer: testLayer1,
+			zoom:  5,
+			expected: map[string]interface{}{
+				"foo": "bar",
+			},
+		},
+		"fall back to default tags": {
+			layer: atlas.Layer{
+				DefaultTags: map[string]interface{}{
+					"class": "default",
+				},
+				DefaultTagsByZoom: map[uint]map[string]interface{}{
+					10: {
+						"class": "detailed",
+					},
+				},
+			},
+			zoom: 5,
+			expected: map[string]interface{}{
+				"class": "default",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
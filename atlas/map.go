@@ -3,9 +3,12 @@ package atlas
 import (
 	"context"
 	"fmt"
-	"log"
+	"reflect"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
@@ -14,11 +17,20 @@ import (
 	"github.com/go-spatial/tegola/geom"
 	"github.com/go-spatial/tegola/geom/slippy"
 	"github.com/go-spatial/tegola/internal/convert"
+	"github.com/go-spatial/tegola/internal/log"
 	"github.com/go-spatial/tegola/mvt"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/debug"
 )
 
+//	supported tile addressing schemes
+const (
+	//	TileSchemeXYZ addresses tiles with y increasing from the top (north) of the map. This is the default.
+	TileSchemeXYZ = "xyz"
+	//	TileSchemeTMS addresses tiles with y increasing from the bottom (south) of the map.
+	TileSchemeTMS = "tms"
+)
+
 //	NewMap creates a new map with the necessary default values
 func NewWebMercatorMap(name string) Map {
 	return Map{
@@ -27,7 +39,6 @@ func NewWebMercatorMap(name string) Map {
 		Bounds:     tegola.WGS84Bounds,
 		Layers:     []Layer{},
 		SRID:       tegola.WebMercator,
-		TileExtent: 4096,
 		TileBuffer: 64,
 	}
 }
@@ -48,8 +59,52 @@ type Map struct {
 
 	SRID uint64
 	//	MVT output values
+	//	TileExtent is the number of units along each edge of the encoded tiles. when 0 the tile extent
+	//	of the atlas the map is fetched from is used, which defaults to 4096
 	TileExtent uint64
 	TileBuffer uint64
+	//	Scheme is the tile addressing scheme clients use for the y coordinate. Either "xyz" or "tms".
+	//	Default: xyz
+	Scheme string
+	//	ClipExtent is an optional mask, in the map's SRID, which all encoded geometries are clipped to.
+	//	features entirely outside of the extent are dropped.
+	ClipExtent *geom.BoundingBox
+	//	OmitEmptyTiles encodes a tile without any features to nothing (nil) rather than a valid MVT
+	//	containing the map's layers with zero features. omitted tiles are not cached
+	OmitEmptyTiles bool
+	//	OverZoom renders layers at the zooms beyond their MaxZoom from the features of the ancestor tile
+	//	at their MaxZoom, clipped to the requested tile. When false, layers are not rendered beyond their MaxZoom.
+	OverZoom bool
+	//	LayerConcurrency is the maximum number of provider queries run concurrently for a tile. the
+	//	layers sharing a provider layer are read with a single query. 0 runs all of the queries concurrently
+	LayerConcurrency int
+	//	TileURLs are the base URLs, such as "https://a.tiles.example.com", the map's tiles are advertised
+	//	from in its TileJSON, for sharding the tile requests across hosts. when empty the tiles are
+	//	advertised from the host serving the TileJSON
+	TileURLs []string
+	//	HashCacheKeys caches the map's tiles under the map's Hash (see CacheNamespace), so the tiles
+	//	cached before a change of the map's config are not served once the config is changed
+	HashCacheKeys bool
+
+	//	the namespace of the map's cached tiles, set by the atlas the map is added to
+	cacheNamespace string
+	//	the window the features of layers with a TimeAttribute are filtered to. see FilterFeaturesByTime
+	timeWindow *TimeWindow
+	//	replaces the simplification of the map's layers. see OverrideSimplify
+	simplify *SimplifyOverride
+	//	the receiver of the map's render and provider query events, set by the atlas the map is added to
+	metrics Metrics
+}
+
+// ToXYZ converts tile coordinates addressed using the map's Scheme into XYZ tile coordinates.
+// XYZ tile coordinates are used for computing the tile's extent and the tile's cache key.
+func (m Map) ToXYZ(z, x, y uint64) (uint64, uint64, uint64) {
+	if m.Scheme == TileSchemeTMS {
+		// the y axis is flipped
+		y = (1 << z) - 1 - y
+	}
+
+	return z, x, y
 }
 
 // AddDebugLayers returns a copy of a Map with the debug layers appended to the layer list
@@ -84,11 +139,23 @@ func (m Map) AddDebugLayers() Map {
 	return m
 }
 
-// FilterLayersByZoom returns a copy of a Map with a subset of layers that match the given zoom
+// FilterLayersByZoom returns a copy of a Map with a subset of layers that match the given zoom.
+// layers with AlwaysRender set match every zoom. when the map has OverZoom set, layers match every
+// zoom beyond their MaxZoom
 func (m Map) FilterLayersByZoom(zoom int) Map {
 	var layers []Layer
 
 	for i := range m.Layers {
+		if m.Layers[i].AlwaysRender {
+			layers = append(layers, m.Layers[i])
+			continue
+		}
+
+		if m.OverZoom && m.Layers[i].MaxZoom != 0 && zoom > m.Layers[i].MaxZoom && m.Layers[i].MinZoom <= zoom {
+			layers = append(layers, m.Layers[i])
+			continue
+		}
+
 		if (m.Layers[i].MinZoom <= zoom || m.Layers[i].MinZoom == 0) && (m.Layers[i].MaxZoom >= zoom || m.Layers[i].MaxZoom == 0) {
 			layers = append(layers, m.Layers[i])
 			continue
@@ -123,8 +190,89 @@ func (m Map) FilterLayersByName(names ...string) Map {
 	return m
 }
 
-//	TODO (arolek): support for max zoom
+//	LayerInfo returns the name, geometry type and zoom range of each of the map's layers. the
+//	values come from the layer definitions so the providers are not queried.
+func (m Map) LayerInfo() []LayerInfo {
+	infos := make([]LayerInfo, 0, len(m.Layers))
+	for i := range m.Layers {
+		info := LayerInfo{
+			Name:     m.Layers[i].MVTName(),
+			GeomType: geomTypeName(m.Layers[i].GeomType),
+			MinZoom:  uint(m.Layers[i].MinZoom),
+			MaxZoom:  uint(m.Layers[i].MaxZoom),
+		}
+
+		//	the layer is rendered at every zoom
+		if m.Layers[i].AlwaysRender {
+			info.MinZoom, info.MaxZoom = 0, MaxZoom
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+//	RenderTileLayers encodes the tile at z, x, y (addressed using the map's Scheme) with only the
+//	named layers. layers are matched on their MVT name, and each name must match at least one of
+//	the map's layers. layers outside of their zoom range are not rendered.
+func (m Map) RenderTileLayers(ctx context.Context, z, x, y uint64, layerNames []string) ([]byte, error) {
+	names := make(map[string]bool, len(layerNames))
+	for _, name := range layerNames {
+		names[name] = false
+	}
+
+	//	keep the map's layer order
+	var layers []Layer
+	for i := range m.Layers {
+		if _, ok := names[m.Layers[i].MVTName()]; ok {
+			names[m.Layers[i].MVTName()] = true
+			layers = append(layers, m.Layers[i])
+		}
+	}
+
+	for _, name := range layerNames {
+		if !names[name] {
+			return nil, ErrLayerNotFound{
+				MapName:   m.Name,
+				LayerName: name,
+			}
+		}
+	}
+
+	m.Layers = layers
+
+	//	normalize the tile coordinates for the map's tile scheme
+	z, x, y = m.ToXYZ(z, x, y)
+
+	tile := slippy.NewTile(z, x, y, float64(m.TileBuffer), m.SRID)
+
+	return m.FilterLayersByZoom(int(z)).Encode(ctx, tile)
+}
+
+//	Encode encodes the tile. the time taken is reported to the map's Metrics
 func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
+	return m.encodeCounting(ctx, tile, nil)
+}
+
+//	encodeCounting encodes the tile as Encode does. when featureCounts is not nil the number of features
+//	the providers returned for each layer is added to it, keyed by the layer's MVT name
+func (m Map) encodeCounting(ctx context.Context, tile *slippy.Tile, featureCounts map[string]int) ([]byte, error) {
+	start := time.Now()
+
+	b, err := m.encode(ctx, tile, featureCounts)
+	if err != nil {
+		return nil, err
+	}
+
+	z, _, _ := tile.ZXY()
+	m.tileMetrics().TileRendered(m.Name, z, time.Since(start))
+
+	return b, nil
+}
+
+//	TODO (arolek): support for max zoom
+func (m Map) encode(ctx context.Context, tile *slippy.Tile, featureCounts map[string]int) ([]byte, error) {
 	// tile container
 	var mvtTile mvt.Tile
 	// wait group for concurrent layer fetching
@@ -132,77 +280,299 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 
 	// layer stack
 	mvtLayers := make([]*mvt.Layer, len(m.Layers))
+	// the layers each layer's features were split into, by SplitByField, in name order
+	mvtSplitLayers := make([][]*mvt.Layer, len(m.Layers))
+	// the tiles of the layers whose provider serves them already encoded
+	encodedLayers := make([][]byte, len(m.Layers))
+	// the number of features the provider returned for each layer
+	layerCounts := make([]int, len(m.Layers))
+
+	// layers sharing a provider layer are fetched with a single provider query
+	groups := groupLayersBySource(m.Layers)
+
+	// layers beyond their MaxZoom are fetched with the query of their ancestor tile
+	if m.OverZoom {
+		groups = m.groupLayersByQueryZoom(groups, tile)
+	}
 
-	// set our waitgroup count
-	wg.Add(len(m.Layers))
+	// a provider panicking fails the tile. the first panic is returned once all of the layers have finished
+	var (
+		panicOnce sync.Once
+		panicErr  error
+	)
+	recordPanic := func(err error) bool {
+		perr, ok := err.(ErrProviderPanic)
+		if !ok {
+			return false
+		}
 
-	// iterate our layers
-	for i, layer := range m.Layers {
+		z, x, y := tile.ZXY()
+		log.Errorf("provider panicked fetching tile (z: %v, x: %v, y: %v) layer (%v): %v\n%s", z, x, y, perr.LayerName, perr.Value, perr.Stack)
+		panicOnce.Do(func() { panicErr = perr })
+		return true
+	}
 
-		// go routine for fetching the layer concurrently
-		go func(i int, l Layer) {
-			mvtLayer := mvt.Layer{
-				Name:         l.MVTName(),
-				DontSimplify: l.DontSimplify,
-			}
+	// bounds the number of provider queries in flight
+	var slots chan struct{}
+	if m.LayerConcurrency > 0 {
+		slots = make(chan struct{}, m.LayerConcurrency)
+	}
 
+	// set our waitgroup count
+	wg.Add(len(groups))
+
+	// iterate our layer groups
+	for _, group := range groups {
+
+		// go routine for fetching the layers concurrently
+		go func(idxs []int) {
 			// on completion let the wait group know
 			defer wg.Done()
 
-			//	fetch layer from data provider
-			err := l.Provider.TileFeatures(ctx, l.ProviderLayerName, tile, func(f *provider.Feature) error {
+			// wait for a query slot. a canceled request gives up waiting
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			// all the layers in a group share the same source
+			src := m.Layers[idxs[0]]
+
+			// the tile the features are read for. an over zoomed layer reads the features of the ancestor
+			// tile at its MaxZoom, which are clipped to the requested tile below
+			z, _, _ := tile.ZXY()
+			queryTile := tile
+			if qz := m.queryZoom(src, tile); qz < z {
+				queryTile = ancestorTile(tile, qz)
+			}
+
+			// the provider's tile is passed through without decoding its features
+			if mvtTiler, ok := src.Provider.(provider.MVTTiler); ok {
+				var b []byte
+				err := m.queryProvider(src.MVTName(), func() (err error) {
+					b, err = mvtTiler.MVTTile(ctx, src.ProviderLayerName, tile)
+					return err
+				})
+				if err != nil {
+					if !recordPanic(err) && ctx.Err() == nil {
+						z, x, y := tile.ZXY()
+						log.Errorf("err fetching tile (z: %v, x: %v, y: %v) encoded layer (%v): %v", z, x, y, src.MVTName(), err)
+					}
+					return
+				}
+
+				encodedLayers[idxs[0]] = b
+				return
+			}
+
+			layers := make([]mvt.Layer, len(idxs))
+			srcLayers := make([]Layer, len(idxs))
+			for j, idx := range idxs {
+				// resolve the default tags for the tile's zoom once for the layer
+				srcLayers[j] = m.Layers[idx]
+				srcLayers[j].DefaultTags = srcLayers[j].DefaultTagsForZoom(uint(z))
+
+				layers[j] = mvt.Layer{
+					Name:         m.Layers[idx].MVTName(),
+					DontSimplify: m.Layers[idx].DontSimplify,
+					Simplifier:   m.Layers[idx].Simplifier,
+					Quantization: m.Layers[idx].Quantization,
+					SpecVersion:  m.Layers[idx].Version,
+				}
+				if m.simplify != nil {
+					layers[j].DontSimplify = m.simplify.DontSimplify
+					layers[j].SimplifyTolerance = m.simplify.Tolerance
+				}
+			}
+
+			// the layers split from each layer, keyed by MVT layer name
+			splits := make([]map[string]*mvt.Layer, len(idxs))
+
+			// the hashes of the features added to each deduped layer
+			seen := make([]map[uint64]struct{}, len(idxs))
+			for j := range srcLayers {
+				if srcLayers[j].Dedupe {
+					seen[j] = map[uint64]struct{}{}
+				}
+			}
+
+			// geometries are cut at the tile's buffered extent when it's in the map's SRID
+			var tileExtent *geom.BoundingBox
+			if ext, srid := tile.BufferedExtent(); srid == m.SRID {
+				bbox := geom.BoundingBox(ext)
+				tileExtent = &bbox
+			}
+
+			// the number of square tile units in a square map unit. feature areas are scaled by it
+			// before they are compared to a layer's MinArea
+			var areaScale float64
+			if ext, srid := tile.Extent(); srid == m.SRID {
+				units := float64(m.TileExtent)
+				if units == 0 {
+					units = tegola.DefaultExtent
+				}
+				res := (ext[1][0] - ext[0][0]) / units
+				areaScale = 1 / (res * res)
+			}
+
+			// the SRID the provider declares for the layer replaces the SRIDs the features are decoded with
+			var declaredSRID uint64
+			if s, ok := src.Provider.(provider.SRIDer); ok {
+				declaredSRID = s.SRID(src.ProviderLayerName)
+			}
+
+			// the features are mostly in the same SRID so their transform is looked up once
+			transforms := &transformCache{}
+
+			featureFn := func(f *provider.Feature) error {
+				if declaredSRID != 0 && f.SRID != declaredSRID {
+					// the provider can share the feature (i.e. the feature cache) so it's copied
+					feature := *f
+					feature.SRID = declaredSRID
+					f = &feature
+				}
+
+				for _, idx := range idxs {
+					layerCounts[idx]++
+				}
+
+				// a corrupt geometry can decode to NaN or infinite coordinates, which quantize to garbage
+				if finite, err := geom.IsFinite(f.Geometry); err == nil && !finite {
+					for j := range srcLayers {
+						if srcLayers[j].FailOnInvalidCoordinates {
+							return ErrInvalidCoordinates{
+								LayerName: srcLayers[j].MVTName(),
+								FeatureID: f.ID,
+							}
+						}
+					}
+					log.Warnf("layer (%v) feature %v: dropped, the geometry has NaN or infinite coordinates", src.MVTName(), f.ID)
+					return nil
+				}
+
 				// TODO: remove this geom conversion step once the mvt package has adopted the new geom package
 				geo, err := convert.ToTegola(f.Geometry)
 				if err != nil {
 					return err
 				}
 
-				// check if the feature SRID and map SRID are different. If they are then reporject
-				if f.SRID != m.SRID {
-					// TODO(arolek): support for additional projections
-					g, err := basic.ToWebMercator(f.SRID, geo)
+				// distribute the feature to each of the layers in the group
+				for j := range srcLayers {
+					if !srcLayers[j].inTimeWindow(f.Tags, m.timeWindow) {
+						continue
+					}
+
+					mvtFeature, err := m.layerFeature(ctx, srcLayers[j], f, geo, tileExtent, transforms)
 					if err != nil {
-						return fmt.Errorf("unable to transform geometry to webmercator from SRID (%v) for feature %v due to error: %v", f.SRID, f.ID, err)
+						return err
+					}
+					if mvtFeature == nil {
+						continue
 					}
-					geo = g.Geometry
-				}
 
-				// add default tags, but don't overwrite a tag that already exists
-				for k, v := range l.DefaultTags {
-					if _, ok := f.Tags[k]; !ok {
-						f.Tags[k] = v
+					if srcLayers[j].MinArea > 0 && areaScale > 0 {
+						if area, ok := polygonArea(mvtFeature.Geometry); ok && area*areaScale < srcLayers[j].MinArea {
+							continue
+						}
 					}
-				}
 
-				mvtLayer.AddFeatures(mvt.Feature{
-					ID:       &f.ID,
-					Tags:     f.Tags,
-					Geometry: geo,
-				})
+					if seen[j] != nil {
+						hash, err := featureHash(mvtFeature)
+						if err != nil {
+							return err
+						}
+						if _, ok := seen[j][hash]; ok {
+							continue
+						}
+						seen[j][hash] = struct{}{}
+					}
+
+					// features assigned to a split layer are added to it instead
+					if name := srcLayers[j].splitLayerName(mvtFeature.Tags); name != "" {
+						if splits[j] == nil {
+							splits[j] = map[string]*mvt.Layer{}
+						}
+						split, ok := splits[j][name]
+						if !ok {
+							split = &mvt.Layer{
+								Name:              name,
+								DontSimplify:      layers[j].DontSimplify,
+								Simplifier:        layers[j].Simplifier,
+								SimplifyTolerance: layers[j].SimplifyTolerance,
+								Quantization:      layers[j].Quantization,
+								SpecVersion:       layers[j].SpecVersion,
+							}
+							splits[j][name] = split
+						}
+						split.AddFeatures(*mvtFeature)
+						continue
+					}
+
+					layers[j].AddFeatures(*mvtFeature)
+				}
 
 				return nil
+			}
+
+			//	fetch layer from data provider
+			err := m.queryProvider(src.MVTName(), func() error {
+				if src.SQLFilter != "" {
+					filterer, ok := src.Provider.(provider.SQLFilterer)
+					if !ok {
+						return ErrSQLFilterUnsupported{LayerName: src.MVTName()}
+					}
+					return filterer.TileFeaturesWithFilter(ctx, src.ProviderLayerName, src.SQLFilter, queryTile, featureFn)
+				}
+				return src.Provider.TileFeatures(ctx, src.ProviderLayerName, queryTile, featureFn)
 			})
 			if err != nil {
-				switch err {
-				case context.Canceled:
+				switch {
+				case recordPanic(err):
+				case ctx.Err() != nil:
+					// the request was canceled (i.e. the client disconnected) and the provider query
+					// unwound. the context error is returned once all of the layers have finished
 					// TODO (arolek): add debug logs
 				default:
 					z, x, y := tile.ZXY()
 					// TODO (arolek): should we return an error to the response or just log the error?
 					// we can't just write to the response as the waitgroup is going to write to the response as well
-					log.Printf("err fetching tile (z: %v, x: %v, y: %v) features: %v", z, x, y, err)
+					log.Errorf("err fetching tile (z: %v, x: %v, y: %v) features: %v", z, x, y, err)
 				}
 				return
 			}
 
-			// add the layer to the slice position
-			mvtLayers[i] = &mvtLayer
-		}(i, layer)
+			// add the layers to their slice positions
+			for j, idx := range idxs {
+				mvtLayers[idx] = &layers[j]
+
+				names := make([]string, 0, len(splits[j]))
+				for name := range splits[j] {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					mvtSplitLayers[idx] = append(mvtSplitLayers[idx], splits[j][name])
+				}
+			}
+		}(group)
 	}
 
 	// wait for the waitgroup to finish
 	wg.Wait()
 
+	// the layers whose provider serves them already encoded are not counted
+	if featureCounts != nil {
+		for i := range m.Layers {
+			if _, ok := m.Layers[i].Provider.(provider.MVTTiler); !ok {
+				featureCounts[m.Layers[i].MVTName()] += layerCounts[i]
+			}
+		}
+	}
+
 	// stop processing if the context has an error. this check is necessary
 	// otherwise the server continues processing even if the request was canceled
 	// as the waitgroup was not notified of the cancel
@@ -210,13 +580,44 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 		return nil, ctx.Err()
 	}
 
+	if panicErr != nil {
+		return nil, panicErr
+	}
+
+	// the layers are encoded in draw order
+	order := layerOrder(m.Layers)
+
+	// each layer is followed by the layers split from it
+	tileLayers := make([]*mvt.Layer, 0, len(mvtLayers))
+	for _, i := range order {
+		tileLayers = append(tileLayers, mvtLayers[i])
+		tileLayers = append(tileLayers, mvtSplitLayers[i]...)
+	}
+
+	// the encoded layers are appended to the encoded tile. concatenated MVT tiles decode as a single
+	// tile with the layers of each
+	var encoded []byte
+	for _, i := range order {
+		encoded = append(encoded, encodedLayers[i]...)
+	}
+
+	// a tile without any features is encoded unless the map omits empty tiles
+	if m.OmitEmptyTiles && !hasFeatures(tileLayers) && len(encoded) == 0 {
+		return nil, nil
+	}
+
 	//	add layers to our tile
-	mvtTile.AddLayers(mvtLayers...)
+	mvtTile.AddLayers(tileLayers...)
 
 	z, x, y := tile.ZXY()
 
 	// TODO (arolek): change out the tile type for VTile. tegola.Tile will be deprecated
 	tegolaTile := tegola.NewTile(int(z), int(x), int(y))
+	if m.TileExtent != 0 {
+		tegolaTile.Extent = float64(m.TileExtent)
+		//	recompute the tile's cached pixel bounds for the extent
+		tegolaTile.Init()
+	}
 
 	// generate our tile
 	vtile, err := mvtTile.VTile(ctx, tegolaTile)
@@ -225,5 +626,258 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 	}
 
 	// encode the tile
-	return proto.Marshal(vtile)
+	b, err := proto.Marshal(vtile)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, encoded...), nil
+}
+
+//	layerFeature prepares a provider feature for encoding in the given layer. the feature's geometry
+//	is reprojected to the map's SRID, using the transform read from transforms, which may be nil, and
+//	clipped to the map's ClipExtent and to tileExtent, if not nil. the layer's renamed and default tags
+//	are applied to a copy of the feature's tags. a nil feature is returned if the feature should not be
+//	included in the layer: it's outside of the mask or the tile, or oversized for the layer's OversizeRatio
+func (m Map) layerFeature(ctx context.Context, l Layer, f *provider.Feature, geo tegola.Geometry, tileExtent *geom.BoundingBox, transforms *transformCache) (*mvt.Feature, error) {
+	// the provider could not determine the feature's SRID. fall back to the layer's configured SRID
+	srid := f.SRID
+	if srid == 0 {
+		srid = l.SRID
+	}
+
+	// snap the coordinates in the source units so adjacent tiles agree on shared edges
+	if l.SnapGrid > 0 {
+		g, err := basic.SnapToGrid(l.SnapGrid, geo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to snap geometry to grid (%v) for feature %v due to error: %v", l.SnapGrid, f.ID, err)
+		}
+		geo = g.Geometry
+	}
+
+	// repair the polygon rings after snapping as snapping can collapse a ring's points
+	if l.RepairGeometry {
+		g, dropped, err := basic.RepairRings(geo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to repair geometry for feature %v due to error: %v", f.ID, err)
+		}
+		if dropped > 0 {
+			log.Warnf("layer (%v) feature %v: dropped %v ring(s) with fewer than 3 points", l.MVTName(), f.ID, dropped)
+		}
+		if g.Geometry == nil {
+			return nil, nil
+		}
+		geo = g.Geometry
+	}
+
+	if l.ReverseLines {
+		g, err := basic.ReverseLines(geo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to reverse lines for feature %v due to error: %v", f.ID, err)
+		}
+		geo = g.Geometry
+	}
+
+	// check if the feature SRID and map SRID are different. If they are then reporject
+	if srid != m.SRID {
+		// a straight segment in the feature's projection curves in the map's projection
+		if l.Densify > 0 {
+			g, err := basic.Densify(l.Densify, geo)
+			if err != nil {
+				return nil, fmt.Errorf("unable to densify geometry (%v) for feature %v due to error: %v", l.Densify, f.ID, err)
+			}
+			geo = g.Geometry
+		}
+
+		// TODO(arolek): support for additional projections
+		transform, err := transforms.transform(srid)
+		if err != nil {
+			return nil, fmt.Errorf("unable to transform geometry to webmercator from SRID (%v) for feature %v due to error: %v", srid, f.ID, err)
+		}
+		g, err := basic.ApplyToPoints(geo, transform)
+		if err != nil {
+			return nil, fmt.Errorf("unable to transform geometry to webmercator from SRID (%v) for feature %v due to error: %v", srid, f.ID, err)
+		}
+		geo = g.Geometry
+	}
+
+	// clip the geometry to the map's extent mask
+	if m.ClipExtent != nil {
+		var err error
+		geo, err = clipGeometry(ctx, geo, *m.ClipExtent)
+		if err != nil {
+			return nil, fmt.Errorf("unable to clip geometry for feature %v due to error: %v", f.ID, err)
+		}
+		if geo == nil {
+			// the feature is outside of the mask
+			return nil, nil
+		}
+	}
+
+	// cut the geometry at the tile's buffer so geometries extending past the tile are not encoded whole
+	if tileExtent != nil {
+		if l.OversizeRatio > 0 && oversized(geo, *tileExtent, l.OversizeRatio) {
+			return nil, nil
+		}
+
+		var err error
+		geo, err = clipToTile(geo, *tileExtent)
+		if err != nil {
+			return nil, fmt.Errorf("unable to clip geometry to the tile for feature %v due to error: %v", f.ID, err)
+		}
+		if geo == nil {
+			// the feature is outside of the tile's buffer
+			return nil, nil
+		}
+	}
+
+	// the feature can be shared by several layers so the tags are copied before the default tags are added
+	tags := make(map[string]interface{}, len(f.Tags)+len(l.DefaultTags))
+	for k, v := range f.Tags {
+		if _, ok := l.FieldRename[k]; ok {
+			continue
+		}
+		tags[k] = v
+	}
+
+	if len(l.FieldRename) > 0 {
+		for _, k := range l.renameTags(f.Tags, tags) {
+			log.Warnf("layer (%v) feature %v: dropped tag (%v), its new name (%v) is already a tag", l.MVTName(), f.ID, k, l.FieldRename[k])
+		}
+	}
+
+	// add default tags, but don't overwrite a tag that already exists
+	for k, v := range l.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	id := f.ID
+
+	return &mvt.Feature{
+		ID:       &id,
+		Tags:     tags,
+		Geometry: geo,
+	}, nil
+}
+
+//	layerOrder returns the indexes of the layers in draw order, by increasing ZIndex. layers with the
+//	same ZIndex keep their order
+func layerOrder(layers []Layer) []int {
+	order := make([]int, len(layers))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return layers[order[i]].ZIndex < layers[order[j]].ZIndex
+	})
+
+	return order
+}
+
+//	groupLayersBySource groups the indexes of layers which share the same provider, provider layer name and SQL filter.
+//	groups are returned in the order of their first layer
+func groupLayersBySource(layers []Layer) [][]int {
+	var groups [][]int
+
+LAYERS_LOOP:
+	for i := range layers {
+		// only providers which can be compared can be grouped
+		if layers[i].Provider != nil && reflect.TypeOf(layers[i].Provider).Comparable() {
+			for j, g := range groups {
+				l := layers[g[0]]
+				if reflect.TypeOf(l.Provider) == reflect.TypeOf(layers[i].Provider) &&
+					l.Provider == layers[i].Provider &&
+					l.ProviderLayerName == layers[i].ProviderLayerName &&
+					l.SQLFilter == layers[i].SQLFilter {
+					groups[j] = append(groups[j], i)
+					continue LAYERS_LOOP
+				}
+			}
+		}
+
+		groups = append(groups, []int{i})
+	}
+
+	return groups
+}
+
+//	queryZoom returns the zoom of the tile the layer's features are read for. when the map has OverZoom
+//	set this is the layer's MaxZoom for tiles beyond it, otherwise the tile's zoom
+func (m Map) queryZoom(l Layer, tile *slippy.Tile) uint64 {
+	z, _, _ := tile.ZXY()
+	if m.OverZoom && l.MaxZoom > 0 && z > uint64(l.MaxZoom) {
+		return uint64(l.MaxZoom)
+	}
+
+	return z
+}
+
+//	groupLayersByQueryZoom splits the layer groups so the layers of each group read their features for
+//	the same tile. the layers of a group differing only in their MaxZoom over zoom from different tiles
+func (m Map) groupLayersByQueryZoom(groups [][]int, tile *slippy.Tile) [][]int {
+	split := make([][]int, 0, len(groups))
+	for _, g := range groups {
+		var zooms []uint64
+		byZoom := map[uint64][]int{}
+		for _, idx := range g {
+			qz := m.queryZoom(m.Layers[idx], tile)
+			if _, ok := byZoom[qz]; !ok {
+				zooms = append(zooms, qz)
+			}
+			byZoom[qz] = append(byZoom[qz], idx)
+		}
+
+		for _, qz := range zooms {
+			split = append(split, byZoom[qz])
+		}
+	}
+
+	return split
+}
+
+//	ancestorTile returns the tile at zoom z, which must not exceed the tile's zoom, containing the tile
+func ancestorTile(tile *slippy.Tile, z uint64) *slippy.Tile {
+	tz, x, y := tile.ZXY()
+	shift := tz - z
+
+	return slippy.NewTile(z, x>>shift, y>>shift, tile.Buffer, tile.SRID)
+}
+
+//	queryProvider runs a provider query for the named layer. a panic during the query is recovered
+//	and returned as an ErrProviderPanic so a misbehaving provider fails the tile rather than the process.
+//	the time taken is reported to the map's Metrics
+func (m Map) queryProvider(layerName string, query func() error) (err error) {
+	start := time.Now()
+	defer func() {
+		m.tileMetrics().ProviderQueried(m.Name, layerName, time.Since(start), err)
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			stack := make([]byte, 64<<10)
+			stack = stack[:runtime.Stack(stack, false)]
+
+			err = ErrProviderPanic{
+				LayerName: layerName,
+				Value:     r,
+				Stack:     stack,
+			}
+		}
+	}()
+
+	return query()
+}
+
+// hasFeatures reports whether any of the layers contain at least one feature
+func hasFeatures(layers []*mvt.Layer) bool {
+	for i := range layers {
+		if layers[i] != nil && len(layers[i].Features()) > 0 {
+			return true
+		}
+	}
+
+	return false
 }
@@ -3,15 +3,22 @@ package atlas_test
 import (
 	"context"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/arolek/p"
 	"github.com/golang/protobuf/proto"
 
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/encoding/wkb"
 	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/maths/webmercator"
 	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/test"
 )
 
@@ -132,6 +139,18 @@ func TestMapFilterLayersByName(t *testing.T) {
 	}
 }
 
+func TestMapLayerInfo(t *testing.T) {
+	expected := []atlas.LayerInfo{
+		{Name: "test-layer", GeomType: "Point", MinZoom: 4, MaxZoom: 9},
+		{Name: "test-layer-2-name", GeomType: "LineString", MinZoom: 10, MaxZoom: 20},
+		{Name: "test-layer", GeomType: "Point", MinZoom: 10, MaxZoom: 20},
+	}
+
+	if infos := testMap.LayerInfo(); !reflect.DeepEqual(infos, expected) {
+		t.Errorf("layer info, expected %+v got %+v", expected, infos)
+	}
+}
+
 func TestEncode(t *testing.T) {
 	// create vars for the vector tile types so we can take their addresses
 	// unknown := vectorTile.Tile_UNKNOWN
@@ -317,3 +336,1857 @@ func TestEncode(t *testing.T) {
 		}
 	}
 }
+
+// emptyTileProvider is a provider that never returns any features
+type emptyTileProvider struct{}
+
+func (emptyTileProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (emptyTileProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return nil
+}
+
+func TestEncodeEmptyTiles(t *testing.T) {
+	type tcase struct {
+		grid           atlas.Map
+		expectedLayers []string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		out, err := tc.grid.Encode(context.Background(), slippy.NewTile(2, 3, 4, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if tc.grid.OmitEmptyTiles {
+			if out != nil {
+				t.Errorf("expected no output got %v bytes", len(out))
+			}
+			return
+		}
+
+		if len(out) == 0 {
+			t.Fatalf("expected an encoded tile got no output")
+		}
+
+		var tile vectorTile.Tile
+		if err = proto.Unmarshal(out, &tile); err != nil {
+			t.Fatalf("error unmarshalling output: %v", err)
+		}
+
+		if len(tile.Layers) != len(tc.expectedLayers) {
+			t.Fatalf("layer count, expected %v got %v", len(tc.expectedLayers), len(tile.Layers))
+		}
+
+		for i, l := range tile.Layers {
+			if l.GetName() != tc.expectedLayers[i] {
+				t.Errorf("layer name, expected %v got %v", tc.expectedLayers[i], l.GetName())
+			}
+			if len(l.Features) != 0 {
+				t.Errorf("layer (%v) feature count, expected 0 got %v", l.GetName(), len(l.Features))
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"empty tiles": {
+			grid: atlas.Map{
+				Layers: []atlas.Layer{
+					{
+						Name:     "layer1",
+						Provider: emptyTileProvider{},
+					},
+					{
+						Name:     "layer2",
+						Provider: emptyTileProvider{},
+					},
+				},
+			},
+			expectedLayers: []string{"layer1", "layer2"},
+		},
+		"omit empty tiles": {
+			grid: atlas.Map{
+				OmitEmptyTiles: true,
+				Layers: []atlas.Layer{
+					{
+						Name:     "layer1",
+						Provider: emptyTileProvider{},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestMapToXYZ(t *testing.T) {
+	type tcase struct {
+		scheme   string
+		zxy      [3]uint64
+		expected [3]uint64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("test")
+		m.Scheme = tc.scheme
+
+		z, x, y := m.ToXYZ(tc.zxy[0], tc.zxy[1], tc.zxy[2])
+		if [3]uint64{z, x, y} != tc.expected {
+			t.Errorf("expected %v got %v", tc.expected, [3]uint64{z, x, y})
+		}
+	}
+
+	tests := map[string]tcase{
+		"default": {
+			zxy:      [3]uint64{2, 1, 1},
+			expected: [3]uint64{2, 1, 1},
+		},
+		"xyz": {
+			scheme:   atlas.TileSchemeXYZ,
+			zxy:      [3]uint64{2, 1, 1},
+			expected: [3]uint64{2, 1, 1},
+		},
+		"tms": {
+			scheme:   atlas.TileSchemeTMS,
+			zxy:      [3]uint64{2, 1, 1},
+			expected: [3]uint64{2, 1, 2},
+		},
+		"tms zoom 0": {
+			scheme:   atlas.TileSchemeTMS,
+			zxy:      [3]uint64{0, 0, 0},
+			expected: [3]uint64{0, 0, 0},
+		},
+		"tms bottom row": {
+			scheme:   atlas.TileSchemeTMS,
+			zxy:      [3]uint64{16, 11436, 0},
+			expected: [3]uint64{16, 11436, 65535},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestMapTileScheme(t *testing.T) {
+	xyzMap := atlas.NewWebMercatorMap("xyz")
+	xyzMap.Layers = []atlas.Layer{
+		{
+			Name:     "layer1",
+			Provider: &test.TileProvider{},
+		},
+	}
+
+	tmsMap := xyzMap
+	tmsMap.Name = "tms"
+	tmsMap.Scheme = atlas.TileSchemeTMS
+
+	// the tms tile 2/1/2 covers the same geographic area as the xyz tile 2/1/1
+	z, x, y := xyzMap.ToXYZ(2, 1, 1)
+	xyzOut, err := xyzMap.Encode(context.Background(), slippy.NewTile(z, x, y, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("xyz err: %v", err)
+	}
+
+	z, x, y = tmsMap.ToXYZ(2, 1, 2)
+	if y != 1 {
+		t.Fatalf("tms y, expected 1 got %v", y)
+	}
+	tmsOut, err := tmsMap.Encode(context.Background(), slippy.NewTile(z, x, y, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("tms err: %v", err)
+	}
+
+	if !reflect.DeepEqual(xyzOut, tmsOut) {
+		t.Errorf("expected the xyz and tms tiles to match")
+	}
+}
+
+// pointProvider returns a single point feature with the configured SRID
+type pointProvider struct {
+	srid uint64
+	pt   geom.Point
+}
+
+func (pointProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p pointProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: p.pt,
+		SRID:     p.srid,
+	})
+}
+
+// sridProvider is a pointProvider which declares the SRID of its layers
+type sridProvider struct {
+	pointProvider
+	declared uint64
+}
+
+func (p sridProvider) SRID(layer string) uint64 { return p.declared }
+
+func TestEncodeLayerSRID(t *testing.T) {
+	lon, lat := 13.405, 52.52
+	worldMercator, err := webmercator.ToXY(lon, lat)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tile := slippy.NewTile(0, 0, 0, 64, tegola.WebMercator)
+
+	newMap := func(l atlas.Layer) atlas.Map {
+		m := atlas.NewWebMercatorMap("srid")
+		l.Name = "points"
+		m.Layers = append(m.Layers, l)
+		return m
+	}
+
+	// the expected tile is encoded from a feature which is already in WebMercator
+	expected, err := newMap(atlas.Layer{
+		Provider: pointProvider{
+			srid: tegola.WebMercator,
+			pt:   geom.Point{webmercator.PLonToX(lon), webmercator.PLatToY(lat)},
+		},
+	}).Encode(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	type tcase struct {
+		layer       atlas.Layer
+		expectEmpty bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		out, err := newMap(tc.layer).Encode(context.Background(), tile)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if tc.expectEmpty {
+			if len(out) != 0 {
+				t.Errorf("expected no output got %v bytes", len(out))
+			}
+			return
+		}
+
+		if !reflect.DeepEqual(out, expected) {
+			t.Errorf("encoded tile, expected %v got %v", expected, out)
+		}
+	}
+
+	tests := map[string]tcase{
+		// a gpkg geometry header with an srs_id of 0 results in a feature SRID of 0
+		"unknown feature srid uses layer srid": {
+			layer: atlas.Layer{
+				SRID:     tegola.WorldMercator,
+				Provider: pointProvider{pt: geom.Point{worldMercator[0], worldMercator[1]}},
+			},
+		},
+		"feature srid takes precedence": {
+			layer: atlas.Layer{
+				SRID:     tegola.WGS84,
+				Provider: pointProvider{srid: tegola.WorldMercator, pt: geom.Point{worldMercator[0], worldMercator[1]}},
+			},
+		},
+		"provider declared srid": {
+			layer: atlas.Layer{
+				Provider: sridProvider{
+					pointProvider: pointProvider{pt: geom.Point{lon, lat}},
+					declared:      tegola.WGS84,
+				},
+			},
+		},
+		"provider declared srid takes precedence": {
+			layer: atlas.Layer{
+				SRID: tegola.WorldMercator,
+				Provider: sridProvider{
+					pointProvider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{lon, lat}},
+					declared:      tegola.WGS84,
+				},
+			},
+		},
+		"unknown feature srid without a layer srid": {
+			layer: atlas.Layer{
+				Provider: pointProvider{pt: geom.Point{worldMercator[0], worldMercator[1]}},
+			},
+			expectEmpty: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// polygonProvider returns a single polygon feature in WebMercator
+type polygonProvider struct {
+	polygon geom.Polygon
+}
+
+func (polygonProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p polygonProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: p.polygon,
+		SRID:     tegola.WebMercator,
+	})
+}
+
+// featurePixelExtent decodes the geometry commands of a vector tile feature and returns the extent of its vertices
+func featurePixelExtent(f *vectorTile.Tile_Feature) (ext [2][2]int64) {
+	zigzag := func(v uint32) int64 { return int64(int32(v>>1) ^ -int32(v&1)) }
+
+	var x, y int64
+	first := true
+	g := f.Geometry
+	for i := 0; i < len(g); {
+		cmd, count := g[i]&0x7, int(g[i]>>3)
+		i++
+		if cmd == 7 { // close path
+			continue
+		}
+		for j := 0; j < count; j++ {
+			x += zigzag(g[i])
+			y += zigzag(g[i+1])
+			i += 2
+
+			if first {
+				ext = [2][2]int64{{x, y}, {x, y}}
+				first = false
+				continue
+			}
+			if x < ext[0][0] {
+				ext[0][0] = x
+			}
+			if y < ext[0][1] {
+				ext[0][1] = y
+			}
+			if x > ext[1][0] {
+				ext[1][0] = x
+			}
+			if y > ext[1][1] {
+				ext[1][1] = y
+			}
+		}
+	}
+
+	return ext
+}
+
+func TestEncodeClipExtent(t *testing.T) {
+	const max = 20037508.34
+
+	tile := slippy.NewTile(0, 0, 0, 64, tegola.WebMercator)
+
+	// a polygon spanning the whole tile
+	fullTile := geom.Polygon{{{-max, -max}, {max, -max}, {max, max}, {-max, max}}}
+
+	type tcase struct {
+		clipExtent *geom.BoundingBox
+		// nil if no feature is expected
+		expected *[2][2]int64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("clip")
+		m.ClipExtent = tc.clipExtent
+		m.Layers = append(m.Layers, atlas.Layer{
+			Name:         "polygons",
+			Provider:     polygonProvider{polygon: fullTile},
+			DontSimplify: true,
+		})
+
+		out, err := m.Encode(context.Background(), tile)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if tc.expected == nil {
+			for _, l := range vt.Layers {
+				if len(l.Features) != 0 {
+					t.Errorf("layer (%v) feature count, expected 0 got %v", l.GetName(), len(l.Features))
+				}
+			}
+			return
+		}
+
+		if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+			t.Fatalf("expected a single layer with a single feature got %v", vt.Layers)
+		}
+
+		ext := featurePixelExtent(vt.Layers[0].Features[0])
+		if ext != *tc.expected {
+			t.Errorf("feature extent, expected %v got %v", *tc.expected, ext)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no mask": {
+			expected: &[2][2]int64{{0, 0}, {4096, 4096}},
+		},
+		"west half": {
+			clipExtent: &geom.BoundingBox{{-max, -max}, {0, max}},
+			expected:   &[2][2]int64{{0, 0}, {2048, 4096}},
+		},
+		"outside": {
+			clipExtent: &geom.BoundingBox{{2 * max, -max}, {3 * max, max}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// featureCommands returns the command ids and counts of a vector tile feature's geometry
+func featureCommands(f *vectorTile.Tile_Feature) (cmds [][2]uint32) {
+	g := f.Geometry
+	for i := 0; i < len(g); {
+		cmd, count := g[i]&0x7, g[i]>>3
+		cmds = append(cmds, [2]uint32{cmd, count})
+		i++
+		if cmd != 7 { // close path has no parameters
+			i += int(count) * 2
+		}
+	}
+	return cmds
+}
+
+func TestEncodeRepairGeometry(t *testing.T) {
+	const half = 20037508.34 / 2
+
+	type tcase struct {
+		polygon  geom.Polygon
+		snapGrid float64
+		// nil if no feature is expected
+		expected [][2]uint32
+	}
+
+	// move to 1 point, line to 3 points and close the path
+	closedSquare := [][2]uint32{{1, 1}, {2, 3}, {7, 1}}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("repair")
+		m.Layers = append(m.Layers, atlas.Layer{
+			Name:           "polygons",
+			Provider:       polygonProvider{polygon: tc.polygon},
+			DontSimplify:   true,
+			RepairGeometry: true,
+			SnapGrid:       tc.snapGrid,
+		})
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if tc.expected == nil {
+			for _, l := range vt.Layers {
+				if len(l.Features) != 0 {
+					t.Errorf("layer (%v) feature count, expected 0 got %v", l.GetName(), len(l.Features))
+				}
+			}
+			return
+		}
+
+		if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+			t.Fatalf("expected a single layer with a single feature got %v", vt.Layers)
+		}
+
+		if cmds := featureCommands(vt.Layers[0].Features[0]); !reflect.DeepEqual(cmds, tc.expected) {
+			t.Errorf("geometry commands, expected %v got %v", tc.expected, cmds)
+		}
+	}
+
+	tests := map[string]tcase{
+		"unclosed ring": {
+			polygon:  geom.Polygon{{{-half, -half}, {half, -half}, {half, half}, {-half, half}}},
+			expected: closedSquare,
+		},
+		"explicitly closed ring": {
+			polygon:  geom.Polygon{{{-half, -half}, {half, -half}, {half, half}, {-half, half}, {-half, -half}}},
+			expected: closedSquare,
+		},
+		"degenerate ring": {
+			polygon: geom.Polygon{{{-half, -half}, {half, half}, {-half, -half}}},
+		},
+		"ring collapsed by snapping": {
+			polygon:  geom.Polygon{{{-half, -half}, {-half + 1, -half + 1}, {half, half}}},
+			snapGrid: 10,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// featuresProvider returns the same features for every layer and tile
+type featuresProvider struct {
+	features []provider.Feature
+}
+
+func (featuresProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p featuresProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	for i := range p.features {
+		f := p.features[i]
+		if err := fn(&f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEncodeDedupe(t *testing.T) {
+	type tcase struct {
+		dedupe   bool
+		features []provider.Feature
+		expected int
+	}
+
+	point := func(id uint64, x float64, tags map[string]interface{}) provider.Feature {
+		return provider.Feature{
+			ID:       id,
+			Geometry: geom.Point{x, 0},
+			SRID:     tegola.WebMercator,
+			Tags:     tags,
+		}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("dedupe")
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "points",
+				Provider: featuresProvider{features: tc.features},
+				Dedupe:   tc.dedupe,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if len(vt.Layers) != 1 {
+			t.Fatalf("layers, expected 1 got %v", len(vt.Layers))
+		}
+		if len(vt.Layers[0].Features) != tc.expected {
+			t.Errorf("features, expected %v got %v", tc.expected, len(vt.Layers[0].Features))
+		}
+	}
+
+	tags := map[string]interface{}{"name": "a", "rank": 1}
+
+	tests := map[string]tcase{
+		"identical features": {
+			dedupe: true,
+			features: []provider.Feature{
+				point(1, 1000, tags),
+				point(2, 1000, map[string]interface{}{"rank": 1, "name": "a"}),
+			},
+			expected: 1,
+		},
+		"different geometries": {
+			dedupe:   true,
+			features: []provider.Feature{point(1, 1000, tags), point(2, 5000000, tags)},
+			expected: 2,
+		},
+		"different tags": {
+			dedupe:   true,
+			features: []provider.Feature{point(1, 1000, tags), point(2, 1000, map[string]interface{}{"name": "b", "rank": 1})},
+			expected: 2,
+		},
+		"dedupe off": {
+			features: []provider.Feature{point(1, 1000, tags), point(2, 1000, tags)},
+			expected: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeMinArea(t *testing.T) {
+	type tcase struct {
+		minArea  float64
+		expected []uint64
+	}
+
+	// a square with sides of the given length, in meters, inside of tile 1/0/0
+	square := func(id uint64, side float64) provider.Feature {
+		x, y := -10000000.0, 10000000.0
+		return provider.Feature{
+			ID:       id,
+			Geometry: geom.Polygon{{{x, y}, {x + side, y}, {x + side, y + side}, {x, y + side}}},
+			SRID:     tegola.WebMercator,
+		}
+	}
+
+	features := []provider.Feature{
+		// ~418 square tile units at zoom 1
+		square(1, 100000),
+		// ~4.2 and ~9.4 square tile units at zoom 1
+		square(2, 10000),
+		square(3, 15000),
+		// points have no area and are kept
+		{ID: 4, Geometry: geom.Point{-10000000, 10000000}, SRID: tegola.WebMercator},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("min area")
+		m.Layers = []atlas.Layer{
+			{
+				Name:         "polygons",
+				Provider:     featuresProvider{features: features},
+				DontSimplify: true,
+				MinArea:      tc.minArea,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(1, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if len(vt.Layers) != 1 {
+			t.Fatalf("layers, expected 1 got %v", len(vt.Layers))
+		}
+
+		var ids []uint64
+		for _, f := range vt.Layers[0].Features {
+			ids = append(ids, f.GetId())
+		}
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("feature ids, expected %v got %v", tc.expected, ids)
+		}
+	}
+
+	tests := map[string]tcase{
+		"small polygons dropped": {
+			minArea:  10,
+			expected: []uint64{1, 4},
+		},
+		"threshold off": {
+			expected: []uint64{1, 2, 3, 4},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeSplitByField(t *testing.T) {
+	road := func(id uint64, class interface{}) provider.Feature {
+		f := provider.Feature{
+			ID:       id,
+			Geometry: geom.Point{float64(id) * 1000, 0},
+			SRID:     tegola.WebMercator,
+			Tags:     map[string]interface{}{},
+		}
+		if class != nil {
+			f.Tags["class"] = class
+		}
+		return f
+	}
+
+	m := atlas.NewWebMercatorMap("split")
+	m.Layers = []atlas.Layer{
+		{
+			Name: "roads",
+			Provider: featuresProvider{features: []provider.Feature{
+				road(1, "motorway"),
+				road(2, "residential"),
+				road(3, "primary"),
+				road(4, "motorway"),
+				// not in SplitLayers
+				road(5, "track"),
+				// without the field
+				road(6, nil),
+			}},
+			SplitByField: "class",
+			SplitLayers: map[string]string{
+				"motorway":    "motorways",
+				"primary":     "primary_roads",
+				"residential": "residential_roads",
+			},
+		},
+	}
+
+	out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var vt vectorTile.Tile
+	if err = proto.Unmarshal(out, &vt); err != nil {
+		t.Fatalf("err unmarshalling tile: %v", err)
+	}
+
+	var names []string
+	ids := map[string][]uint64{}
+	for _, l := range vt.Layers {
+		names = append(names, l.GetName())
+		for _, f := range l.Features {
+			ids[l.GetName()] = append(ids[l.GetName()], f.GetId())
+		}
+	}
+
+	// the split layers follow the layer in name order
+	if expected := []string{"roads", "motorways", "primary_roads", "residential_roads"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("layer names, expected %v got %v", expected, names)
+	}
+
+	expected := map[string][]uint64{
+		"roads":             {5, 6},
+		"motorways":         {1, 4},
+		"primary_roads":     {3},
+		"residential_roads": {2},
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("feature ids, expected %v got %v", expected, ids)
+	}
+}
+
+// countingProvider counts the number of TileFeatures calls per provider layer
+type countingProvider struct {
+	sync.Mutex
+	calls map[string]int
+}
+
+func (*countingProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *countingProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.Lock()
+	p.calls[layer]++
+	p.Unlock()
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{0, 0},
+		SRID:     tegola.WebMercator,
+		Tags: map[string]interface{}{
+			"name": layer,
+		},
+	})
+}
+
+func TestEncodeSharedProviderLayer(t *testing.T) {
+	p := &countingProvider{calls: map[string]int{}}
+
+	m := atlas.NewWebMercatorMap("shared")
+	m.Layers = []atlas.Layer{
+		{
+			Name:              "layer1",
+			ProviderLayerName: "shared",
+			Provider:          p,
+			DefaultTags: map[string]interface{}{
+				"foo": "bar",
+			},
+		},
+		{
+			Name:              "layer2",
+			ProviderLayerName: "shared",
+			Provider:          p,
+		},
+		{
+			Name:              "layer3",
+			ProviderLayerName: "other",
+			Provider:          p,
+		},
+	}
+
+	out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expectedCalls := map[string]int{"shared": 1, "other": 1}
+	if !reflect.DeepEqual(p.calls, expectedCalls) {
+		t.Errorf("provider calls, expected %v got %v", expectedCalls, p.calls)
+	}
+
+	var vt vectorTile.Tile
+	if err = proto.Unmarshal(out, &vt); err != nil {
+		t.Fatalf("err unmarshalling tile: %v", err)
+	}
+
+	// layer name and the expected tag keys
+	expected := []struct {
+		name string
+		keys []string
+	}{
+		{"layer1", []string{"foo", "name"}},
+		{"layer2", []string{"name"}},
+		{"layer3", []string{"name"}},
+	}
+
+	if len(vt.Layers) != len(expected) {
+		t.Fatalf("layers length, expected %v got %v", len(expected), len(vt.Layers))
+	}
+
+	for i, l := range vt.Layers {
+		if l.GetName() != expected[i].name {
+			t.Errorf("layer (%v) name, expected %v got %v", i, expected[i].name, l.GetName())
+		}
+
+		if len(l.Features) != 1 {
+			t.Errorf("layer (%v) features, expected 1 got %v", i, len(l.Features))
+		}
+
+		keys := append([]string{}, l.Keys...)
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, expected[i].keys) {
+			t.Errorf("layer (%v) keys, expected %v got %v", i, expected[i].keys, keys)
+		}
+	}
+}
+
+// filteringProvider records the filters of the TileFeaturesWithFilter calls per provider layer
+type filteringProvider struct {
+	countingProvider
+	filters map[string][]string
+}
+
+func (p *filteringProvider) TileFeaturesWithFilter(ctx context.Context, layer string, filter string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.Lock()
+	p.filters[layer] = append(p.filters[layer], filter)
+	p.Unlock()
+
+	return p.TileFeatures(ctx, layer, t, fn)
+}
+
+func TestEncodeSQLFilter(t *testing.T) {
+	p := &filteringProvider{
+		countingProvider: countingProvider{calls: map[string]int{}},
+		filters:          map[string][]string{},
+	}
+
+	m := atlas.NewWebMercatorMap("filtered")
+	m.Layers = []atlas.Layer{
+		{
+			Name:              "roads",
+			ProviderLayerName: "shared",
+			Provider:          p,
+			SQLFilter:         "type = 'road'",
+		},
+		{
+			Name:              "all",
+			ProviderLayerName: "shared",
+			Provider:          p,
+		},
+	}
+
+	if _, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	//	layers with different filters can't share a provider call
+	expectedCalls := map[string]int{"shared": 2}
+	if !reflect.DeepEqual(p.calls, expectedCalls) {
+		t.Errorf("provider calls, expected %v got %v", expectedCalls, p.calls)
+	}
+
+	expectedFilters := map[string][]string{"shared": {"type = 'road'"}}
+	if !reflect.DeepEqual(p.filters, expectedFilters) {
+		t.Errorf("provider filters, expected %v got %v", expectedFilters, p.filters)
+	}
+
+	//	a provider without filter support drops the layer instead of returning unfiltered features
+	m.Layers[0].Provider = &countingProvider{calls: map[string]int{}}
+
+	out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var vt vectorTile.Tile
+	if err = proto.Unmarshal(out, &vt); err != nil {
+		t.Fatalf("err unmarshalling tile: %v", err)
+	}
+	if len(vt.Layers) != 1 || vt.Layers[0].GetName() != "all" {
+		t.Errorf("layers, expected only the unfiltered layer got %v", len(vt.Layers))
+	}
+}
+
+// blockingProvider blocks TileFeatures until the context is canceled
+type blockingProvider struct {
+	started chan struct{}
+	unwound chan struct{}
+}
+
+func (blockingProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p blockingProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	defer close(p.unwound)
+	close(p.started)
+
+	// simulate a long running query
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestEncodeContextCanceled(t *testing.T) {
+	p := blockingProvider{
+		started: make(chan struct{}),
+		unwound: make(chan struct{}),
+	}
+
+	m := atlas.NewWebMercatorMap("blocking")
+	m.Layers = append(m.Layers, atlas.Layer{
+		Name:     "blocking",
+		Provider: p,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := m.Encode(ctx, slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		errc <- err
+	}()
+
+	// cancel once the provider query is in flight (i.e. the client disconnected)
+	<-p.started
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Errorf("expected %v got %v", context.Canceled, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Encode to return")
+	}
+
+	select {
+	case <-p.unwound:
+	default:
+		t.Error("expected the provider query to have unwound")
+	}
+}
+
+func TestMapRenderTileLayers(t *testing.T) {
+	threeLayers := atlas.NewWebMercatorMap("three-layers")
+	for _, name := range []string{"a", "b", "c"} {
+		threeLayers.Layers = append(threeLayers.Layers, atlas.Layer{
+			Name:     name,
+			Provider: &test.TileProvider{},
+		})
+	}
+
+	//	a background layer rendered at every zoom and a layer limited to its zoom range
+	alwaysRender := atlas.NewWebMercatorMap("always-render")
+	for _, name := range []string{"background", "roads"} {
+		alwaysRender.Layers = append(alwaysRender.Layers, atlas.Layer{
+			Name:         name,
+			MinZoom:      10,
+			MaxZoom:      12,
+			AlwaysRender: name == "background",
+			// the center of tile 3/3/3
+			Provider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{-2504688.54, 2504688.54}},
+		})
+	}
+
+	//	testMap with the values needed to encode
+	grid := testMap
+	grid.SRID = tegola.WebMercator
+	grid.TileExtent = 4096
+	grid.TileBuffer = 64
+
+	type tcase struct {
+		grid           atlas.Map
+		z, x, y        uint64
+		layerNames     []string
+		expectedLayers []string
+		expectedErr    error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		out, err := tc.grid.RenderTileLayers(context.Background(), tc.z, tc.x, tc.y, tc.layerNames)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if tc.expectedErr != nil {
+			return
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var names []string
+		for _, l := range vt.Layers {
+			names = append(names, l.GetName())
+		}
+
+		if !reflect.DeepEqual(names, tc.expectedLayers) {
+			t.Errorf("layers, expected %v got %v", tc.expectedLayers, names)
+		}
+	}
+
+	tests := map[string]tcase{
+		"two of three layers": {
+			grid:           threeLayers,
+			z:              2,
+			x:              1,
+			y:              1,
+			layerNames:     []string{"c", "a"},
+			expectedLayers: []string{"a", "c"},
+		},
+		"testMap subset": {
+			grid:           grid,
+			z:              10,
+			layerNames:     []string{"test-layer-2-name"},
+			expectedLayers: []string{"test-layer-2-name"},
+		},
+		"testMap layer outside of its zoom range": {
+			grid:           grid,
+			z:              5,
+			layerNames:     []string{"test-layer", "test-layer-2-name"},
+			expectedLayers: []string{"test-layer"},
+		},
+		"always rendered layer outside of its zoom range": {
+			grid:           alwaysRender,
+			z:              3,
+			x:              3,
+			y:              3,
+			layerNames:     []string{"background", "roads"},
+			expectedLayers: []string{"background"},
+		},
+		"unknown layer": {
+			grid:        threeLayers,
+			z:           2,
+			layerNames:  []string{"a", "d"},
+			expectedErr: atlas.ErrLayerNotFound{MapName: "three-layers", LayerName: "d"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// tileHalfProvider records the tiles it's queried for and returns a polygon covering the west half
+// of the queried tile
+type tileHalfProvider struct {
+	sync.Mutex
+	queried [][3]uint64
+}
+
+func (*tileHalfProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *tileHalfProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	z, x, y := t.ZXY()
+	p.Lock()
+	p.queried = append(p.queried, [3]uint64{z, x, y})
+	p.Unlock()
+
+	ext, _ := t.Extent()
+	midX := (ext[0][0] + ext[1][0]) / 2
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Polygon{{{ext[0][0], ext[0][1]}, {midX, ext[0][1]}, {midX, ext[1][1]}, {ext[0][0], ext[1][1]}}},
+		SRID:     tegola.WebMercator,
+	})
+}
+
+func TestMapOverZoom(t *testing.T) {
+	type tcase struct {
+		overZoom bool
+		z, x, y  uint64
+		// the tile the provider is expected to be queried for, nil if it's not expected to be queried
+		expectedQuery *[3]uint64
+		// nil if no feature is expected
+		expectedExtent *[2][2]int64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		p := &tileHalfProvider{}
+
+		m := atlas.NewWebMercatorMap("overzoom")
+		m.OverZoom = tc.overZoom
+		m.Layers = []atlas.Layer{
+			{
+				Name:         "polygons",
+				MaxZoom:      18,
+				Provider:     p,
+				DontSimplify: true,
+			},
+		}
+
+		out, err := m.RenderTileLayers(context.Background(), tc.z, tc.x, tc.y, []string{"polygons"})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		switch {
+		case tc.expectedQuery == nil && len(p.queried) != 0:
+			t.Errorf("queried tiles, expected none got %v", p.queried)
+		case tc.expectedQuery != nil && (len(p.queried) != 1 || p.queried[0] != *tc.expectedQuery):
+			t.Errorf("queried tiles, expected [%v] got %v", *tc.expectedQuery, p.queried)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if tc.expectedExtent == nil {
+			for _, l := range vt.Layers {
+				if len(l.Features) != 0 {
+					t.Errorf("layer (%v) feature count, expected 0 got %v", l.GetName(), len(l.Features))
+				}
+			}
+			return
+		}
+
+		if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+			t.Fatalf("expected a single layer with a single feature got %v", vt.Layers)
+		}
+
+		ext := featurePixelExtent(vt.Layers[0].Features[0])
+		if ext != *tc.expectedExtent {
+			t.Errorf("feature extent, expected %v got %v", *tc.expectedExtent, ext)
+		}
+	}
+
+	tests := map[string]tcase{
+		"max zoom": {
+			overZoom:       true,
+			z:              18,
+			x:              1000,
+			y:              2000,
+			expectedQuery:  &[3]uint64{18, 1000, 2000},
+			expectedExtent: &[2][2]int64{{0, 0}, {2048, 4096}},
+		},
+		// the z22 tile is in the west half of its z18 ancestor, which the polygon covers
+		"over zoomed inside": {
+			overZoom:       true,
+			z:              22,
+			x:              16000 + 5,
+			y:              32000 + 7,
+			expectedQuery:  &[3]uint64{18, 1000, 2000},
+			expectedExtent: &[2][2]int64{{-64, -64}, {4160, 4160}},
+		},
+		// the z22 tile is in the east half of its z18 ancestor, which the polygon doesn't cover
+		"over zoomed outside": {
+			overZoom:      true,
+			z:             22,
+			x:             16000 + 12,
+			y:             32000 + 7,
+			expectedQuery: &[3]uint64{18, 1000, 2000},
+		},
+		"over zoom disabled": {
+			z: 22,
+			x: 16000 + 5,
+			y: 32000 + 7,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// panickingProvider panics reading the features of the layer named panic
+type panickingProvider struct{}
+
+func (panickingProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (panickingProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	if layer == "panic" {
+		var tags map[string]interface{}
+		tags["boom"] = true
+	}
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{0, 0},
+		SRID:     tegola.WebMercator,
+	})
+}
+
+func TestEncodeProviderPanic(t *testing.T) {
+	type tcase struct {
+		layers []string
+		// the layer expected to panic, empty if the tile is expected to encode
+		expectedPanic string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("panic")
+		for _, name := range tc.layers {
+			m.Layers = append(m.Layers, atlas.Layer{
+				Name:              name,
+				ProviderLayerName: name,
+				Provider:          panickingProvider{},
+			})
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if tc.expectedPanic == "" {
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if len(out) == 0 {
+				t.Errorf("expected a tile got no output")
+			}
+			return
+		}
+
+		perr, ok := err.(atlas.ErrProviderPanic)
+		if !ok {
+			t.Fatalf("error, expected atlas.ErrProviderPanic got %T (%v)", err, err)
+		}
+		if perr.LayerName != tc.expectedPanic {
+			t.Errorf("layer name, expected %v got %v", tc.expectedPanic, perr.LayerName)
+		}
+		if perr.Value == nil || len(perr.Stack) == 0 {
+			t.Errorf("expected the recovered value and stack got %v and %v bytes", perr.Value, len(perr.Stack))
+		}
+		if out != nil {
+			t.Errorf("expected no output got %v bytes", len(out))
+		}
+	}
+
+	tests := map[string]tcase{
+		"no panic": {
+			layers: []string{"points"},
+		},
+		"panic": {
+			layers:        []string{"panic"},
+			expectedPanic: "panic",
+		},
+		// a panicking layer fails the tile even though the other layers encode
+		"panic with other layers": {
+			layers:        []string{"points", "panic", "more_points"},
+			expectedPanic: "panic",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeLayerVersion(t *testing.T) {
+	type tcase struct {
+		version  uint
+		expected uint32
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("versioned")
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "points",
+				Provider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{0, 0}},
+				Version:  tc.version,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if len(vt.Layers) != 1 {
+			t.Fatalf("expected a single layer got %v", len(vt.Layers))
+		}
+		if v := vt.Layers[0].GetVersion(); v != tc.expected {
+			t.Errorf("version, expected %v got %v", tc.expected, v)
+		}
+	}
+
+	tests := map[string]tcase{
+		"default": {
+			expected: 2,
+		},
+		"version 1": {
+			version:  1,
+			expected: 1,
+		},
+		"version 2": {
+			version:  2,
+			expected: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeInvalidCoordinates(t *testing.T) {
+	type tcase struct {
+		fail     bool
+		expected []uint64
+	}
+
+	// a little endian WKB point whose x coordinate bytes decode to NaN
+	nanPoint, err := wkb.DecodeBytes([]byte{
+		0x01,
+		0x01, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x7f,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	})
+	if err != nil {
+		t.Fatalf("err decoding wkb: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("invalid-coordinates")
+		m.Layers = []atlas.Layer{
+			{
+				Name: "points",
+				Provider: featuresProvider{features: []provider.Feature{
+					{ID: 1, Geometry: geom.Point{0, 0}, SRID: tegola.WebMercator},
+					{ID: 2, Geometry: nanPoint, SRID: tegola.WebMercator},
+				}},
+				FailOnInvalidCoordinates: tc.fail,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var ids []uint64
+		for _, l := range vt.Layers {
+			for _, f := range l.Features {
+				ids = append(ids, f.GetId())
+			}
+		}
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("feature ids, expected %v got %v", tc.expected, ids)
+		}
+	}
+
+	tests := map[string]tcase{
+		// the feature is dropped rather than encoded
+		"drop": {
+			expected: []uint64{1},
+		},
+		// the layer fails and is left out of the tile
+		"fail": {
+			fail: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// featureVertices decodes the pixel coordinates of the feature's vertices in command order
+func featureVertices(f *vectorTile.Tile_Feature) (vertices [][2]int64) {
+	zigzag := func(v uint32) int64 { return int64(int32(v>>1) ^ -int32(v&1)) }
+
+	var x, y int64
+	g := f.Geometry
+	for i := 0; i < len(g); {
+		cmd, count := g[i]&0x7, int(g[i]>>3)
+		i++
+		if cmd == 7 { // close path
+			continue
+		}
+		for j := 0; j < count; j++ {
+			x += zigzag(g[i])
+			y += zigzag(g[i+1])
+			i += 2
+			vertices = append(vertices, [2]int64{x, y})
+		}
+	}
+	return vertices
+}
+
+func TestEncodeReverseLines(t *testing.T) {
+	const half = 20037508.34 / 2
+
+	type tcase struct {
+		reverse  bool
+		expected [][2]int64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("reversed")
+		m.Layers = []atlas.Layer{
+			{
+				Name: "lines",
+				Provider: featuresProvider{features: []provider.Feature{
+					{ID: 1, Geometry: geom.LineString{{-half, half}, {0, 0}, {half, 0}}, SRID: tegola.WebMercator},
+				}},
+				ReverseLines: tc.reverse,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+			t.Fatalf("expected a single layer with a single feature got %v", vt.Layers)
+		}
+		if vertices := featureVertices(vt.Layers[0].Features[0]); !reflect.DeepEqual(vertices, tc.expected) {
+			t.Errorf("vertices, expected %v got %v", tc.expected, vertices)
+		}
+	}
+
+	tests := map[string]tcase{
+		"unchanged": {
+			expected: [][2]int64{{1024, 1024}, {2048, 2048}, {3072, 2048}},
+		},
+		"reversed": {
+			reverse:  true,
+			expected: [][2]int64{{3072, 2048}, {2048, 2048}, {1024, 1024}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeLayerZIndex(t *testing.T) {
+	type tcase struct {
+		zIndexes []int
+		expected []string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("ordered")
+		for i, name := range []string{"a", "b", "c"} {
+			m.Layers = append(m.Layers, atlas.Layer{
+				Name:     name,
+				Provider: pointProvider{srid: tegola.WebMercator, pt: geom.Point{float64(i), 0}},
+				ZIndex:   tc.zIndexes[i],
+			})
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var names []string
+		for _, l := range vt.Layers {
+			names = append(names, l.GetName())
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("layer order, expected %v got %v", tc.expected, names)
+		}
+	}
+
+	tests := map[string]tcase{
+		"map order": {
+			zIndexes: []int{0, 0, 0},
+			expected: []string{"a", "b", "c"},
+		},
+		"z index": {
+			zIndexes: []int{2, 0, 1},
+			expected: []string{"b", "c", "a"},
+		},
+		"ties keep map order": {
+			zIndexes: []int{1, -1, 1},
+			expected: []string{"b", "a", "c"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeOversizeRatio(t *testing.T) {
+	type tcase struct {
+		ratio    float64
+		expected int
+	}
+
+	// a polygon covering the whole web mercator world
+	const max = 20037508.34
+	world := provider.Feature{
+		ID:       1,
+		Geometry: geom.Polygon{{{-max, -max}, {max, -max}, {max, max}, {-max, max}}},
+		SRID:     tegola.WebMercator,
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("oceans")
+		m.Layers = []atlas.Layer{
+			{
+				Name:          "ocean",
+				Provider:      featuresProvider{features: []provider.Feature{world}},
+				OversizeRatio: tc.ratio,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(14, 8192, 8192, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var got int
+		for _, l := range vt.Layers {
+			got += len(l.Features)
+		}
+		if got != tc.expected {
+			t.Errorf("features, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"clip": {
+			ratio:    0,
+			expected: 1,
+		},
+		"ratio larger than the feature": {
+			ratio:    100000,
+			expected: 1,
+		},
+		"skip": {
+			ratio:    100,
+			expected: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeLayerOutputName(t *testing.T) {
+	type tcase struct {
+		outputName string
+		expected   string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("aliased")
+		m.Layers = []atlas.Layer{
+			{
+				Name:       "internal",
+				Provider:   pointProvider{srid: tegola.WebMercator, pt: geom.Point{0, 0}},
+				OutputName: tc.outputName,
+			},
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		if len(vt.Layers) != 1 {
+			t.Fatalf("expected a single layer got %v", len(vt.Layers))
+		}
+		if name := vt.Layers[0].GetName(); name != tc.expected {
+			t.Errorf("layer name, expected %v got %v", tc.expected, name)
+		}
+	}
+
+	tests := map[string]tcase{
+		"name": {
+			expected: "internal",
+		},
+		"output name": {
+			outputName: "public",
+			expected:   "public",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// slowProvider simulates a slow query and records the number of queries in flight
+type slowProvider struct {
+	delay time.Duration
+
+	sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (*slowProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *slowProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.Lock()
+	p.inFlight++
+	if p.inFlight > p.maxInFlight {
+		p.maxInFlight = p.inFlight
+	}
+	p.Unlock()
+
+	defer func() {
+		p.Lock()
+		p.inFlight--
+		p.Unlock()
+	}()
+
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{0, 0},
+		SRID:     tegola.WebMercator,
+		Tags: map[string]interface{}{
+			"name": layer,
+		},
+	})
+}
+
+func TestEncodeLayerConcurrency(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	type tcase struct {
+		concurrency int
+		// the expected maximum number of queries in flight
+		expectedInFlight int
+		// the render is expected to take at least minDuration and less than maxDuration
+		minDuration time.Duration
+		maxDuration time.Duration
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		p := &slowProvider{delay: delay}
+
+		m := atlas.NewWebMercatorMap("slow")
+		m.LayerConcurrency = tc.concurrency
+		for _, name := range []string{"a", "b", "c"} {
+			m.Layers = append(m.Layers, atlas.Layer{
+				Name:              name,
+				ProviderLayerName: name,
+				Provider:          p,
+			})
+		}
+
+		start := time.Now()
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		elapsed := time.Since(start)
+
+		if p.maxInFlight != tc.expectedInFlight {
+			t.Errorf("queries in flight, expected %v got %v", tc.expectedInFlight, p.maxInFlight)
+		}
+		if elapsed < tc.minDuration || elapsed >= tc.maxDuration {
+			t.Errorf("render duration, expected between %v and %v got %v", tc.minDuration, tc.maxDuration, elapsed)
+		}
+
+		// the layers keep the map's order regardless of which query finished first
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var names []string
+		for _, l := range vt.Layers {
+			names = append(names, l.GetName())
+		}
+		if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(names, expected) {
+			t.Errorf("layer names, expected %v got %v", expected, names)
+		}
+	}
+
+	tests := map[string]tcase{
+		// the render takes about as long as the slowest layer
+		"unbounded": {
+			expectedInFlight: 3,
+			minDuration:      delay,
+			maxDuration:      2 * delay,
+		},
+		"two at a time": {
+			concurrency:      2,
+			expectedInFlight: 2,
+			minDuration:      2 * delay,
+			maxDuration:      3 * delay,
+		},
+		"sequential": {
+			concurrency:      1,
+			expectedInFlight: 1,
+			minDuration:      3 * delay,
+			maxDuration:      6 * delay,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEncodeFieldRename(t *testing.T) {
+	m := atlas.NewWebMercatorMap("rename")
+	m.Layers = []atlas.Layer{
+		{
+			Name: "roads",
+			Provider: featuresProvider{features: []provider.Feature{
+				{
+					ID:       1,
+					Geometry: geom.Point{1000, 0},
+					SRID:     tegola.WebMercator,
+					Tags:     map[string]interface{}{"osm_highway": "primary", "name": "main"},
+				},
+				// the renamed tag collides with the highway tag and is dropped
+				{
+					ID:       2,
+					Geometry: geom.Point{2000, 0},
+					SRID:     tegola.WebMercator,
+					Tags:     map[string]interface{}{"osm_highway": "residential", "highway": "track"},
+				},
+			}},
+			FieldRename: map[string]string{"osm_highway": "highway"},
+		},
+	}
+
+	out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var vt vectorTile.Tile
+	if err = proto.Unmarshal(out, &vt); err != nil {
+		t.Fatalf("err unmarshalling tile: %v", err)
+	}
+	if len(vt.Layers) != 1 {
+		t.Fatalf("layer count, expected 1 got %v", len(vt.Layers))
+	}
+
+	l := vt.Layers[0]
+	tags := map[uint64]map[string]string{}
+	for _, f := range l.Features {
+		ft := map[string]string{}
+		for i := 0; i+1 < len(f.Tags); i += 2 {
+			ft[l.Keys[f.Tags[i]]] = l.Values[f.Tags[i+1]].GetStringValue()
+		}
+		tags[f.GetId()] = ft
+	}
+
+	expected := map[uint64]map[string]string{
+		1: {"highway": "primary", "name": "main"},
+		2: {"highway": "track"},
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("tags, expected %v got %v", expected, tags)
+	}
+}
@@ -0,0 +1,59 @@
+package atlas
+
+import "time"
+
+//	Metrics receives the render, cache and provider query events of an atlas, i.e. to export them to
+//	Prometheus. the events are labeled with the map name and, where it applies, the zoom or layer.
+//	implementations must be safe for concurrent use
+type Metrics interface {
+	//	TileRendered is called after a tile of the map is encoded with the time it took to encode
+	TileRendered(mapName string, z uint64, duration time.Duration)
+	//	CacheHit is called when a tile of the map is read from the cache backend
+	CacheHit(mapName string, z uint64)
+	//	CacheMiss is called when a tile of the map is not found in the cache backend
+	CacheMiss(mapName string, z uint64)
+	//	ProviderQueried is called after the provider query of a layer of the map with the time it took
+	//	and the error it failed with, nil on success
+	ProviderQueried(mapName, layerName string, duration time.Duration, err error)
+}
+
+//	NopMetrics discards the events. it's used when no metrics are set
+type NopMetrics struct{}
+
+func (NopMetrics) TileRendered(mapName string, z uint64, duration time.Duration)                {}
+func (NopMetrics) CacheHit(mapName string, z uint64)                                            {}
+func (NopMetrics) CacheMiss(mapName string, z uint64)                                           {}
+func (NopMetrics) ProviderQueried(mapName, layerName string, duration time.Duration, err error) {}
+
+//	SetMetrics sets the receiver of the atlas's events. the render and provider query events are
+//	received for the maps added after it is set. nil discards the events
+func (a *Atlas) SetMetrics(metrics Metrics) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.metrics = metrics
+}
+
+//	Metrics returns the receiver of the atlas's events, NopMetrics if none is set
+func (a *Atlas) Metrics() Metrics {
+	a.RLock()
+	defer a.RUnlock()
+
+	if a.metrics == nil {
+		return NopMetrics{}
+	}
+	return a.metrics
+}
+
+//	SetMetrics sets the receiver of the DefaultAtlas's events
+func SetMetrics(metrics Metrics) {
+	DefaultAtlas.SetMetrics(metrics)
+}
+
+//	tileMetrics returns the receiver of the map's events, NopMetrics if none is set
+func (m Map) tileMetrics() Metrics {
+	if m.metrics == nil {
+		return NopMetrics{}
+	}
+	return m.metrics
+}
@@ -0,0 +1,98 @@
+package atlas_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+// recordingMetrics records the events it receives, without their durations
+type recordingMetrics struct {
+	sync.Mutex
+	events []string
+}
+
+func (r *recordingMetrics) record(event string) {
+	r.Lock()
+	defer r.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingMetrics) TileRendered(mapName string, z uint64, duration time.Duration) {
+	r.record("rendered " + mapName)
+}
+
+func (r *recordingMetrics) CacheHit(mapName string, z uint64) {
+	r.record("hit " + mapName)
+}
+
+func (r *recordingMetrics) CacheMiss(mapName string, z uint64) {
+	r.record("miss " + mapName)
+}
+
+func (r *recordingMetrics) ProviderQueried(mapName, layerName string, duration time.Duration, err error) {
+	if err != nil {
+		r.record("query failed " + mapName + "/" + layerName)
+		return
+	}
+	r.record("query " + mapName + "/" + layerName)
+}
+
+func TestAtlasMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+
+	a := &atlas.Atlas{}
+	a.SetCache(memory.New())
+	a.SetMetrics(metrics)
+
+	m := atlas.NewWebMercatorMap("measured")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "layer1",
+			Provider: &test.TileProvider{},
+		},
+	}
+	if err := a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	m, err := a.Map("measured")
+	if err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// seed a tile then serve it and a tile which was not seeded
+	if err = a.SeedMapTile(ctx, m, 2, 1, 3); err != nil {
+		t.Fatalf("err seeding tile: %v", err)
+	}
+	if _, _, err = a.RenderTileWithInfo(ctx, m, 2, 1, 3); err != nil {
+		t.Fatalf("err rendering seeded tile: %v", err)
+	}
+	if _, _, err = a.RenderTileWithInfo(ctx, m, 2, 0, 0); err != nil {
+		t.Fatalf("err rendering tile: %v", err)
+	}
+
+	expected := []string{
+		"query measured/layer1",
+		"rendered measured",
+		"hit measured",
+		"miss measured",
+		"query measured/layer1",
+		"rendered measured",
+	}
+	if !reflect.DeepEqual(metrics.events, expected) {
+		t.Errorf("events, expected %v got %v", expected, metrics.events)
+	}
+
+	// without metrics the events are discarded
+	if _, ok := (&atlas.Atlas{}).Metrics().(atlas.NopMetrics); !ok {
+		t.Errorf("expected NopMetrics for an atlas without metrics")
+	}
+}
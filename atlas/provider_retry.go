@@ -0,0 +1,140 @@
+package atlas
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	providerRetry configures how failed provider queries are retried
+type providerRetry struct {
+	//	the maximum number of queries per layer and tile, including the first
+	maxAttempts int
+	//	the wait before the first retry. it doubles with each following retry
+	backoff time.Duration
+	//	the deadline of each query. 0 only limits the queries by the request context
+	attemptTimeout time.Duration
+}
+
+//	do calls query until it succeeds or the attempts are used up, waiting with an exponential backoff
+//	between attempts. the last error is returned if every attempt fails. the backoff is interrupted
+//	and the context's error returned if ctx is canceled.
+func (r providerRetry) do(ctx context.Context, query func(ctx context.Context) error) error {
+	var err error
+	backoff := r.backoff
+
+	for attempt := 1; ; attempt++ {
+		err = r.attempt(ctx, query)
+		if err == nil || attempt >= r.maxAttempts {
+			return err
+		}
+
+		//	the request was canceled or timed out, there's no one left to retry for
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Infof("provider query attempt %v of %v failed, retrying in %v: %v", attempt, r.maxAttempts, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+func (r providerRetry) attempt(ctx context.Context, query func(ctx context.Context) error) error {
+	if r.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.attemptTimeout)
+		defer cancel()
+	}
+
+	return query(ctx)
+}
+
+//	retryingProvider retries the failed queries of a registered provider. the features of each
+//	attempt are buffered and only streamed to the caller once an attempt succeeds, so the caller
+//	never sees the partial results of a failed attempt.
+type retryingProvider struct {
+	provider.Tiler
+	retry providerRetry
+}
+
+//	SRID returns the SRID the wrapped provider declares for the layer, 0 if it doesn't implement provider.SRIDer
+func (p retryingProvider) SRID(layer string) uint64 {
+	if s, ok := p.Tiler.(provider.SRIDer); ok {
+		return s.SRID(layer)
+	}
+	return 0
+}
+
+//	TileFeatures reads the layer's features for the tile from the wrapped provider, retrying failed queries
+func (p retryingProvider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	return p.stream(ctx, fn, func(ctx context.Context, fn func(f *provider.Feature) error) error {
+		return p.Tiler.TileFeatures(ctx, layer, tile, fn)
+	})
+}
+
+//	stream buffers the features of each attempt of query and passes the features of the successful attempt to fn
+func (p retryingProvider) stream(ctx context.Context, fn func(f *provider.Feature) error, query func(ctx context.Context, fn func(f *provider.Feature) error) error) error {
+	var features []provider.Feature
+
+	err := p.retry.do(ctx, func(ctx context.Context) error {
+		//	drop the features of a failed attempt
+		features = features[:0]
+
+		return query(ctx, func(f *provider.Feature) error {
+			features = append(features, *f)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range features {
+		if err := fn(&features[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//	retryingFilterProvider is a retryingProvider for providers supporting SQL filters
+type retryingFilterProvider struct {
+	retryingProvider
+}
+
+//	TileFeaturesWithFilter reads the layer's features matching the filter for the tile from the
+//	wrapped provider, retrying failed queries
+func (p retryingFilterProvider) TileFeaturesWithFilter(ctx context.Context, layer string, filter string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	filterer := p.Tiler.(provider.SQLFilterer)
+
+	return p.stream(ctx, fn, func(ctx context.Context, fn func(f *provider.Feature) error) error {
+		return filterer.TileFeaturesWithFilter(ctx, layer, filter, tile, fn)
+	})
+}
+
+//	newRetryingProvider wraps the provider so its failed queries are retried. the returned provider
+//	implements provider.SQLFilterer if the wrapped provider does
+func newRetryingProvider(p provider.Tiler, retry providerRetry) provider.Tiler {
+	rp := retryingProvider{
+		Tiler: p,
+		retry: retry,
+	}
+
+	if _, ok := p.(provider.SQLFilterer); ok {
+		return retryingFilterProvider{rp}
+	}
+
+	return rp
+}
@@ -0,0 +1,81 @@
+package atlas
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+//	RenderInfo describes how a tile returned by RenderTileWithInfo was produced
+type RenderInfo struct {
+	//	FromCache is true when the tile was read from the cache backend rather than encoded
+	FromCache bool
+	//	Backend is the type of the cache backend (i.e. "file", "redis", "s3"). empty if no cache is set
+	Backend string
+	//	RenderDuration is the time taken to encode the tile. 0 when the tile was read from the cache
+	RenderDuration time.Duration
+	//	FeatureCounts is the number of features the providers returned for each layer, keyed by the
+	//	layer's name in the tile, before the features are filtered and clipped to the tile. nil when
+	//	the tile was read from the cache. layers whose provider serves them already encoded are not counted
+	FeatureCounts map[string]int
+}
+
+//	RenderTileWithInfo returns the map's tile at z, x, y (addressed using the map's Scheme) from the
+//	configured cache backend, encoding the tile on a cache miss, along with how the tile was produced.
+//	an encoded tile is not written to the cache. a failed cache read is logged and the tile is encoded.
+//	the tile is the uncompressed MVT protobuf, cache backends storing gzipped tiles decompress them when read.
+//	the tiles of a map with a simplification override (see Map.OverrideSimplify) or filtered by time
+//	(see Map.FilterFeaturesByTime) are always encoded
+func (a *Atlas) RenderTileWithInfo(ctx context.Context, m Map, z, x, y uint64) ([]byte, RenderInfo, error) {
+	var info RenderInfo
+
+	//	normalize the tile coordinates for the map's tile scheme
+	z, x, y = m.ToXYZ(z, x, y)
+
+	//	the cached tiles are simplified with the layers' own simplification and not filtered by time
+	if a.cacher != nil && m.simplify == nil && m.timeWindow == nil {
+		info.Backend = cacheBackend(a.cacher)
+
+		key := m.cacheKey(z, x, y)
+
+		b, hit, err := a.cacher.Get(&key)
+		switch {
+		case err != nil:
+			log.Warnf("error reading tile (%v) from the cache: %v", key.String(), err)
+		case hit:
+			a.Metrics().CacheHit(m.Name, z)
+			info.FromCache = true
+			return b, info, nil
+		default:
+			a.Metrics().CacheMiss(m.Name, z)
+		}
+	}
+
+	tile := slippy.NewTile(z, x, y, float64(m.TileBuffer), m.SRID)
+
+	info.FeatureCounts = make(map[string]int, len(m.Layers))
+
+	start := time.Now()
+	b, err := m.encodeCounting(ctx, tile, info.FeatureCounts)
+	if err != nil {
+		return nil, info, err
+	}
+	info.RenderDuration = time.Since(start)
+
+	return b, info, nil
+}
+
+//	cacheBackend returns the type of the cache backend, the name of the package implementing it
+func cacheBackend(c cache.Interface) string {
+	t := reflect.TypeOf(c)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return path.Base(t.PkgPath())
+}
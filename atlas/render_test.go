@@ -0,0 +1,236 @@
+package atlas_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+func TestRenderTileWithInfo(t *testing.T) {
+	type tcase struct {
+		cache bool
+		// seed the tile before rendering it
+		seed bool
+
+		expectedFromCache bool
+		expectedBackend   string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		a := &atlas.Atlas{}
+		if tc.cache {
+			a.SetCache(memory.New())
+		}
+
+		m := atlas.NewWebMercatorMap("test-map")
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "layer1",
+				Provider: &test.TileProvider{},
+			},
+		}
+
+		ctx := context.Background()
+
+		if tc.seed {
+			if err := a.SeedMapTile(ctx, m, 2, 1, 3); err != nil {
+				t.Fatalf("err seeding tile: %v", err)
+			}
+		}
+
+		b, info, err := a.RenderTileWithInfo(ctx, m, 2, 1, 3)
+		if err != nil {
+			t.Fatalf("err rendering tile: %v", err)
+		}
+
+		if info.FromCache != tc.expectedFromCache {
+			t.Errorf("from cache, expected %v got %v", tc.expectedFromCache, info.FromCache)
+		}
+		if info.Backend != tc.expectedBackend {
+			t.Errorf("backend, expected %v got %v", tc.expectedBackend, info.Backend)
+		}
+		if info.FromCache && info.RenderDuration != 0 {
+			t.Errorf("render duration, expected 0 for a cached tile got %v", info.RenderDuration)
+		}
+
+		// the cached tile matches the encoded one
+		encoded, _, err := (&atlas.Atlas{}).RenderTileWithInfo(ctx, m, 2, 1, 3)
+		if err != nil {
+			t.Fatalf("err encoding tile: %v", err)
+		}
+		if !bytes.Equal(b, encoded) {
+			t.Errorf("tile, expected %v got %v", encoded, b)
+		}
+
+		// the tile is the raw protobuf, it decodes without being gunzipped
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(b, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+		if len(vt.Layers) != 1 || vt.Layers[0].GetName() != "layer1" {
+			t.Errorf("layers, expected [layer1] got %v", vt.Layers)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no cache": {},
+		"first render": {
+			cache:           true,
+			expectedBackend: "memory",
+		},
+		"seeded": {
+			cache:             true,
+			seed:              true,
+			expectedFromCache: true,
+			expectedBackend:   "memory",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestRenderTileSimplifyOverride(t *testing.T) {
+	// the size of a pixel of a zoom 1 tile
+	const pixel = 20037508.34 / 4096
+
+	// a line across the tile which zigzags 2 pixels off of its course every fourth vertex. the middle
+	// vertex of the three between the zigzags is redundant
+	var line geom.LineString
+	for i := 0; i < 30; i++ {
+		y := 10000000.0
+		if i%4 == 3 {
+			y += 2 * pixel
+		}
+		line = append(line, [2]float64{-18000000 + float64(i)*500000, y})
+	}
+
+	a := &atlas.Atlas{}
+	a.SetCache(memory.New())
+
+	m := atlas.NewWebMercatorMap("simplified")
+	m.Layers = []atlas.Layer{
+		{
+			Name: "lines",
+			Provider: featuresProvider{features: []provider.Feature{
+				{ID: 1, Geometry: line, SRID: tegola.WebMercator},
+			}},
+		},
+	}
+
+	ctx := context.Background()
+	// the cached tile is simplified with the layer's simplification
+	if err := a.SeedMapTile(ctx, m, 1, 0, 0); err != nil {
+		t.Fatalf("err seeding tile: %v", err)
+	}
+
+	// vertices renders the tile and returns the number of vertices of the line
+	vertices := func(t *testing.T, m atlas.Map, fromCache bool) int {
+		b, info, err := a.RenderTileWithInfo(ctx, m, 1, 0, 0)
+		if err != nil {
+			t.Fatalf("err rendering tile: %v", err)
+		}
+		if info.FromCache != fromCache {
+			t.Errorf("from cache, expected %v got %v", fromCache, info.FromCache)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(b, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+		if len(vt.Layers) != 1 || len(vt.Layers[0].Features) != 1 {
+			t.Fatalf("expected 1 layer with 1 feature got %v", vt.Layers)
+		}
+
+		return len(featureVertices(vt.Layers[0].Features[0]))
+	}
+
+	// seeding the overridden map doesn't cache the overridden tile
+	if _, err := a.SeedMapTileWithResult(ctx, m.OverrideSimplify(atlas.SimplifyOverride{DontSimplify: true}), 1, 0, 0); err != nil {
+		t.Fatalf("err seeding overridden tile: %v", err)
+	}
+
+	// the overridden tiles are encoded rather than read from the cache
+	layerDefault := vertices(t, m, true)
+	dontSimplify := vertices(t, m.OverrideSimplify(atlas.SimplifyOverride{DontSimplify: true}), false)
+	tolerance := vertices(t, m.OverrideSimplify(atlas.SimplifyOverride{Tolerance: 5}), false)
+
+	// the layer's simplification drops redundant vertices
+	if dontSimplify != len(line) {
+		t.Errorf("dont simplify vertices, expected %v got %v", len(line), dontSimplify)
+	}
+	if layerDefault >= dontSimplify {
+		t.Errorf("layer default vertices, expected fewer than %v got %v", dontSimplify, layerDefault)
+	}
+	// the zigzags are within the tolerance
+	if tolerance != 2 {
+		t.Errorf("tolerance vertices, expected 2 got %v", tolerance)
+	}
+}
+
+func TestRenderTileFeatureCounts(t *testing.T) {
+	point := func(id uint64, x, y float64) provider.Feature {
+		return provider.Feature{ID: id, Geometry: geom.Point{x, y}, SRID: tegola.WebMercator}
+	}
+
+	a := &atlas.Atlas{}
+	a.SetCache(memory.New())
+
+	m := atlas.NewWebMercatorMap("counted")
+	m.Layers = []atlas.Layer{
+		{
+			Name: "points",
+			Provider: featuresProvider{features: []provider.Feature{
+				point(1, 1000, 1000),
+				point(2, 2000, 2000),
+				// outside of the tile, it's counted before it's clipped
+				point(3, -1000000, -1000000),
+			}},
+		},
+		{
+			Name: "places",
+			Provider: featuresProvider{features: []provider.Feature{
+				point(1, 3000, 3000),
+				point(2, 4000, 4000),
+			}},
+		},
+	}
+
+	ctx := context.Background()
+
+	_, info, err := a.RenderTileWithInfo(ctx, m, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("err rendering tile: %v", err)
+	}
+
+	expected := map[string]int{"points": 3, "places": 2}
+	if !reflect.DeepEqual(info.FeatureCounts, expected) {
+		t.Errorf("feature counts, expected %v got %v", expected, info.FeatureCounts)
+	}
+
+	// the features of a cached tile are not counted
+	if err = a.SeedMapTile(ctx, m, 1, 1, 0); err != nil {
+		t.Fatalf("err seeding tile: %v", err)
+	}
+	if _, info, err = a.RenderTileWithInfo(ctx, m, 1, 1, 0); err != nil {
+		t.Fatalf("err rendering tile: %v", err)
+	}
+	if !info.FromCache || info.FeatureCounts != nil {
+		t.Errorf("cached tile feature counts, expected nil got %v", info.FeatureCounts)
+	}
+}
@@ -0,0 +1,186 @@
+package atlas
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/slippy"
+)
+
+//	SeedOrder is the order in which tiles are enumerated when seeding
+type SeedOrder int
+
+const (
+	//	SeedOrderRowMajor enumerates tiles row by row starting with the top left tile. This is the default.
+	SeedOrderRowMajor SeedOrder = iota
+	//	SeedOrderCenterOut enumerates tiles by increasing distance from the center of the bounds
+	//	so the central tiles are seeded first
+	SeedOrderCenterOut
+)
+
+//	TilesInBounds returns the tiles at zoom z covering the WGS84 bounds (minx, miny, maxx, maxy)
+//	enumerated in the provided order
+func TilesInBounds(bounds [4]float64, z uint64, order SeedOrder) []Tile {
+	minx, miny := slippy.TileFromLonLat(bounds[0], bounds[3], uint(z))
+	maxx, maxy := slippy.TileFromLonLat(bounds[2], bounds[1], uint(z))
+
+	tiles := make([]Tile, 0, (maxx-minx+1)*(maxy-miny+1))
+	for y := miny; y <= maxy; y++ {
+		for x := minx; x <= maxx; x++ {
+			tiles = append(tiles, Tile{Z: z, X: uint64(x), Y: uint64(y)})
+		}
+	}
+
+	if order == SeedOrderCenterOut {
+		//	the center of the tile range in tile coordinates
+		cx := float64(minx+maxx+1) / 2
+		cy := float64(miny+maxy+1) / 2
+
+		dist := func(t Tile) float64 {
+			dx := float64(t.X) + 0.5 - cx
+			dy := float64(t.Y) + 0.5 - cy
+			return dx*dx + dy*dy
+		}
+
+		//	a stable sort keeps tiles of equal distance in row major order
+		sort.SliceStable(tiles, func(i, j int) bool {
+			return dist(tiles[i]) < dist(tiles[j])
+		})
+	}
+
+	return tiles
+}
+
+//	TileCoord is the XYZ coordinates of a tile
+type TileCoord struct {
+	Z, X, Y uint
+}
+
+//	TilesForBounds returns the tiles covering the WGS84 bounds at each zoom from minZoom to maxZoom
+//	inclusive. the tiles are ordered by zoom then row major. an ErrInvalidZoomRange is returned when
+//	minZoom is greater than maxZoom or maxZoom is greater than MaxZoom
+func TilesForBounds(bounds *geom.BoundingBox, minZoom, maxZoom uint) ([]TileCoord, error) {
+	if bounds == nil {
+		return nil, ErrMissingBounds
+	}
+	if minZoom > maxZoom || maxZoom > MaxZoom {
+		return nil, ErrInvalidZoomRange{
+			MinZoom: minZoom,
+			MaxZoom: maxZoom,
+		}
+	}
+
+	var tiles []TileCoord
+	for z := minZoom; z <= maxZoom; z++ {
+		minx, miny := slippy.TileFromLonLat(bounds.MinX(), bounds.MaxY(), z)
+		maxx, maxy := slippy.TileFromLonLat(bounds.MaxX(), bounds.MinY(), z)
+
+		for y := miny; y <= maxy; y++ {
+			for x := minx; x <= maxx; x++ {
+				tiles = append(tiles, TileCoord{Z: z, X: x, Y: y})
+			}
+		}
+	}
+
+	return tiles, nil
+}
+
+//	SeedMapTiles will generate the tiles of the map covering the WGS84 bounds
+//	for each of the zooms and persist them to the configured cache backend.
+//	Tiles are seeded zoom by zoom in the provided order.
+func (a *Atlas) SeedMapTiles(ctx context.Context, m Map, bounds [4]float64, zooms []uint64, order SeedOrder) error {
+	//	confirm we have a cache backend
+	if a.cacher == nil {
+		return ErrMissingCache
+	}
+
+	for _, z := range zooms {
+		for _, t := range TilesInBounds(bounds, z, order) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			//	SeedMapTile expects the coordinates in the map's tile scheme. the conversion is its own inverse
+			z, x, y := m.ToXYZ(t.Z, t.X, t.Y)
+
+			if err := a.SeedMapTile(ctx, m.FilterLayersByZoom(int(z)), z, x, y); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//	SeedTiles will generate the listed tiles of the named map and persist them to the configured cache
+//	backend. the tile coordinates are addressed using the map's Scheme, as with SeedMapTile, so the
+//	tiles returned by TilesForBounds can be seeded for XYZ maps. the tiles are seeded by
+//	concurrency workers, at least 1. tiles outside of the tile grid are not seeded and, along with
+//	the tiles which fail to seed, are reported in an ErrSeedTiles once the other tiles are seeded.
+func (a *Atlas) SeedTiles(ctx context.Context, mapName string, tiles []TileCoord, concurrency int) error {
+	//	confirm we have a cache backend
+	if a.cacher == nil {
+		return ErrMissingCache
+	}
+
+	m, err := a.Map(mapName)
+	if err != nil {
+		return err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	//	the error of each tile, by the tile's position in tiles
+	errs := make([]error, len(tiles))
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+
+			for idx := range queue {
+				t := tiles[idx]
+				errs[idx] = a.SeedMapTile(ctx, m.FilterLayersByZoom(int(t.Z)), uint64(t.Z), uint64(t.X), uint64(t.Y))
+			}
+		}()
+	}
+
+queueTiles:
+	for i, t := range tiles {
+		if t.Z > MaxZoom || t.X >= 1<<t.Z || t.Y >= 1<<t.Z {
+			errs[i] = ErrInvalidTile{Tile: t}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			break queueTiles
+		case queue <- i:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var seedErr ErrSeedTiles
+	for i := range errs {
+		if errs[i] != nil {
+			seedErr.Tiles = append(seedErr.Tiles, tiles[i])
+			seedErr.Errs = append(seedErr.Errs, errs[i])
+		}
+	}
+	if len(seedErr.Errs) > 0 {
+		return seedErr
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package atlas_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+func TestTilesInBounds(t *testing.T) {
+	type tcase struct {
+		bounds   [4]float64
+		zoom     uint64
+		order    atlas.SeedOrder
+		expected []atlas.Tile
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		tiles := atlas.TilesInBounds(tc.bounds, tc.zoom, tc.order)
+
+		if len(tiles) < len(tc.expected) {
+			t.Fatalf("tile count, expected at least %v got %v", len(tc.expected), len(tiles))
+		}
+
+		// only the first tiles are checked
+		if !reflect.DeepEqual(tiles[:len(tc.expected)], tc.expected) {
+			t.Errorf("expected %v got %v", tc.expected, tiles[:len(tc.expected)])
+		}
+	}
+
+	tests := map[string]tcase{
+		"row major": {
+			bounds: tegola.WGS84Bounds,
+			zoom:   1,
+			order:  atlas.SeedOrderRowMajor,
+			expected: []atlas.Tile{
+				{Z: 1, X: 0, Y: 0},
+				{Z: 1, X: 1, Y: 0},
+				{Z: 1, X: 0, Y: 1},
+				{Z: 1, X: 1, Y: 1},
+			},
+		},
+		"center out": {
+			bounds: tegola.WGS84Bounds,
+			zoom:   2,
+			order:  atlas.SeedOrderCenterOut,
+			expected: []atlas.Tile{
+				{Z: 2, X: 1, Y: 1},
+				{Z: 2, X: 2, Y: 1},
+				{Z: 2, X: 1, Y: 2},
+				{Z: 2, X: 2, Y: 2},
+			},
+		},
+		"center out odd tile range": {
+			// 3x3 tiles at zoom 4 centered on tile 4/8/8
+			bounds: [4]float64{0.1, -44.9, 67.4, -0.1},
+			zoom:   4,
+			order:  atlas.SeedOrderCenterOut,
+			expected: []atlas.Tile{
+				{Z: 4, X: 9, Y: 9},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestTilesForBounds(t *testing.T) {
+	type tcase struct {
+		bounds           *geom.BoundingBox
+		minZoom, maxZoom uint
+		expected         []atlas.TileCoord
+		expectedErr      error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		tiles, err := atlas.TilesForBounds(tc.bounds, tc.minZoom, tc.maxZoom)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if !reflect.DeepEqual(tiles, tc.expected) {
+			t.Errorf("expected %v got %v", tc.expected, tiles)
+		}
+	}
+
+	tests := map[string]tcase{
+		"small bounds": {
+			bounds:  &geom.BoundingBox{{0.1, 0.1}, {10, 10}},
+			minZoom: 0,
+			maxZoom: 3,
+			expected: []atlas.TileCoord{
+				{Z: 0, X: 0, Y: 0},
+				{Z: 1, X: 1, Y: 0},
+				{Z: 2, X: 2, Y: 1},
+				{Z: 3, X: 4, Y: 3},
+			},
+		},
+		"across tiles": {
+			bounds:  &geom.BoundingBox{{-10, -10}, {10, 10}},
+			minZoom: 1,
+			maxZoom: 1,
+			expected: []atlas.TileCoord{
+				{Z: 1, X: 0, Y: 0},
+				{Z: 1, X: 1, Y: 0},
+				{Z: 1, X: 0, Y: 1},
+				{Z: 1, X: 1, Y: 1},
+			},
+		},
+		"inverted zoom range": {
+			bounds:      &geom.BoundingBox{{-180, -85.0511}, {180, 85.0511}},
+			minZoom:     2,
+			maxZoom:     1,
+			expectedErr: atlas.ErrInvalidZoomRange{MinZoom: 2, MaxZoom: 1},
+		},
+		"max zoom beyond the tile grid": {
+			bounds:      &geom.BoundingBox{{0.1, 0.1}, {10, 10}},
+			maxZoom:     atlas.MaxZoom + 1,
+			expectedErr: atlas.ErrInvalidZoomRange{MaxZoom: atlas.MaxZoom + 1},
+		},
+		"missing bounds": {
+			maxZoom:     1,
+			expectedErr: atlas.ErrMissingBounds,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestSeedMapTiles(t *testing.T) {
+	c := memory.New()
+
+	a := &atlas.Atlas{}
+	a.SetCache(c)
+
+	m := atlas.NewWebMercatorMap("test-map")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "layer1",
+			Provider: &test.TileProvider{},
+		},
+	}
+
+	zooms := []uint64{0, 1}
+	if err := a.SeedMapTiles(context.Background(), m, tegola.WGS84Bounds, zooms, atlas.SeedOrderCenterOut); err != nil {
+		t.Fatalf("err seeding tiles: %v", err)
+	}
+
+	for _, z := range zooms {
+		for _, tile := range atlas.TilesInBounds(tegola.WGS84Bounds, z, atlas.SeedOrderRowMajor) {
+			key := cache.Key{
+				MapName: m.Name,
+				Z:       int(tile.Z),
+				X:       int(tile.X),
+				Y:       int(tile.Y),
+			}
+
+			val, hit, _ := c.Get(&key)
+			if !hit || len(val) == 0 {
+				t.Errorf("expected tile %v to be seeded", key)
+			}
+		}
+	}
+}
+
+//	setErrCache is a cache which always fails to write
+type setErrCache struct {
+	cache.Interface
+	err error
+}
+
+func (c setErrCache) Set(key *cache.Key, val []byte) error { return c.err }
+
+func TestSeedMapTileSetError(t *testing.T) {
+	setErr := errors.New("set failed")
+
+	a := &atlas.Atlas{}
+	a.SetCache(setErrCache{Interface: memory.New(), err: setErr})
+
+	m := atlas.NewWebMercatorMap("test-map")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "layer1",
+			Provider: &test.TileProvider{},
+		},
+	}
+
+	//	seeding surfaces cache write errors
+	if err := a.SeedMapTile(context.Background(), m, 0, 0, 0); err != setErr {
+		t.Errorf("error, expected %v got %v", setErr, err)
+	}
+}
+
+//	tilesForBounds returns the tiles covering the bounds, failing the test on error
+func tilesForBounds(t *testing.T, bounds *geom.BoundingBox, minZoom, maxZoom uint) []atlas.TileCoord {
+	tiles, err := atlas.TilesForBounds(bounds, minZoom, maxZoom)
+	if err != nil {
+		t.Fatalf("err computing tiles for bounds: %v", err)
+	}
+	return tiles
+}
+
+func TestSeedTiles(t *testing.T) {
+	type tcase struct {
+		tiles       []atlas.TileCoord
+		concurrency int
+		// the tiles expected in the cache
+		expected    []atlas.Tile
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		c := memory.New()
+
+		a := &atlas.Atlas{}
+		a.SetCache(c)
+
+		m := atlas.NewWebMercatorMap("test-map")
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "layer1",
+				Provider: &test.TileProvider{},
+			},
+		}
+		if err := a.AddMap(m); err != nil {
+			t.Fatalf("err adding map: %v", err)
+		}
+
+		err := a.SeedTiles(context.Background(), "test-map", tc.tiles, tc.concurrency)
+		if !reflect.DeepEqual(err, tc.expectedErr) {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+
+		var seeded []atlas.Tile
+		for z := uint64(0); z <= 3; z++ {
+			for y := uint64(0); y < 1<<z; y++ {
+				for x := uint64(0); x < 1<<z; x++ {
+					key := cache.Key{MapName: "test-map", Z: int(z), X: int(x), Y: int(y)}
+					if val, hit, _ := c.Get(&key); hit && len(val) > 0 {
+						seeded = append(seeded, atlas.Tile{Z: z, X: x, Y: y})
+					}
+				}
+			}
+		}
+
+		if !reflect.DeepEqual(seeded, tc.expected) {
+			t.Errorf("seeded tiles, expected %v got %v", tc.expected, seeded)
+		}
+	}
+
+	tests := map[string]tcase{
+		"three tiles": {
+			tiles:       []atlas.TileCoord{{Z: 3, X: 5, Y: 2}, {Z: 0, X: 0, Y: 0}, {Z: 2, X: 1, Y: 3}},
+			concurrency: 2,
+			expected:    []atlas.Tile{{Z: 0, X: 0, Y: 0}, {Z: 2, X: 1, Y: 3}, {Z: 3, X: 5, Y: 2}},
+		},
+		"invalid tile": {
+			tiles:       []atlas.TileCoord{{Z: 1, X: 1, Y: 1}, {Z: 1, X: 2, Y: 0}, {Z: atlas.MaxZoom + 1}},
+			concurrency: 0,
+			expected:    []atlas.Tile{{Z: 1, X: 1, Y: 1}},
+			expectedErr: atlas.ErrSeedTiles{
+				Tiles: []atlas.TileCoord{{Z: 1, X: 2, Y: 0}, {Z: atlas.MaxZoom + 1}},
+				Errs: []error{
+					atlas.ErrInvalidTile{Tile: atlas.TileCoord{Z: 1, X: 2, Y: 0}},
+					atlas.ErrInvalidTile{Tile: atlas.TileCoord{Z: atlas.MaxZoom + 1}},
+				},
+			},
+		},
+		"tiles for bounds": {
+			tiles:       tilesForBounds(t, &geom.BoundingBox{{0.1, 0.1}, {10, 10}}, 2, 3),
+			concurrency: 2,
+			expected:    []atlas.Tile{{Z: 2, X: 2, Y: 1}, {Z: 3, X: 4, Y: 3}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
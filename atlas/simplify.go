@@ -0,0 +1,19 @@
+package atlas
+
+//	SimplifyOverride replaces the simplification of a Map's layers for a single render, i.e. for print
+//	quality exports which need more detail than the layers are simplified to
+type SimplifyOverride struct {
+	//	DontSimplify turns the simplification of every layer off
+	DontSimplify bool
+	//	Tolerance is the tolerance, in tile pixels, the geometries are simplified with, in place of the
+	//	tolerance of the tile's zoom. 0 keeps the tolerance of the tile's zoom
+	Tolerance float64
+}
+
+//	OverrideSimplify returns a copy of a Map whose layers are all simplified as the override sets,
+//	superseding their DontSimplify. the Map the copy was made from is unchanged
+func (m Map) OverrideSimplify(o SimplifyOverride) Map {
+	m.simplify = &o
+
+	return m
+}
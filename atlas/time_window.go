@@ -0,0 +1,113 @@
+package atlas
+
+import (
+	"strings"
+	"time"
+)
+
+//	TimeWindow is the range of time the features of layers with a TimeAttribute are filtered to.
+//	a zero Start or End leaves the window open on that side
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+//	Contains reports whether t falls within the window. both the Start and End are included
+func (w TimeWindow) Contains(t time.Time) bool {
+	if !w.Start.IsZero() && t.Before(w.Start) {
+		return false
+	}
+	if !w.End.IsZero() && t.After(w.End) {
+		return false
+	}
+
+	return true
+}
+
+//	ParseTimeWindow parses a time window in the form "start/end" where start and end are RFC 3339
+//	timestamps. either may be empty to leave the window open on that side. a single timestamp is
+//	a window containing only that instant
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	var w TimeWindow
+
+	parts := strings.Split(s, "/")
+	if len(parts) > 2 || s == "" {
+		return w, ErrInvalidTimeWindow{Value: s}
+	}
+
+	var err error
+	if parts[0] != "" {
+		if w.Start, err = time.Parse(time.RFC3339, parts[0]); err != nil {
+			return w, ErrInvalidTimeWindow{Value: s}
+		}
+	}
+
+	if len(parts) == 1 {
+		w.End = w.Start
+		return w, nil
+	}
+
+	if parts[1] != "" {
+		if w.End, err = time.Parse(time.RFC3339, parts[1]); err != nil {
+			return w, ErrInvalidTimeWindow{Value: s}
+		}
+	}
+
+	if !w.Start.IsZero() && !w.End.IsZero() && w.End.Before(w.Start) {
+		return w, ErrInvalidTimeWindow{Value: s}
+	}
+
+	return w, nil
+}
+
+//	FilterFeaturesByTime returns a copy of a Map which only encodes the features of layers with a
+//	TimeAttribute whose timestamp falls within the window. the features of other layers are not filtered
+func (m Map) FilterFeaturesByTime(w TimeWindow) Map {
+	m.timeWindow = &w
+
+	return m
+}
+
+//	inTimeWindow reports whether a feature with the given tags is encoded in the layer given the
+//	time window, nil for no window. features without a timestamp are filtered out of the window
+func (l *Layer) inTimeWindow(tags map[string]interface{}, w *TimeWindow) bool {
+	if l.TimeAttribute == "" || w == nil {
+		return true
+	}
+
+	t, ok := featureTime(tags[l.TimeAttribute])
+	if !ok {
+		return false
+	}
+
+	return w.Contains(t)
+}
+
+//	featureTime converts the value of a feature's time attribute to a time. RFC 3339 strings and
+//	numbers of seconds since the Unix epoch are supported
+func featureTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return parsed, true
+	case int:
+		return time.Unix(int64(t), 0), true
+	case int32:
+		return time.Unix(int64(t), 0), true
+	case int64:
+		return time.Unix(t, 0), true
+	case uint32:
+		return time.Unix(int64(t), 0), true
+	case uint64:
+		return time.Unix(int64(t), 0), true
+	case float64:
+		return time.Unix(int64(t), 0), true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,257 @@
+package atlas_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	jan := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	type tcase struct {
+		value       string
+		expected    atlas.TimeWindow
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		w, err := atlas.ParseTimeWindow(tc.value)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if err != nil {
+			return
+		}
+
+		if !w.Start.Equal(tc.expected.Start) || !w.End.Equal(tc.expected.End) {
+			t.Errorf("window, expected %v got %v", tc.expected, w)
+		}
+	}
+
+	tests := map[string]tcase{
+		"range": {
+			value:    "2018-01-01T00:00:00Z/2018-02-01T00:00:00Z",
+			expected: atlas.TimeWindow{Start: jan, End: feb},
+		},
+		"open start": {
+			value:    "/2018-02-01T00:00:00Z",
+			expected: atlas.TimeWindow{End: feb},
+		},
+		"open end": {
+			value:    "2018-01-01T00:00:00Z/",
+			expected: atlas.TimeWindow{Start: jan},
+		},
+		"instant": {
+			value:    "2018-01-01T00:00:00Z",
+			expected: atlas.TimeWindow{Start: jan, End: jan},
+		},
+		"end before start": {
+			value:       "2018-02-01T00:00:00Z/2018-01-01T00:00:00Z",
+			expectedErr: atlas.ErrInvalidTimeWindow{Value: "2018-02-01T00:00:00Z/2018-01-01T00:00:00Z"},
+		},
+		"not a timestamp": {
+			value:       "yesterday",
+			expectedErr: atlas.ErrInvalidTimeWindow{Value: "yesterday"},
+		},
+		"too many parts": {
+			value:       "2018-01-01T00:00:00Z/2018-02-01T00:00:00Z/2018-03-01T00:00:00Z",
+			expectedErr: atlas.ErrInvalidTimeWindow{Value: "2018-01-01T00:00:00Z/2018-02-01T00:00:00Z/2018-03-01T00:00:00Z"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestMapFilterFeaturesByTime(t *testing.T) {
+	observation := func(id uint64, observedAt interface{}) provider.Feature {
+		f := provider.Feature{
+			ID:       id,
+			Geometry: geom.Point{float64(id) * 1000, 0},
+			SRID:     tegola.WebMercator,
+			Tags:     map[string]interface{}{},
+		}
+		if observedAt != nil {
+			f.Tags["observed_at"] = observedAt
+		}
+		return f
+	}
+
+	features := []provider.Feature{
+		observation(1, "2017-12-31T23:59:59Z"),
+		observation(2, "2018-01-01T00:00:00Z"),
+		observation(3, "2018-01-15T12:00:00Z"),
+		// 2018-01-20T00:00:00Z in seconds since the epoch
+		observation(4, int64(1516406400)),
+		observation(5, "2018-02-01T00:00:00Z"),
+		observation(6, "2018-02-01T00:00:01Z"),
+		// without a timestamp
+		observation(7, nil),
+		observation(8, "not a time"),
+	}
+
+	type tcase struct {
+		timeAttribute string
+		window        *atlas.TimeWindow
+		expected      []uint64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		m := atlas.NewWebMercatorMap("observations")
+		m.Layers = []atlas.Layer{
+			{
+				Name:          "observations",
+				Provider:      featuresProvider{features: features},
+				TimeAttribute: tc.timeAttribute,
+			},
+		}
+		if tc.window != nil {
+			m = m.FilterFeaturesByTime(*tc.window)
+		}
+
+		out, err := m.Encode(context.Background(), slippy.NewTile(0, 0, 0, 64, tegola.WebMercator))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(out, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var ids []uint64
+		for _, l := range vt.Layers {
+			for _, f := range l.Features {
+				ids = append(ids, f.GetId())
+			}
+		}
+
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("feature ids, expected %v got %v", tc.expected, ids)
+		}
+	}
+
+	january := atlas.TimeWindow{
+		Start: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := map[string]tcase{
+		"no window": {
+			timeAttribute: "observed_at",
+			expected:      []uint64{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		"january": {
+			timeAttribute: "observed_at",
+			window:        &january,
+			expected:      []uint64{2, 3, 4, 5},
+		},
+		"open start": {
+			timeAttribute: "observed_at",
+			window:        &atlas.TimeWindow{End: january.Start},
+			expected:      []uint64{1, 2},
+		},
+		"layer without a time attribute": {
+			window:   &january,
+			expected: []uint64{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestRenderTileTimeWindow(t *testing.T) {
+	observation := func(id uint64, observedAt string) provider.Feature {
+		return provider.Feature{
+			ID:       id,
+			Geometry: geom.Point{float64(id) * 1000, 0},
+			SRID:     tegola.WebMercator,
+			Tags:     map[string]interface{}{"observed_at": observedAt},
+		}
+	}
+
+	a := &atlas.Atlas{}
+	a.SetCache(memory.New())
+
+	m := atlas.NewWebMercatorMap("observations")
+	m.Layers = []atlas.Layer{
+		{
+			Name: "observations",
+			Provider: featuresProvider{features: []provider.Feature{
+				observation(1, "2017-12-31T23:59:59Z"),
+				observation(2, "2018-01-15T12:00:00Z"),
+			}},
+			TimeAttribute: "observed_at",
+		},
+	}
+	filtered := m.FilterFeaturesByTime(atlas.TimeWindow{
+		Start: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	ctx := context.Background()
+
+	// ids renders the tile and returns the ids of its features
+	ids := func(t *testing.T, m atlas.Map, fromCache bool) []uint64 {
+		b, info, err := a.RenderTileWithInfo(ctx, m, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("err rendering tile: %v", err)
+		}
+		if info.FromCache != fromCache {
+			t.Errorf("from cache, expected %v got %v", fromCache, info.FromCache)
+		}
+
+		var vt vectorTile.Tile
+		if err = proto.Unmarshal(b, &vt); err != nil {
+			t.Fatalf("err unmarshalling tile: %v", err)
+		}
+
+		var ids []uint64
+		for _, l := range vt.Layers {
+			for _, f := range l.Features {
+				ids = append(ids, f.GetId())
+			}
+		}
+		return ids
+	}
+
+	// seeding the filtered map doesn't cache the filtered tile
+	if _, err := a.SeedMapTileWithResult(ctx, filtered, 0, 0, 0); err != nil {
+		t.Fatalf("err seeding filtered tile: %v", err)
+	}
+	if got := ids(t, m, false); !reflect.DeepEqual(got, []uint64{1, 2}) {
+		t.Errorf("unfiltered ids, expected [1 2] got %v", got)
+	}
+
+	// the filtered map is encoded rather than read from the cached unfiltered tile
+	if err := a.SeedMapTile(ctx, m, 0, 0, 0); err != nil {
+		t.Fatalf("err seeding tile: %v", err)
+	}
+	if got := ids(t, filtered, false); !reflect.DeepEqual(got, []uint64{2}) {
+		t.Errorf("filtered ids, expected [2] got %v", got)
+	}
+	if got := ids(t, m, true); !reflect.DeepEqual(got, []uint64{1, 2}) {
+		t.Errorf("cached ids, expected [1 2] got %v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package atlas
+
+import (
+	"sync"
+
+	"github.com/go-spatial/tegola/basic"
+)
+
+//	transformCache memoizes the transforms of coordinates to the map's SRID by the SRID they are
+//	transformed from, so a transform is looked up once for the features of a tile rather than for
+//	each feature. a nil cache looks up the transform on every call
+type transformCache struct {
+	sync.Mutex
+	transforms map[uint64]func(coords ...float64) ([]float64, error)
+}
+
+//	transform returns the transform of coordinates in the srid to WebMercator
+func (c *transformCache) transform(srid uint64) (func(coords ...float64) ([]float64, error), error) {
+	if c == nil {
+		return basic.ToWebMercatorTransform(srid)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if fn, ok := c.transforms[srid]; ok {
+		return fn, nil
+	}
+
+	fn, err := basic.ToWebMercatorTransform(srid)
+	if err != nil {
+		return nil, err
+	}
+	if c.transforms == nil {
+		c.transforms = make(map[uint64]func(coords ...float64) ([]float64, error))
+	}
+	c.transforms[srid] = fn
+
+	return fn, nil
+}
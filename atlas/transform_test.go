@@ -0,0 +1,64 @@
+package atlas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+)
+
+func TestTransformCache(t *testing.T) {
+	type tcase struct {
+		srid uint64
+	}
+
+	cache := &transformCache{}
+	line := basic.Line{{10, 10}, {20, 20}, {30, 10}}
+
+	fn := func(t *testing.T, tc tcase) {
+		// the cached transform transforms as the transform looked up on each call
+		for i := 0; i < 2; i++ {
+			cached, err := cache.transform(tc.srid)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			uncached, err := (*transformCache)(nil).transform(tc.srid)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			expected, err := basic.ApplyToPoints(line, uncached)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			got, err := basic.ApplyToPoints(line, cached)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("[%v] geometry, expected %v got %v", i, expected, got)
+			}
+		}
+
+		if _, ok := cache.transforms[tc.srid]; !ok {
+			t.Errorf("expected the transform of srid (%v) to be cached", tc.srid)
+		}
+	}
+
+	tests := map[string]tcase{
+		"wgs84": {
+			srid: tegola.WGS84,
+		},
+		"world mercator": {
+			srid: tegola.WorldMercator,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package basic
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"errors"
@@ -151,19 +152,50 @@ func CloneGeometry(geometry tegola.Geometry) (G, error) {
 
 // ToWebMercator takes a SRID and a geometry encode using that srid, and returns a geometry encoded as a WebMercator.
 func ToWebMercator(SRID uint64, geometry tegola.Geometry) (G, error) {
-	switch SRID {
-	default:
-		return G{}, fmt.Errorf("Don't know how to convert from %v to %v.", tegola.WebMercator, SRID)
-	case tegola.WebMercator:
+	if SRID == tegola.WebMercator {
 		// Instead of just returning the geometry, we are cloning it so that the user of the API can rely
 		// on the result to alway be a copy. Instead of being a reference in the on instance that it's already
 		// in the same SRID.
 
 		return CloneGeometry(geometry)
+	}
+
+	transform, err := ToWebMercatorTransform(SRID)
+	if err != nil {
+		return G{}, err
+	}
+	return ApplyToPoints(geometry, transform)
+}
+
+// ToWebMercatorTransform returns the function ToWebMercator applies to each point of a geometry encoded using
+// the SRID. The function can be reused, with ApplyToPoints, to transform any number of geometries encoded using
+// the SRID without looking up the transform for each geometry.
+func ToWebMercatorTransform(SRID uint64) (func(coords ...float64) ([]float64, error), error) {
+	switch SRID {
+	default:
+		return nil, fmt.Errorf("Don't know how to convert from %v to %v.", tegola.WebMercator, SRID)
+	case tegola.WebMercator:
+		return copyCoords, nil
 	case tegola.WGS84:
+		return webmercator.PToXY, nil
+	case tegola.WorldMercator:
+		return worldMercatorToWebMercator, nil
+	}
+}
+
+// copyCoords is the transform of coordinates which are already in the target SRID.
+func copyCoords(coords ...float64) ([]float64, error) {
+	return append([]float64(nil), coords...), nil
+}
 
-		return ApplyToPoints(geometry, webmercator.PToXY)
+// worldMercatorToWebMercator transforms World Mercator coordinates to WebMercator. World Mercator uses the
+// ellipsoid. go through WGS84 to get to the sphere.
+func worldMercatorToWebMercator(coords ...float64) ([]float64, error) {
+	lonlat, err := webmercator.ToLonLat(coords...)
+	if err != nil {
+		return nil, err
 	}
+	return webmercator.PToXY(lonlat...)
 }
 
 // FromWebMercator takes a geometry encoded with WebMercator, and returns a Geometry encodes to the given srid.
@@ -178,7 +210,194 @@ func FromWebMercator(SRID uint64, geometry tegola.Geometry) (G, error) {
 		return CloneGeometry(geometry)
 	case tegola.WGS84:
 		return ApplyToPoints(geometry, webmercator.PToLonLat)
+	case tegola.WorldMercator:
+		return ApplyToPoints(geometry, func(coords ...float64) ([]float64, error) {
+			lonlat, err := webmercator.PToLonLat(coords...)
+			if err != nil {
+				return nil, err
+			}
+			return webmercator.ToXY(lonlat...)
+		})
+	}
+}
+
+// SnapToGrid takes a grid size and a geometry, and returns a geometry with each coordinate rounded
+// to the nearest multiple of the grid size. Coordinates that differ by less than half the grid size
+// will snap to the same value. A grid size of 0 or less returns a clone of the geometry.
+func SnapToGrid(grid float64, geometry tegola.Geometry) (G, error) {
+	if grid <= 0 {
+		return CloneGeometry(geometry)
+	}
+	return ApplyToPoints(geometry, func(coords ...float64) ([]float64, error) {
+		snapped := make([]float64, len(coords))
+		for i := range coords {
+			snapped[i] = math.Floor(coords[i]/grid+0.5) * grid
+		}
+		return snapped, nil
+	})
+}
+
+// RepairRings repairs the rings of a polygon or multipolygon so they encode correctly. Consecutive
+// duplicate points, such as the points snapping to a grid collapses, are removed and unclosed rings
+// are closed by repeating their first point. A ring left with fewer than 4 points once closed, fewer
+// than 3 distinct points, is dropped. A polygon whose exterior ring is dropped is dropped with its
+// interior rings. The number of dropped rings is returned. Other geometry types are returned as a
+// clone. An empty G is returned if no polygons remain.
+func RepairRings(geometry tegola.Geometry) (G, int, error) {
+	switch geo := geometry.(type) {
+	case tegola.Polygon:
+		ply, dropped := repairPolygon(geo)
+		if ply == nil {
+			return G{}, dropped, nil
+		}
+		return G{ply}, dropped, nil
+	case tegola.MultiPolygon:
+		var (
+			mply    MultiPolygon
+			dropped int
+		)
+		for _, p := range geo.Polygons() {
+			ply, d := repairPolygon(p)
+			dropped += d
+			if ply != nil {
+				mply = append(mply, ply)
+			}
+		}
+		if mply == nil {
+			return G{}, dropped, nil
+		}
+		return G{mply}, dropped, nil
+	default:
+		g, err := CloneGeometry(geometry)
+		return g, 0, err
+	}
+}
+
+func repairPolygon(polygon tegola.Polygon) (ply Polygon, dropped int) {
+	for i, ln := range polygon.Sublines() {
+		l := dedupeLine(CloneLine(ln))
+		if n := len(l); n > 0 && l[0] != l[n-1] {
+			l = append(l, l[0])
+		}
+		if len(l) < 4 {
+			// without its exterior ring the interior rings have nothing to be holes of
+			if i == 0 {
+				return nil, len(polygon.Sublines())
+			}
+			dropped++
+			continue
+		}
+		ply = append(ply, l)
+	}
+	return ply, dropped
+}
+
+// dedupeLine removes the consecutive duplicate points of the line in place
+func dedupeLine(l Line) Line {
+	if len(l) == 0 {
+		return l
+	}
+
+	deduped := l[:1]
+	for _, pt := range l[1:] {
+		if pt != deduped[len(deduped)-1] {
+			deduped = append(deduped, pt)
+		}
+	}
+	return deduped
+}
+
+// Densify inserts evenly spaced vertices into the segments of line strings and polygon rings which
+// are longer than maxSegment, in the geometry's units, so no segment is longer than maxSegment.
+// Densifying before reprojecting keeps long segments on the path they take in the source projection.
+// Polygon rings are implicitly closed so the closing segment is densified as well. Other geometry
+// types, or a maxSegment <= 0, are returned as a clone.
+func Densify(maxSegment float64, geometry tegola.Geometry) (G, error) {
+	if maxSegment <= 0 {
+		return CloneGeometry(geometry)
+	}
+
+	switch geo := geometry.(type) {
+	case tegola.LineString:
+		return G{densifyLine(CloneLine(geo), maxSegment, false)}, nil
+	case tegola.MultiLine:
+		var ml MultiLine
+		for _, l := range geo.Lines() {
+			ml = append(ml, densifyLine(CloneLine(l), maxSegment, false))
+		}
+		return G{ml}, nil
+	case tegola.Polygon:
+		return G{densifyPolygon(geo, maxSegment)}, nil
+	case tegola.MultiPolygon:
+		var mply MultiPolygon
+		for _, p := range geo.Polygons() {
+			mply = append(mply, densifyPolygon(p, maxSegment))
+		}
+		return G{mply}, nil
+	default:
+		return CloneGeometry(geometry)
+	}
+}
+
+func densifyPolygon(polygon tegola.Polygon, maxSegment float64) (ply Polygon) {
+	for _, ln := range polygon.Sublines() {
+		ply = append(ply, densifyLine(CloneLine(ln), maxSegment, true))
+	}
+	return ply
+}
+
+// densifyLine splits the segments of the line longer than maxSegment. when closed the segment from
+// the last point back to the first is split as well
+func densifyLine(line Line, maxSegment float64, closed bool) (l Line) {
+	if len(line) < 2 {
+		return line
+	}
+
+	n := len(line) - 1
+	if closed {
+		n++
+	}
+
+	for i := 0; i < n; i++ {
+		start, end := line[i], line[(i+1)%len(line)]
+		l = append(l, start)
+
+		dx, dy := end[0]-start[0], end[1]-start[1]
+		parts := math.Ceil(math.Hypot(dx, dy) / maxSegment)
+		for j := 1.0; j < parts; j++ {
+			l = append(l, Point{start[0] + dx*j/parts, start[1] + dy*j/parts})
+		}
+	}
+
+	if !closed {
+		l = append(l, line[len(line)-1])
+	}
+
+	return l
+}
+
+// ReverseLines reverses the vertex order of line strings and the line strings of multi line strings.
+// Other geometry types are returned as a clone.
+func ReverseLines(geometry tegola.Geometry) (G, error) {
+	switch geo := geometry.(type) {
+	case tegola.LineString:
+		return G{reverseLine(CloneLine(geo))}, nil
+	case tegola.MultiLine:
+		var ml MultiLine
+		for _, l := range geo.Lines() {
+			ml = append(ml, reverseLine(CloneLine(l)))
+		}
+		return G{ml}, nil
+	default:
+		return CloneGeometry(geometry)
+	}
+}
+
+func reverseLine(line Line) Line {
+	for i, j := 0, len(line)-1; i < j; i, j = i+1, j-1 {
+		line[i], line[j] = line[j], line[i]
 	}
+	return line
 }
 
 func interfaceAsFloatslice(v interface{}) (vals []float64, err error) {
@@ -0,0 +1,386 @@
+package basic_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/maths/webmercator"
+)
+
+func TestSnapToGrid(t *testing.T) {
+	type tcase struct {
+		grid     float64
+		geometry tegola.Geometry
+		expected tegola.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, err := basic.SnapToGrid(tc.grid, tc.geometry)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g.Geometry, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g.Geometry)
+		}
+	}
+
+	tests := map[string]tcase{
+		"coordinates below the grid size snap together": {
+			grid:     0.5,
+			geometry: basic.Line{{10.0000001, 20.2}, {9.9999999, 20.3}},
+			expected: basic.Line{{10, 20}, {10, 20.5}},
+		},
+		"coordinates above the grid size stay apart": {
+			grid:     0.5,
+			geometry: basic.MultiPoint{{10.1, 4}, {10.4, 4}},
+			expected: basic.MultiPoint{{10, 4}, {10.5, 4}},
+		},
+		"no grid": {
+			geometry: basic.Point{1.25, 2.75},
+			expected: basic.Point{1.25, 2.75},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestDensify(t *testing.T) {
+	type tcase struct {
+		maxSegment float64
+		geometry   tegola.Geometry
+		expected   tegola.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, err := basic.Densify(tc.maxSegment, tc.geometry)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g.Geometry, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g.Geometry)
+		}
+	}
+
+	tests := map[string]tcase{
+		"line": {
+			maxSegment: 4,
+			geometry:   basic.Line{{0, 0}, {10, 0}, {10, 2}},
+			expected:   basic.Line{{0, 0}, {10.0 / 3, 0}, {20.0 / 3, 0}, {10, 0}, {10, 2}},
+		},
+		"polygon closing segment": {
+			maxSegment: 6,
+			geometry:   basic.Polygon{{{0, 0}, {5, 0}, {5, 10}}},
+			expected:   basic.Polygon{{{0, 0}, {5, 0}, {5, 5}, {5, 10}, {2.5, 5}}},
+		},
+		"point": {
+			maxSegment: 1,
+			geometry:   basic.Point{1, 2},
+			expected:   basic.Point{1, 2},
+		},
+		"disabled": {
+			geometry: basic.Line{{0, 0}, {10, 0}},
+			expected: basic.Line{{0, 0}, {10, 0}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestReverseLines(t *testing.T) {
+	type tcase struct {
+		geometry tegola.Geometry
+		expected tegola.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, err := basic.ReverseLines(tc.geometry)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g.Geometry, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g.Geometry)
+		}
+	}
+
+	tests := map[string]tcase{
+		"line": {
+			geometry: basic.Line{{0, 0}, {10, 0}, {10, 2}},
+			expected: basic.Line{{10, 2}, {10, 0}, {0, 0}},
+		},
+		"multi line": {
+			geometry: basic.MultiLine{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}, {4, 4}}},
+			expected: basic.MultiLine{{{1, 1}, {0, 0}}, {{4, 4}, {3, 3}, {2, 2}}},
+		},
+		"polygon": {
+			geometry: basic.Polygon{{{0, 0}, {5, 0}, {5, 10}}},
+			expected: basic.Polygon{{{0, 0}, {5, 0}, {5, 10}}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestDensifyToWebMercator(t *testing.T) {
+	type tcase struct {
+		start, end [2]float64
+		// the expected number of vertices after densifying
+		expectedLen int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		dense, err := basic.Densify(10, basic.Line{tc.start, tc.end})
+		if err != nil {
+			t.Fatalf("unexpected err densifying: %v", err)
+		}
+		g, err := basic.ToWebMercator(tegola.WGS84, dense.Geometry)
+		if err != nil {
+			t.Fatalf("unexpected err reprojecting: %v", err)
+		}
+
+		line, ok := g.Geometry.(basic.Line)
+		if !ok {
+			t.Fatalf("geometry, expected basic.Line got %T", g.Geometry)
+		}
+		if len(line) != tc.expectedLen {
+			t.Fatalf("vertices, expected %v got %v", tc.expectedLen, len(line))
+		}
+
+		// the vertices are evenly spaced along the line in lon / lat, which is the path the line
+		// takes once projected
+		parts := float64(tc.expectedLen - 1)
+		for i, pt := range line {
+			lon := tc.start[0] + (tc.end[0]-tc.start[0])*float64(i)/parts
+			lat := tc.start[1] + (tc.end[1]-tc.start[1])*float64(i)/parts
+
+			expected, err := webmercator.PToXY(lon, lat)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if math.Abs(pt[0]-expected[0]) > 1e-6 || math.Abs(pt[1]-expected[1]) > 1e-6 {
+				t.Errorf("[%v] vertex, expected %v got %v", i, expected, pt)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		// a parallel is a straight line of constant y in mercator
+		"east-west": {
+			start:       [2]float64{-60, 45},
+			end:         [2]float64{60, 45},
+			expectedLen: 13,
+		},
+		// latitudes are stretched away from the equator so the line curves in mercator
+		"diagonal": {
+			start:       [2]float64{0, 0},
+			end:         [2]float64{40, 60},
+			expectedLen: 9,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestRepairRings(t *testing.T) {
+	type tcase struct {
+		geometry        tegola.Geometry
+		expected        tegola.Geometry
+		expectedDropped int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, dropped, err := basic.RepairRings(tc.geometry)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g.Geometry, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g.Geometry)
+		}
+		if dropped != tc.expectedDropped {
+			t.Errorf("dropped, expected %v got %v", tc.expectedDropped, dropped)
+		}
+	}
+
+	square := basic.Line{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	closedSquare := basic.Line{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+
+	// a ring of 4 points snapped to a 10 unit grid, it collapses to a line
+	snapped, err := basic.SnapToGrid(10, basic.Polygon{{{0, 0}, {2, 1}, {9, 1}, {11, 2}}})
+	if err != nil {
+		t.Fatalf("err snapping ring: %v", err)
+	}
+
+	tests := map[string]tcase{
+		"unclosed ring": {
+			geometry: basic.Polygon{square},
+			expected: basic.Polygon{closedSquare},
+		},
+		"explicitly closed ring": {
+			geometry: basic.Polygon{closedSquare},
+			expected: basic.Polygon{closedSquare},
+		},
+		"duplicate points": {
+			geometry: basic.Polygon{{{0, 0}, {0, 0}, {10, 0}, {10, 10}, {10, 10}, {0, 10}}},
+			expected: basic.Polygon{closedSquare},
+		},
+		"degenerate interior ring": {
+			geometry:        basic.Polygon{square, {{2, 2}, {3, 3}, {2, 2}}},
+			expected:        basic.Polygon{closedSquare},
+			expectedDropped: 1,
+		},
+		"snapped degenerate interior ring": {
+			geometry:        basic.Polygon{square, snapped.Geometry.(basic.Polygon)[0]},
+			expected:        basic.Polygon{closedSquare},
+			expectedDropped: 1,
+		},
+		"degenerate exterior ring": {
+			geometry:        basic.MultiPolygon{{{{0, 0}, {1, 1}}, {{2, 2}, {3, 2}, {3, 3}}}, {square}},
+			expected:        basic.MultiPolygon{{closedSquare}},
+			expectedDropped: 2,
+		},
+		"nothing left": {
+			geometry:        basic.Polygon{{{0, 0}, {1, 1}, {0, 0}}},
+			expected:        nil,
+			expectedDropped: 1,
+		},
+		"not a polygon": {
+			geometry: basic.Line{{0, 0}, {1, 1}},
+			expected: basic.Line{{0, 0}, {1, 1}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestToWebMercatorTransform(t *testing.T) {
+	type tcase struct {
+		srid uint64
+		geom tegola.Geometry
+		// the transform is not found
+		expectedErr bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		transform, err := basic.ToWebMercatorTransform(tc.srid)
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("error, expected an error got nil")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		expected, err := basic.ToWebMercator(tc.srid, tc.geom)
+		if err != nil {
+			t.Fatalf("unexpected err reprojecting: %v", err)
+		}
+
+		// the transform is reused for each geometry
+		for i := 0; i < 2; i++ {
+			got, err := basic.ApplyToPoints(tc.geom, transform)
+			if err != nil {
+				t.Fatalf("unexpected err transforming: %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("[%v] geometry, expected %v got %v", i, expected, got)
+			}
+		}
+	}
+
+	poly := basic.Polygon{
+		basic.Line{{10, 10}, {20, 10}, {20, 20}, {10, 20}},
+	}
+
+	tests := map[string]tcase{
+		"wgs84": {
+			srid: tegola.WGS84,
+			geom: poly,
+		},
+		"world mercator": {
+			srid: tegola.WorldMercator,
+			geom: basic.Line{{1000000, 2000000}, {3000000, 4000000}},
+		},
+		"web mercator": {
+			srid: tegola.WebMercator,
+			geom: basic.Point{1000000, 2000000},
+		},
+		"unsupported": {
+			srid:        1234,
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// benchmarkFeatures are the geometries of the features of a tile, a point for each feature
+var benchmarkFeatures = func() []basic.Point {
+	pts := make([]basic.Point, 1000)
+	for i := range pts {
+		pts[i] = basic.Point{float64(i%360) - 180, float64(i%170) - 85}
+	}
+	return pts
+}()
+
+func BenchmarkToWebMercator(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		for _, pt := range benchmarkFeatures {
+			if _, err := basic.ToWebMercator(tegola.WorldMercator, pt); err != nil {
+				b.Fatalf("unexpected err: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkToWebMercatorTransform(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		// the transform is looked up once for the features
+		transform, err := basic.ToWebMercatorTransform(tegola.WorldMercator)
+		if err != nil {
+			b.Fatalf("unexpected err: %v", err)
+		}
+		for _, pt := range benchmarkFeatures {
+			if _, err := basic.ApplyToPoints(pt, transform); err != nil {
+				b.Fatalf("unexpected err: %v", err)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 //	Interface defines a cache back end
@@ -16,6 +17,19 @@ type Interface interface {
 	Purge(key *Key) error
 }
 
+//	MapPurger is implemented by cache backends which can enumerate their keys by map name
+type MapPurger interface {
+	//	PurgeMap removes every key for the named map
+	PurgeMap(mapName string) error
+}
+
+//	ModTimer is implemented by cache backends which record when each value was last set, allowing
+//	the age of a cached value to be checked
+type ModTimer interface {
+	//	GetWithModTime behaves like Get and additionally returns the time the value was last set
+	GetWithModTime(key *Key) (val []byte, modTime time.Time, hit bool, err error)
+}
+
 //	ParseKey will parse a string in the format /:map/:layer/:z/:x/:y into a Key struct. The :layer value is optional
 //	ParseKey also supports other OS delimeters (i.e. Windows - "\")
 func ParseKey(str string) (*Key, error) {
@@ -96,7 +110,10 @@ func ParseKey(str string) (*Key, error) {
 }
 
 type Key struct {
-	MapName   string
+	MapName string
+	//	optional. the namespace of the map's tiles, i.e. the hash of the map's config. the tiles of
+	//	each namespace are cached separately under the map
+	Namespace string
 	LayerName string
 	Z         int
 	X         int
@@ -104,7 +121,7 @@ type Key struct {
 }
 
 func (k Key) String() string {
-	return filepath.Join(k.MapName, k.LayerName, strconv.Itoa(k.Z), strconv.Itoa(k.X), strconv.Itoa(k.Y))
+	return filepath.Join(k.MapName, k.Namespace, k.LayerName, strconv.Itoa(k.Z), strconv.Itoa(k.X), strconv.Itoa(k.Y))
 }
 
 // InitFunc initilize a cache given a config map.
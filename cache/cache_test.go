@@ -54,3 +54,39 @@ func TestParseKey(t *testing.T) {
 		}
 	}
 }
+
+func TestKeyString(t *testing.T) {
+	type tcase struct {
+		key      cache.Key
+		expected string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		if got := tc.key.String(); got != tc.expected {
+			t.Errorf("key, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"map": {
+			key:      cache.Key{MapName: "osm", Z: 12, X: 11, Y: 123},
+			expected: "osm/12/11/123",
+		},
+		"layer": {
+			key:      cache.Key{MapName: "osm", LayerName: "buildings", Z: 12, X: 11, Y: 123},
+			expected: "osm/buildings/12/11/123",
+		},
+		// the tiles of the namespace are cached under the map
+		"namespace": {
+			key:      cache.Key{MapName: "osm", Namespace: "0123456789abcdef", Z: 12, X: 11, Y: 123},
+			expected: "osm/0123456789abcdef/12/11/123",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/util/dict"
@@ -12,6 +14,8 @@ import (
 
 var (
 	ErrMissingBasepath = errors.New("filecache: missing required param 'basepath'")
+	ErrMissingMapName  = errors.New("filecache: missing map name")
+	ErrInvalidMapName  = errors.New("filecache: invalid map name")
 )
 
 const CacheType = "file"
@@ -79,23 +83,36 @@ type Cache struct {
 //	if there is a hit. the second argument denotes a hit or miss
 //	so the consumer does not need to sniff errors for cache read misses
 func (fc *Cache) Get(key *cache.Key) ([]byte, bool, error) {
+	val, _, hit, err := fc.GetWithModTime(key)
+	return val, hit, err
+}
+
+//	GetWithModTime reads a z,x,y entry from the cache along with the modification time of
+//	the entry's file, implementing cache.ModTimer
+func (fc *Cache) GetWithModTime(key *cache.Key) ([]byte, time.Time, bool, error) {
 	path := filepath.Join(fc.Basepath, key.String())
 
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, false, nil
+			return nil, time.Time{}, false, nil
 		}
 
-		return nil, false, err
+		return nil, time.Time{}, false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, false, err
 	}
 
 	val, err := ioutil.ReadAll(f)
 	if err != nil {
-		return nil, false, err
+		return nil, time.Time{}, false, err
 	}
 
-	return val, true, nil
+	return val, info.ModTime(), true, nil
 }
 
 func (fc *Cache) Set(key *cache.Key, val []byte) error {
@@ -106,12 +123,12 @@ func (fc *Cache) Set(key *cache.Key, val []byte) error {
 		return nil
 	}
 
-	//	the tmpPath uses the destPath with a simple "-tmp" suffix. we're going to do
-	//	a Rename at the end of this method and according to the os.Rename() docs:
-	//	"If newpath already exists and is not a directory, Rename replaces it.
+	//	the tile is written to a temp file which is renamed to the destPath once complete. according
+	//	to the os.Rename() docs: "If newpath already exists and is not a directory, Rename replaces it.
 	//	OS-specific restrictions may apply when oldpath and newpath are in different directories"
+	//	so the temp file is created in the same directory as the destPath. each write gets a uniquely
+	//	named temp file so concurrent writes of the same key don't clobber each other.
 	destPath := filepath.Join(fc.Basepath, key.String())
-	tmpPath := destPath + "-tmp"
 
 	//	the key can have a directory syntax so we need to makeAll
 	if err = os.MkdirAll(filepath.Dir(destPath), os.ModePerm); err != nil {
@@ -119,20 +136,29 @@ func (fc *Cache) Set(key *cache.Key, val []byte) error {
 	}
 
 	//	create the file
-	f, err := os.Create(tmpPath)
+	f, err := ioutil.TempFile(filepath.Dir(destPath), filepath.Base(destPath)+"-tmp")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	tmpPath := f.Name()
 
 	//	copy the contents
 	_, err = f.Write(val)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 
 	//	move the temp file to the destination
-	return os.Rename(tmpPath, destPath)
+	if err = os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
 }
 
 func (fc *Cache) Purge(key *cache.Key) error {
@@ -146,3 +172,17 @@ func (fc *Cache) Purge(key *cache.Key) error {
 	//	remove the locker key on purge
 	return os.Remove(path)
 }
+
+//	PurgeMap removes the map's directory, and all the tiles in it, from the cache. implements cache.MapPurger
+func (fc *Cache) PurgeMap(mapName string) error {
+	//	an empty map name would remove the basepath
+	if mapName == "" {
+		return ErrMissingMapName
+	}
+	//	the map's directory must be a directory of the basepath
+	if mapName == "." || mapName == ".." || strings.ContainsRune(mapName, '/') || strings.ContainsRune(mapName, filepath.Separator) {
+		return ErrInvalidMapName
+	}
+
+	return os.RemoveAll(filepath.Join(fc.Basepath, mapName))
+}
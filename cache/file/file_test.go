@@ -2,7 +2,11 @@ package file_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/go-spatial/tegola/cache"
@@ -123,6 +127,22 @@ func TestSetGetPurge(t *testing.T) {
 			t.Errorf("purge failed. err: %v", err)
 			return
 		}
+
+		//	the file should be removed
+		path := filepath.Join(tc.config["basepath"].(string), tc.key.String())
+		if _, err = os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("purge failed. expected file (%v) to not exist, stat err: %v", path, err)
+		}
+
+		//	test miss
+		output, hit, err = fc.Get(&tc.key)
+		if err != nil {
+			t.Errorf("read failed. err: %v", err)
+			return
+		}
+		if hit || output != nil {
+			t.Errorf("read failed. should have been a miss but cache reported a hit (%v)", output)
+		}
 	}
 
 	tests := map[string]tcase{
@@ -224,6 +244,66 @@ func TestSetOverwrite(t *testing.T) {
 	}
 }
 
+func TestConcurrentSet(t *testing.T) {
+	fc, err := file.New(map[string]interface{}{
+		"basepath": "testfiles/tegola-cache",
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	key := cache.Key{
+		MapName: "concurrent",
+		Z:       3,
+		X:       2,
+		Y:       1,
+	}
+
+	vals := [][]byte{
+		[]byte("\x66\x6f\x6f"),
+		[]byte("\x53\x69\x6c\x61\x73"),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(val []byte) {
+			defer wg.Done()
+			if err := fc.Set(&key, val); err != nil {
+				t.Errorf("write failed. err: %v", err)
+			}
+		}(vals[i%len(vals)])
+	}
+	wg.Wait()
+
+	//	the entry should be one of the complete writes
+	output, hit, err := fc.Get(&key)
+	if err != nil {
+		t.Fatalf("read failed. err: %v", err)
+	}
+	if !hit {
+		t.Fatalf("read failed. should have been a hit but cache reported a miss")
+	}
+	if !reflect.DeepEqual(output, vals[0]) && !reflect.DeepEqual(output, vals[1]) {
+		t.Errorf("expected one of %v got %v", vals, output)
+	}
+
+	//	no temp files should be left behind
+	dir := filepath.Join(fc.(*file.Cache).Basepath, filepath.Dir(key.String()))
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error reading dir (%v): %v", dir, err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected a single file in (%v) got %v", dir, len(files))
+	}
+
+	//	clean up
+	if err = fc.(cache.MapPurger).PurgeMap(key.MapName); err != nil {
+		t.Errorf("purge failed. err: %v", err)
+	}
+}
+
 func TestMaxZoom(t *testing.T) {
 	type tcase struct {
 		config      map[string]interface{}
@@ -316,3 +396,107 @@ func TestMaxZoom(t *testing.T) {
 		})
 	}
 }
+
+func TestPurgeMap(t *testing.T) {
+	type tcase struct {
+		config  map[string]interface{}
+		mapName string
+		keys    []cache.Key
+		err     error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		fc, err := file.New(tc.config)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		for i := range tc.keys {
+			if err = fc.Set(&tc.keys[i], []byte("\x53\x69\x6c\x61\x73")); err != nil {
+				t.Fatalf("write failed. err: %v", err)
+			}
+		}
+
+		err = fc.(cache.MapPurger).PurgeMap(tc.mapName)
+		if err != tc.err {
+			t.Fatalf("purge map, expected err %v got %v", tc.err, err)
+		}
+
+		for i := range tc.keys {
+			_, hit, err := fc.Get(&tc.keys[i])
+			if err != nil {
+				t.Fatalf("read failed. err: %v", err)
+			}
+			expectedHit := tc.keys[i].MapName != tc.mapName || tc.err != nil
+			if hit != expectedHit {
+				t.Errorf("key (%v) hit, expected %v got %v", tc.keys[i], expectedHit, hit)
+			}
+
+			//	clean up
+			if err = fc.Purge(&tc.keys[i]); err != nil {
+				t.Errorf("purge failed. err: %v", err)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"purge map": {
+			config: map[string]interface{}{
+				"basepath": "testfiles/tegola-cache",
+			},
+			mapName: "purge-map",
+			keys: []cache.Key{
+				{MapName: "purge-map", Z: 0, X: 0, Y: 0},
+				{MapName: "purge-map", Z: 1, X: 1, Y: 0},
+				{MapName: "purge-map", LayerName: "layer", Z: 1, X: 1, Y: 1},
+				{MapName: "purge-map-keep", Z: 1, X: 1, Y: 1},
+			},
+		},
+		"missing map name": {
+			config: map[string]interface{}{
+				"basepath": "testfiles/tegola-cache",
+			},
+			keys: []cache.Key{
+				{MapName: "purge-map-keep", Z: 1, X: 1, Y: 1},
+			},
+			err: file.ErrMissingMapName,
+		},
+		"basepath": {
+			config: map[string]interface{}{
+				"basepath": "testfiles/tegola-cache",
+			},
+			mapName: ".",
+			keys: []cache.Key{
+				{MapName: "purge-map-keep", Z: 1, X: 1, Y: 1},
+			},
+			err: file.ErrInvalidMapName,
+		},
+		"parent directory": {
+			config: map[string]interface{}{
+				"basepath": "testfiles/tegola-cache",
+			},
+			mapName: "..",
+			keys: []cache.Key{
+				{MapName: "purge-map-keep", Z: 1, X: 1, Y: 1},
+			},
+			err: file.ErrInvalidMapName,
+		},
+		"path separator": {
+			config: map[string]interface{}{
+				"basepath": "testfiles/tegola-cache",
+			},
+			mapName: "../purge-map-keep",
+			keys: []cache.Key{
+				{MapName: "purge-map-keep", Z: 1, X: 1, Y: 1},
+			},
+			err: file.ErrInvalidMapName,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
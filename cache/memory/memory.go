@@ -1,40 +1,58 @@
 package memory
 
 import (
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-spatial/tegola/cache"
 )
 
 func New() *MemoryCache {
 	return &MemoryCache{
-		keyVals: map[string][]byte{},
+		keyVals: map[string]entry{},
 	}
 }
 
+//	entry is a cached value and the time it was set
+type entry struct {
+	val     []byte
+	modTime time.Time
+}
+
 //	test cacher, implements the cache.Interface
 type MemoryCache struct {
-	keyVals map[string][]byte
+	keyVals map[string]entry
 	sync.RWMutex
 }
 
 func (mc *MemoryCache) Get(key *cache.Key) ([]byte, bool, error) {
+	val, _, hit, err := mc.GetWithModTime(key)
+	return val, hit, err
+}
+
+//	GetWithModTime returns the value along with the time it was set, implementing cache.ModTimer
+func (mc *MemoryCache) GetWithModTime(key *cache.Key) ([]byte, time.Time, bool, error) {
 	mc.RLock()
 	defer mc.RUnlock()
 
-	val, ok := mc.keyVals[key.String()]
+	e, ok := mc.keyVals[key.String()]
 	if !ok {
-		return nil, false, nil
+		return nil, time.Time{}, false, nil
 	}
 
-	return val, true, nil
+	return e.val, e.modTime, true, nil
 }
 
 func (mc *MemoryCache) Set(key *cache.Key, val []byte) error {
 	mc.Lock()
 	defer mc.Unlock()
 
-	mc.keyVals[key.String()] = val
+	mc.keyVals[key.String()] = entry{
+		val:     val,
+		modTime: time.Now(),
+	}
 
 	return nil
 }
@@ -47,3 +65,18 @@ func (mc *MemoryCache) Purge(key *cache.Key) error {
 
 	return nil
 }
+
+//	PurgeMap removes all the keys under the map's namespace, implementing cache.MapPurger
+func (mc *MemoryCache) PurgeMap(mapName string) error {
+	mc.Lock()
+	defer mc.Unlock()
+
+	prefix := mapName + string(filepath.Separator)
+	for k := range mc.keyVals {
+		if strings.HasPrefix(k, prefix) {
+			delete(mc.keyVals, k)
+		}
+	}
+
+	return nil
+}
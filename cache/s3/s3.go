@@ -2,6 +2,7 @@ package s3
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"io"
 	"os"
@@ -32,12 +33,23 @@ const (
 	ConfigKeyRegion         = "region" //	defaults to "us-east-1"
 	ConfigKeyAWSAccessKeyID = "aws_access_key_id"
 	ConfigKeyAWSSecretKey   = "aws_secret_access_key"
+	ConfigKeyACL            = "access_control_list" //	defaults to the bucket's policy
+	ConfigKeyContentType    = "content_type"        //	defaults to "application/vnd.mapbox-vector-tile"
+	ConfigKeyGZip           = "gzip"                //	defaults to false
 )
 
 const (
-	DefaultRegion = "us-east-1"
+	DefaultRegion      = "us-east-1"
+	DefaultContentType = "application/vnd.mapbox-vector-tile"
 )
 
+//	S3API is the subset of the s3 client used by the cache. it's satisfied by *s3.S3
+type S3API interface {
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	DeleteObject(*s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
 func init() {
 	cache.Register(CacheType, New)
 }
@@ -53,6 +65,9 @@ func init() {
 //			aws_secret_access_key (string): an AWS secret access key
 //			basepath (string): a path prefix added to all cache operations inside of the S3 bucket
 //			max_zoom (int): max zoom to use the cache. beyond this zoom cache Set() calls will be ignored
+//			access_control_list (string): the canned ACL to apply to the tiles (i.e. public-read)
+//			content_type (string): the content type of the tiles. defaults to 'application/vnd.mapbox-vector-tile'
+//			gzip (bool): gzip the tiles and set their content encoding to gzip. defaults to false
 
 func New(config map[string]interface{}) (cache.Interface, error) {
 	var err error
@@ -88,6 +103,24 @@ func New(config map[string]interface{}) (cache.Interface, error) {
 		return nil, err
 	}
 
+	acl := ""
+	s3cache.ACL, err = c.String(ConfigKeyACL, &acl)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := DefaultContentType
+	s3cache.ContentType, err = c.String(ConfigKeyContentType, &contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := false
+	s3cache.GZip, err = c.Bool(ConfigKeyGZip, &gz)
+	if err != nil {
+		return nil, err
+	}
+
 	//	check for region env var
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -185,9 +218,19 @@ type Cache struct {
 	//	should not be leveraged for higher zooms when data changes often.
 	MaxZoom *uint
 
+	//	ACL is the optional canned ACL (i.e. public-read) applied to the tiles
+	ACL string
+
+	//	ContentType is the content type set on the tiles.
+	ContentType string
+
+	//	GZip indicates the tiles are gzipped and their content encoding set to gzip
+	//	so they can be served directly from the bucket, i.e. behind a CDN
+	GZip bool
+
 	//	client holds a reference to the s3 client. it's expected the client
 	//	has an active session and read, write, delete permissions have been checked
-	Client *s3.S3
+	Client S3API
 }
 
 func (s3c *Cache) Set(key *cache.Key, val []byte) error {
@@ -201,11 +244,32 @@ func (s3c *Cache) Set(key *cache.Key, val []byte) error {
 	//	add our basepath
 	k := filepath.Join(s3c.Basepath, key.String())
 
+	if s3c.GZip {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err = w.Write(val); err != nil {
+			return err
+		}
+		if err = w.Close(); err != nil {
+			return err
+		}
+		val = buf.Bytes()
+	}
+
 	input := s3.PutObjectInput{
 		Body:   aws.ReadSeekCloser(bytes.NewReader(val)),
 		Bucket: aws.String(s3c.Bucket),
 		Key:    aws.String(k),
 	}
+	if s3c.ContentType != "" {
+		input.ContentType = aws.String(s3c.ContentType)
+	}
+	if s3c.ACL != "" {
+		input.ACL = aws.String(s3c.ACL)
+	}
+	if s3c.GZip {
+		input.ContentEncoding = aws.String("gzip")
+	}
 
 	_, err = s3c.Client.PutObject(&input)
 	if err != nil {
@@ -239,13 +303,32 @@ func (s3c *Cache) Get(key *cache.Key) ([]byte, bool, error) {
 		return nil, false, err
 	}
 
+	defer result.Body.Close()
+
 	var buf bytes.Buffer
 	_, err = io.Copy(&buf, result.Body)
 	if err != nil {
 		return nil, false, err
 	}
 
-	return buf.Bytes(), true, nil
+	//	the http client transparently decompresses gzip encoded responses in some
+	//	cases so check the body is gzipped too before decompressing it
+	b := buf.Bytes()
+	if aws.StringValue(result.ContentEncoding) == "gzip" && len(b) > 1 && b[0] == 0x1f && b[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, false, err
+		}
+		defer r.Close()
+
+		var out bytes.Buffer
+		if _, err = io.Copy(&out, r); err != nil {
+			return nil, false, err
+		}
+		b = out.Bytes()
+	}
+
+	return b, true, nil
 }
 
 func (s3c *Cache) Purge(key *cache.Key) error {
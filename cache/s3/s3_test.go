@@ -1,11 +1,18 @@
 package s3_test
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+
 	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/cache/s3"
 )
@@ -331,3 +338,178 @@ func TestMaxZoom(t *testing.T) {
 		})
 	}
 }
+
+//	mockS3 is an in memory implementation of s3.S3API
+type mockS3 struct {
+	sync.Mutex
+	objects map[string]awss3.PutObjectInput
+	bodies  map[string][]byte
+}
+
+func newMockS3() *mockS3 {
+	return &mockS3{
+		objects: map[string]awss3.PutObjectInput{},
+		bodies:  map[string][]byte{},
+	}
+}
+
+func (m *mockS3) PutObject(input *awss3.PutObjectInput) (*awss3.PutObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	body, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	k := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Key)
+	m.objects[k] = *input
+	m.bodies[k] = body
+
+	return &awss3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) GetObject(input *awss3.GetObjectInput) (*awss3.GetObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	k := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Key)
+	obj, ok := m.objects[k]
+	if !ok {
+		return nil, awserr.New(awss3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+
+	return &awss3.GetObjectOutput{
+		Body:            ioutil.NopCloser(bytes.NewReader(m.bodies[k])),
+		ContentEncoding: obj.ContentEncoding,
+		ContentType:     obj.ContentType,
+	}, nil
+}
+
+func (m *mockS3) DeleteObject(input *awss3.DeleteObjectInput) (*awss3.DeleteObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	k := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Key)
+	delete(m.objects, k)
+	delete(m.bodies, k)
+
+	return &awss3.DeleteObjectOutput{}, nil
+}
+
+func TestSetGetPurgeMock(t *testing.T) {
+	type tcase struct {
+		cache    s3.Cache
+		key      cache.Key
+		expected []byte
+		//	the expected object key in the bucket
+		expectedKey             string
+		expectedACL             *string
+		expectedContentType     *string
+		expectedContentEncoding *string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		client := newMockS3()
+		tc.cache.Client = client
+
+		//	a miss before the write
+		_, hit, err := tc.cache.Get(&tc.key)
+		if err != nil {
+			t.Fatalf("read failed. err: %v", err)
+		}
+		if hit {
+			t.Fatalf("read failed. should have been a miss but cache reported a hit")
+		}
+
+		//	test write
+		if err = tc.cache.Set(&tc.key, tc.expected); err != nil {
+			t.Fatalf("write failed. err: %v", err)
+		}
+
+		obj, ok := client.objects[tc.cache.Bucket+"/"+tc.expectedKey]
+		if !ok {
+			t.Fatalf("expected object (%v) to be written, got %v", tc.expectedKey, client.objects)
+		}
+		if !reflect.DeepEqual(obj.ACL, tc.expectedACL) {
+			t.Errorf("acl, expected %v got %v", aws.StringValue(tc.expectedACL), aws.StringValue(obj.ACL))
+		}
+		if !reflect.DeepEqual(obj.ContentType, tc.expectedContentType) {
+			t.Errorf("content type, expected %v got %v", aws.StringValue(tc.expectedContentType), aws.StringValue(obj.ContentType))
+		}
+		if !reflect.DeepEqual(obj.ContentEncoding, tc.expectedContentEncoding) {
+			t.Errorf("content encoding, expected %v got %v", aws.StringValue(tc.expectedContentEncoding), aws.StringValue(obj.ContentEncoding))
+		}
+		if body := client.bodies[tc.cache.Bucket+"/"+tc.expectedKey]; tc.cache.GZip && !bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+			t.Errorf("expected a gzipped body got %v", body)
+		}
+
+		output, hit, err := tc.cache.Get(&tc.key)
+		if err != nil {
+			t.Fatalf("read failed. err: %v", err)
+		}
+		if !hit {
+			t.Fatalf("read failed. should have been a hit but cache reported a miss")
+		}
+		if !reflect.DeepEqual(output, tc.expected) {
+			t.Errorf("expected %v got %v", tc.expected, output)
+		}
+
+		//	test purge
+		if err = tc.cache.Purge(&tc.key); err != nil {
+			t.Fatalf("purge failed. err: %v", err)
+		}
+
+		if _, hit, err = tc.cache.Get(&tc.key); err != nil || hit {
+			t.Errorf("read after purge, expected a miss got hit %v err %v", hit, err)
+		}
+	}
+
+	key := cache.Key{
+		MapName: "test-map",
+		Z:       0,
+		X:       1,
+		Y:       2,
+	}
+
+	tests := map[string]tcase{
+		"defaults": {
+			cache: s3.Cache{
+				Bucket: "tegola-test",
+			},
+			key:         key,
+			expected:    []byte{0x53, 0x69, 0x6c, 0x61, 0x73},
+			expectedKey: "test-map/0/1/2",
+		},
+		"basepath acl and content type": {
+			cache: s3.Cache{
+				Bucket:      "tegola-test",
+				Basepath:    "cache",
+				ACL:         "public-read",
+				ContentType: s3.DefaultContentType,
+			},
+			key:                 key,
+			expected:            []byte{0x53, 0x69, 0x6c, 0x61, 0x73},
+			expectedKey:         "cache/test-map/0/1/2",
+			expectedACL:         aws.String("public-read"),
+			expectedContentType: aws.String(s3.DefaultContentType),
+		},
+		"gzip": {
+			cache: s3.Cache{
+				Bucket: "tegola-test",
+				GZip:   true,
+			},
+			key:                     key,
+			expected:                []byte{0x53, 0x69, 0x6c, 0x61, 0x73},
+			expectedKey:             "test-map/0/1/2",
+			expectedContentEncoding: aws.String("gzip"),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
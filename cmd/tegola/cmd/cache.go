@@ -185,47 +185,22 @@ var cacheCmd = &cobra.Command{
 							log.Fatalf("error seeding tile (%+v): %v", mt.Tile, err)
 						}
 
-						//	filter down the layers we need for this zoom
-						m = m.FilterLayersByZoom(mt.Tile.Z)
-
-						//	check if overwriting the cache is not ok
-						if !cacheOverwrite {
-							//	lookup our cache
-							c := atlas.GetCache()
-							if c == nil {
-								log.Fatalf("error fetching cache: %v", err)
-							}
-
-							//	cache key
-							key := cache.Key{
-								MapName: mt.MapName,
-								Z:       mt.Tile.Z,
-								X:       mt.Tile.X,
-								Y:       mt.Tile.Y,
-							}
-
-							//	read the tile from the cache
-							_, hit, err := c.Get(&key)
-							if err != nil {
-								log.Fatal("error reading from cache: %v", err)
-							}
-							//	if we have a cache hit, then skip processing this tile
-							if hit {
-								log.Infof("cache seed set to not overwrite existing tiles. skipping map (%v) tile (%v/%v/%v)", mt.MapName, mt.Tile.Z, mt.Tile.X, mt.Tile.Y)
-								continue
-							}
-						}
-
 						//	set tile buffer if it was configured by the user
 						if conf.TileBuffer > 0 {
 							mt.Tile.Buffer = float64(conf.TileBuffer)
 						}
 
 						//	seed the tile
-						if err = atlas.SeedMapTile(ctx, m, uint64(mt.Tile.Z), uint64(mt.Tile.X), uint64(mt.Tile.Y)); err != nil {
+						seeded, err := seedMapTile(ctx, m, mt.Tile, cacheOverwrite)
+						if err != nil {
 							log.Errorf("error seeding tile (%+v): %v", mt.Tile, err)
 							break
 						}
+						//	the cache seed is set to not overwrite existing tiles
+						if !seeded {
+							log.Infof("cache seed set to not overwrite existing tiles. skipping map (%v) tile (%v/%v/%v)", mt.MapName, mt.Tile.Z, mt.Tile.X, mt.Tile.Y)
+							continue
+						}
 
 						//	TODO: this is a hack to get around large arrays not being garbage collected
 						//	https://github.com/golang/go/issues/14045 - should be addressed in Go 1.11
@@ -243,7 +218,7 @@ var cacheCmd = &cobra.Command{
 						}
 
 						//	purge the tile
-						if err = atlas.PurgeMapTile(m, mt.Tile); err != nil {
+						if err = purgeMapTile(m, mt.Tile); err != nil {
 							log.Errorf("error purging tile (%+v): %v", mt.Tile, err)
 							break
 						}
@@ -294,6 +269,62 @@ var cacheCmd = &cobra.Command{
 	},
 }
 
+//	schemeTile returns the coordinates of the XYZ tile addressed using the map's Scheme, as the atlas
+//	seeds and purges tiles. the conversion is its own inverse
+func schemeTile(m atlas.Map, tile *tegola.Tile) (z, x, y uint64) {
+	return m.ToXYZ(uint64(tile.Z), uint64(tile.X), uint64(tile.Y))
+}
+
+//	seedMapTile seeds the map's tile. the tiles of the cache command are XYZ tiles whatever the
+//	map's Scheme. unless overwrite is set a tile which is already cached is not seeded, seeded
+//	reports whether the tile was seeded
+func seedMapTile(ctx context.Context, m atlas.Map, tile *tegola.Tile, overwrite bool) (seeded bool, err error) {
+	//	filter down the layers we need for this zoom
+	m = m.FilterLayersByZoom(tile.Z)
+
+	//	check if overwriting the cache is not ok
+	if !overwrite {
+		//	lookup our cache
+		c := atlas.GetCache()
+		if c == nil {
+			return false, atlas.ErrMissingCache
+		}
+
+		//	the tiles are cached by their XYZ coordinates
+		key := cache.Key{
+			MapName:   m.Name,
+			Namespace: m.CacheNamespace(),
+			Z:         tile.Z,
+			X:         tile.X,
+			Y:         tile.Y,
+		}
+
+		//	read the tile from the cache
+		_, hit, err := c.Get(&key)
+		if err != nil {
+			return false, fmt.Errorf("error reading from cache: %v", err)
+		}
+		//	if we have a cache hit, then skip processing this tile
+		if hit {
+			return false, nil
+		}
+	}
+
+	z, x, y := schemeTile(m, tile)
+	if err = atlas.SeedMapTile(ctx, m, z, x, y); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+//	purgeMapTile purges the map's XYZ tile from the cache
+func purgeMapTile(m atlas.Map, tile *tegola.Tile) error {
+	z, x, y := schemeTile(m, tile)
+
+	return atlas.PurgeMapTile(m, tegola.NewTile(int(z), int(x), int(y)))
+}
+
 type MapTile struct {
 	MapName string
 	Tile    *tegola.Tile
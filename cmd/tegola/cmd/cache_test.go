@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+func TestSeedPurgeMapTile(t *testing.T) {
+	type tcase struct {
+		scheme string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		c := memory.New()
+		atlas.SetCache(c)
+		defer atlas.SetCache(nil)
+
+		m := atlas.NewWebMercatorMap("cache-cmd")
+		m.Scheme = tc.scheme
+		m.Layers = []atlas.Layer{
+			{
+				Name:     "layer1",
+				Provider: &test.TileProvider{},
+			},
+		}
+		if err := atlas.AddMap(m); err != nil {
+			t.Fatalf("err adding map: %v", err)
+		}
+		defer atlas.RemoveMap(m.Name)
+
+		m, err := atlas.GetMap(m.Name)
+		if err != nil {
+			t.Fatalf("err fetching map: %v", err)
+		}
+
+		// the XYZ tile of the command is cached at its XYZ coordinates whatever the map's scheme
+		tile := tegola.NewTile(2, 1, 0)
+		key := cache.Key{MapName: m.Name, Z: 2, X: 1, Y: 0}
+		mirrored := cache.Key{MapName: m.Name, Z: 2, X: 1, Y: 3}
+
+		cached := func(key cache.Key) bool {
+			_, hit, err := c.Get(&key)
+			if err != nil {
+				t.Fatalf("err reading from cache: %v", err)
+			}
+			return hit
+		}
+
+		seeded, err := seedMapTile(context.Background(), m, tile, false)
+		if err != nil {
+			t.Fatalf("err seeding tile: %v", err)
+		}
+		if !seeded {
+			t.Errorf("seeded, expected true got false")
+		}
+		if !cached(key) {
+			t.Errorf("tile (%v) not cached", key.String())
+		}
+		if cached(mirrored) {
+			t.Errorf("mirrored tile (%v) cached", mirrored.String())
+		}
+
+		// the cached tile is found and not overwritten
+		if seeded, err = seedMapTile(context.Background(), m, tile, false); err != nil {
+			t.Fatalf("err seeding tile: %v", err)
+		}
+		if seeded {
+			t.Errorf("seeded, expected the cached tile to be skipped")
+		}
+
+		if err = purgeMapTile(m, tile); err != nil {
+			t.Fatalf("err purging tile: %v", err)
+		}
+		if cached(key) {
+			t.Errorf("tile (%v) not purged", key.String())
+		}
+	}
+
+	tests := map[string]tcase{
+		"xyz": {scheme: atlas.TileSchemeXYZ},
+		"tms": {scheme: atlas.TileSchemeTMS},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
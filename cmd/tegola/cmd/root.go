@@ -7,6 +7,7 @@ import (
 	"log"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,10 +15,13 @@ import (
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/mvt"
 	"github.com/go-spatial/tegola/provider"
 	_ "github.com/go-spatial/tegola/provider/debug"
+	_ "github.com/go-spatial/tegola/provider/geojson"
 	_ "github.com/go-spatial/tegola/provider/gpkg"
 	_ "github.com/go-spatial/tegola/provider/postgis"
+	_ "github.com/go-spatial/tegola/provider/proxy"
 )
 
 var (
@@ -77,10 +81,15 @@ func initConfig() {
 		log.Fatal(err)
 	}
 
+	//	the feature cache and provider retries must be set before the maps are added to apply to their layers
+	atlas.SetFeatureCache(conf.FeatureCache.MaxEntries, time.Duration(conf.FeatureCache.TTL)*time.Second)
+	atlas.SetProviderRetry(conf.ProviderRetry.MaxAttempts, time.Duration(conf.ProviderRetry.Backoff)*time.Millisecond, time.Duration(conf.ProviderRetry.AttemptTimeout)*time.Second)
+
 	// init our maps
 	if err = initMaps(conf.Maps, providers); err != nil {
 		log.Fatal(err)
 	}
+	atlas.SetDefaultMap(conf.DefaultMap)
 
 	if len(conf.Cache) != 0 {
 		// init cache backends
@@ -118,6 +127,12 @@ func initMaps(maps []config.Map, providers map[string]provider.Tiler) error {
 		newMap := atlas.NewWebMercatorMap(m.Name)
 		newMap.Attribution = html.EscapeString(m.Attribution)
 		newMap.Center = m.Center
+		newMap.Scheme = m.Scheme
+		newMap.OverZoom = m.OverZoom
+		newMap.OmitEmptyTiles = m.OmitEmptyTiles
+		newMap.LayerConcurrency = m.LayerConcurrency
+		newMap.TileURLs = m.TileURLs
+		newMap.HashCacheKeys = m.HashCacheKeys
 
 		if len(m.Bounds) == 4 {
 			newMap.Bounds = [4]float64{m.Bounds[0], m.Bounds[1], m.Bounds[2], m.Bounds[3]}
@@ -169,21 +184,47 @@ func initMaps(maps []config.Map, providers map[string]provider.Tiler) error {
 				}
 			}
 
+			quantization := mvt.QuantizeTruncate
+			if l.SnapToPixelGrid {
+				quantization = mvt.QuantizeNearest
+			}
+
 			//	add our layer to our layers slice
 			newMap.Layers = append(newMap.Layers, atlas.Layer{
 				Name:              l.Name,
 				ProviderLayerName: providerLayer[1],
 				MinZoom:           l.MinZoom,
 				MaxZoom:           l.MaxZoom,
-				Provider:          provider,
+				AlwaysRender:      l.AlwaysRender,
+				ProviderName:      providerLayer[0],
 				DefaultTags:       defaultTags,
 				GeomType:          layerGeomType,
 				DontSimplify:      l.DontSimplify,
+				Quantization:      quantization,
+				Version:           l.Version,
+				SnapGrid:          l.SnapGrid,
+				Densify:           l.Densify,
+				SQLFilter:         l.SQLFilter,
+				RepairGeometry:    l.RepairGeometry,
+				Dedupe:            l.Dedupe,
+				MinArea:           l.MinArea,
+				SplitByField:      l.SplitByField,
+				SplitLayers:       l.SplitLayers,
+				TimeAttribute:     l.TimeAttribute,
+
+				FailOnInvalidCoordinates: l.FailOnInvalidCoordinates,
+				ReverseLines:             l.ReverseLines,
+				ZIndex:                   l.ZIndex,
+				OversizeRatio:            l.OversizeRatio,
+				DetectGeomType:           l.DetectGeomType,
+				FieldRename:              l.FieldRename,
 			})
 		}
 
 		//	register map
-		atlas.AddMap(newMap)
+		if err := atlas.AddMap(newMap); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -233,6 +274,9 @@ func initProviders(providers []map[string]interface{}) (map[string]provider.Tile
 
 		//	add the provider to our map of registered providers
 		registeredProviders[pname] = prov
+
+		//	register the provider with the atlas so it can be shared across map layers
+		atlas.RegisterProvider(pname, prov)
 	}
 
 	return registeredProviders, err
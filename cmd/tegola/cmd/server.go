@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	gdcmd "github.com/gdey/cmd"
 	"github.com/spf13/cobra"
 	"github.com/go-spatial/tegola/provider"
@@ -36,6 +38,12 @@ var serverCmd = &cobra.Command{
 			server.CORSAllowedOrigin = conf.Webserver.CORSAllowedOrigin
 		}
 
+		//	set the cached tile TTL
+		if conf.Webserver.TileCacheTTL > 0 {
+			server.TileCacheTTL = time.Duration(conf.Webserver.TileCacheTTL) * time.Second
+			server.TileCacheTTLJitter = float64(conf.Webserver.TileCacheTTLJitter) / 100
+		}
+
 		//	set tile buffer
 		if conf.TileBuffer > 0 {
 			server.TileBuffer = float64(conf.TileBuffer)
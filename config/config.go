@@ -23,12 +23,16 @@ import (
 type Config struct {
 	//	the tile buffer to use
 	TileBuffer int64 `toml:"tile_buffer"`
+	//	the name of the map served for requests naming a map which is not configured. optional
+	DefaultMap string `toml:"default_map"`
 	// LocationName is the file name or http server that the config was read from.
 	// If this is an empty string, it means that the location was unknown. This is the case if
 	// the Parse() function is used directly.
-	LocationName string
-	Webserver    Webserver              `toml:"webserver"`
-	Cache        map[string]interface{} `toml:"cache"`
+	LocationName  string
+	Webserver     Webserver              `toml:"webserver"`
+	Cache         map[string]interface{} `toml:"cache"`
+	FeatureCache  FeatureCache           `toml:"feature_cache"`
+	ProviderRetry ProviderRetry          `toml:"provider_retry"`
 	// Map of providers.
 	Providers []map[string]interface{}
 	Maps      []Map
@@ -38,6 +42,32 @@ type Webserver struct {
 	HostName          string `toml:"hostname"`
 	Port              string `toml:"port"`
 	CORSAllowedOrigin string `toml:"cors_allowed_origin"`
+	//	the number of seconds a cached tile is fresh for. stale tiles are served while they are
+	//	rendered again in the background. 0 disables expiry
+	TileCacheTTL int `toml:"tile_cache_ttl"`
+	//	the percent by which the TTL of each cached tile is varied, up or down, so tiles cached at the
+	//	same time, i.e. by a seed, don't expire at the same time. 0 disables the jitter
+	TileCacheTTLJitter int `toml:"tile_cache_ttl_jitter"`
+}
+
+//	FeatureCache configures the in memory cache of provider query results which layers of different
+//	maps querying the same provider layer for the same tile share
+type FeatureCache struct {
+	//	the maximum number of query results to keep. 0 disables the cache
+	MaxEntries int `toml:"max_entries"`
+	//	the number of seconds a query result is kept. 0 disables the cache
+	TTL int `toml:"ttl"`
+}
+
+//	ProviderRetry configures the retries of failed provider queries, such as queries failing on a reset
+//	database connection
+type ProviderRetry struct {
+	//	the maximum number of queries per layer and tile, including the first. 1 or less disables retries
+	MaxAttempts int `toml:"max_attempts"`
+	//	the number of milliseconds to wait before the first retry. the wait doubles with each retry
+	Backoff int `toml:"backoff"`
+	//	the number of seconds each query may take. 0 for no limit other than the request's
+	AttemptTimeout int `toml:"attempt_timeout"`
 }
 
 // A Map represents a map in the Tegola Config file.
@@ -46,7 +76,19 @@ type Map struct {
 	Attribution string     `toml:"attribution"`
 	Bounds      []float64  `toml:"bounds"`
 	Center      [3]float64 `toml:"center"`
-	Layers      []MapLayer `toml:"layers"`
+	//	Scheme is the tile addressing scheme of the map's tile URLs, "xyz" or "tms". Defaults to "xyz"
+	Scheme string `toml:"scheme"`
+	//	OverZoom renders the layers beyond their MaxZoom from their tile at MaxZoom
+	OverZoom bool `toml:"over_zoom"`
+	//	OmitEmptyTiles responds to requests for tiles without features with no content rather than an MVT without features
+	OmitEmptyTiles bool `toml:"omit_empty_tiles"`
+	//	LayerConcurrency is the maximum number of provider queries run concurrently for a tile. 0 for no limit
+	LayerConcurrency int `toml:"layer_concurrency"`
+	//	TileURLs are the base URLs the map's tiles are advertised from in its TileJSON
+	TileURLs []string `toml:"tile_urls"`
+	//	HashCacheKeys caches the map's tiles under the hash of the map's config
+	HashCacheKeys bool       `toml:"hash_cache_keys"`
+	Layers        []MapLayer `toml:"layers"`
 }
 
 type MapLayer struct {
@@ -60,6 +102,43 @@ type MapLayer struct {
 	//	DontSimplify indicates wheather feature simplification should be applied.
 	//	We use a negative in the name so the default is to simplify
 	DontSimplify bool `toml:"dont_simplify"`
+	//	AlwaysRender renders the layer at every zoom regardless of MinZoom and MaxZoom
+	AlwaysRender bool `toml:"always_render"`
+	//	SnapToPixelGrid rounds coordinates to the nearest point of the tile's grid rather than truncating them,
+	//	keeping the shared edges of adjacent features together
+	SnapToPixelGrid bool `toml:"snap_to_pixel_grid"`
+	//	SnapGrid is the grid size, in the provider's units, coordinates are snapped to. 0 disables snapping
+	SnapGrid float64 `toml:"snap_grid"`
+	//	Densify is the maximum segment length, in the provider's units, of reprojected features. 0 disables densification
+	Densify float64 `toml:"densify"`
+	//	SQLFilter is a SQL predicate the provider applies to the layer's features. Only supported by SQL backed providers
+	SQLFilter string `toml:"sql_filter"`
+	//	RepairGeometry closes polygon rings and drops rings with too few points before encoding
+	RepairGeometry bool `toml:"repair_geometry"`
+	//	Dedupe drops features with the same geometry and tags as a feature already in the tile
+	Dedupe bool `toml:"dedupe"`
+	//	MinArea drops polygons with an area, in tile units, below the threshold. 0 disables the threshold
+	MinArea float64 `toml:"min_area"`
+	//	SplitByField is the name of a tag used to assign features to the layers named in SplitLayers
+	SplitByField string `toml:"split_by_field"`
+	//	SplitLayers maps SplitByField values to the names of the layers their features are encoded in
+	SplitLayers map[string]string `toml:"split_layers"`
+	//	Version is the MVT spec version the layer is encoded with, 1 or 2. Defaults to 2
+	Version uint `toml:"version"`
+	//	TimeAttribute is the name of a tag holding the feature's timestamp. requests with a time window filter on it
+	TimeAttribute string `toml:"time_attribute"`
+	//	FailOnInvalidCoordinates fails the layer on a feature with NaN or infinite coordinates instead of dropping the feature
+	FailOnInvalidCoordinates bool `toml:"fail_on_invalid_coordinates"`
+	//	ReverseLines reverses the vertex order of line strings
+	ReverseLines bool `toml:"reverse_lines"`
+	//	ZIndex is the draw order of the layer in the tile. layers are encoded in increasing ZIndex
+	ZIndex int `toml:"z_index"`
+	//	OversizeRatio skips features wider or taller than this many times the tile instead of clipping them
+	OversizeRatio float64 `toml:"oversize_ratio"`
+	//	DetectGeomType detects the geometry type of a layer whose provider doesn't report it from a sample of its features
+	DetectGeomType bool `toml:"detect_geom_type"`
+	//	FieldRename maps the names of the provider's tags to the names they are encoded with
+	FieldRename map[string]string `toml:"field_rename"`
 }
 
 //	checks the config for issues
@@ -73,6 +152,15 @@ func (c *Config) Validate() error {
 			mapLayers[m.Name] = map[string]MapLayer{}
 		}
 
+		switch m.Scheme {
+		case "", "xyz", "tms":
+		default:
+			return ErrInvalidTileScheme{
+				MapName: m.Name,
+				Scheme:  m.Scheme,
+			}
+		}
+
 		for _, l := range m.Layers {
 			var name string
 
@@ -107,6 +195,21 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if j := c.Webserver.TileCacheTTLJitter; j < 0 || j > 100 {
+		return ErrInvalidTileCacheTTLJitter{
+			Jitter: j,
+		}
+	}
+
+	//	the default map must be configured
+	if c.DefaultMap != "" {
+		if _, ok := mapLayers[c.DefaultMap]; !ok {
+			return ErrMapNotFound{
+				MapName: c.DefaultMap,
+			}
+		}
+	}
+
 	return nil
 }
 
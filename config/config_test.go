@@ -86,6 +86,7 @@ func TestParse(t *testing.T) {
 				attribution = "Test Attribution"
 				bounds = [-180.0, -85.05112877980659, 180.0, 85.0511287798066]
 				center = [-76.275329586789, 39.153492567373, 8.0]
+				scheme = "tms"
 
 					[[maps.layers]]
 					provider_layer = "provider1.water"
@@ -129,6 +130,7 @@ func TestParse(t *testing.T) {
 						Attribution: "Test Attribution",
 						Bounds:      []float64{-180, -85.05112877980659, 180, 85.0511287798066},
 						Center:      [3]float64{-76.275329586789, 39.153492567373, 8.0},
+						Scheme:      "tms",
 						Layers: []config.MapLayer{
 							{
 								ProviderLayer: "provider1.water",
@@ -522,6 +524,63 @@ func TestValidate(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		"default map": {
+			config: config.Config{
+				DefaultMap: "osm",
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Layers: []config.MapLayer{
+							{
+								ProviderLayer: "provider1.water",
+							},
+						},
+					},
+				},
+			},
+		},
+		"default map not configured": {
+			config: config.Config{
+				DefaultMap: "missing",
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Layers: []config.MapLayer{
+							{
+								ProviderLayer: "provider1.water",
+							},
+						},
+					},
+				},
+			},
+			expectedErr: config.ErrMapNotFound{
+				MapName: "missing",
+			},
+		},
+		"invalid scheme": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:   "osm",
+						Scheme: "wmts",
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidTileScheme{
+				MapName: "osm",
+				Scheme:  "wmts",
+			},
+		},
+		"tile cache ttl jitter": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					TileCacheTTLJitter: 150,
+				},
+			},
+			expectedErr: config.ErrInvalidTileCacheTTLJitter{
+				Jitter: 150,
+			},
+		},
 	}
 
 	for name, tc := range tests {
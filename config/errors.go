@@ -34,3 +34,20 @@ type ErrMissingEnvVar struct {
 func (e ErrMissingEnvVar) Error() string {
 	return fmt.Sprintf("config: config file is referencing an environment variable that is not set (%v)", e.EnvVar)
 }
+
+type ErrInvalidTileCacheTTLJitter struct {
+	Jitter int
+}
+
+func (e ErrInvalidTileCacheTTLJitter) Error() string {
+	return fmt.Sprintf("config: invalid tile_cache_ttl_jitter (%v), expected a percent between 0 and 100", e.Jitter)
+}
+
+type ErrInvalidTileScheme struct {
+	MapName string
+	Scheme  string
+}
+
+func (e ErrInvalidTileScheme) Error() string {
+	return fmt.Sprintf("config: map (%v) has an invalid scheme (%v), expected xyz or tms", e.MapName, e.Scheme)
+}
@@ -130,6 +130,16 @@ func (bb *BoundingBox) Contains(pt [2]float64) (v bool) {
 	return true
 }
 
+// Intersects will return weather the bounding boxes share any points, including their edges.
+// The corners of either bounding box may be in any order.
+func (bb *BoundingBox) Intersects(bbox BoundingBox) bool {
+	if bb == nil {
+		return false
+	}
+	return bb.MinX() <= bbox.MaxX() && bbox.MinX() <= bb.MaxX() &&
+		bb.MinY() <= bbox.MaxY() && bbox.MinY() <= bb.MaxY()
+}
+
 // NewBBox returns X1, Y1, X2, Y2 (LL, UR) for the input points
 func NewBBox(points ...[2]float64) (bbox BoundingBox) {
 	var xy [2]float64
@@ -160,3 +170,55 @@ func NewBBox(points ...[2]float64) (bbox BoundingBox) {
 	}
 	return bbox
 }
+
+// BBoxOf returns the bounding box of the points of the geometry. ErrEmptyGeometry is returned if the
+// geometry has no points and ErrUnknownGeometry if the geometry is not one of the geom types.
+func BBoxOf(g Geometry) (bbox BoundingBox, err error) {
+	points, err := geometryPoints(nil, g)
+	if err != nil {
+		return bbox, err
+	}
+	if len(points) == 0 {
+		return bbox, ErrEmptyGeometry
+	}
+	return NewBBox(points...), nil
+}
+
+// geometryPoints appends the points of the geometry to points
+func geometryPoints(points [][2]float64, g Geometry) ([][2]float64, error) {
+	switch geo := g.(type) {
+	case Pointer:
+		return append(points, geo.XY()), nil
+	case MultiPointer:
+		return append(points, geo.Points()...), nil
+	case LineStringer:
+		return append(points, geo.Verticies()...), nil
+	case MultiLineStringer:
+		for _, ln := range geo.LineStrings() {
+			points = append(points, ln...)
+		}
+		return points, nil
+	case Polygoner:
+		for _, rn := range geo.LinearRings() {
+			points = append(points, rn...)
+		}
+		return points, nil
+	case MultiPolygoner:
+		for _, ply := range geo.Polygons() {
+			for _, rn := range ply {
+				points = append(points, rn...)
+			}
+		}
+		return points, nil
+	case Collectioner:
+		var err error
+		for _, cg := range geo.Geometries() {
+			if points, err = geometryPoints(points, cg); err != nil {
+				return points, err
+			}
+		}
+		return points, nil
+	default:
+		return points, ErrUnknownGeometry
+	}
+}
@@ -143,6 +143,96 @@ func TestBBoxContains(t *testing.T) {
 	}
 }
 
+func TestBBoxIntersects(t *testing.T) {
+	type tcase struct {
+		bb       geom.BoundingBox
+		bbox     geom.BoundingBox
+		expected bool
+	}
+	fn := func(t *testing.T, tc tcase) {
+		t.Parallel()
+		bb := tc.bb
+		if got := bb.Intersects(tc.bbox); got != tc.expected {
+			t.Errorf("intersects, expected %v got %v", tc.expected, got)
+		}
+	}
+	tests := map[string]tcase{
+		"overlapping": {
+			bb:       geom.BoundingBox{{0, 0}, {3, 3}},
+			bbox:     geom.BoundingBox{{2, 2}, {5, 5}},
+			expected: true,
+		},
+		"contained": {
+			bb:       geom.BoundingBox{{0, 0}, {3, 3}},
+			bbox:     geom.BoundingBox{{1, 1}, {2, 2}},
+			expected: true,
+		},
+		"touching edges": {
+			bb:       geom.BoundingBox{{0, 0}, {3, 3}},
+			bbox:     geom.BoundingBox{{3, 0}, {5, 3}},
+			expected: true,
+		},
+		"top left and bottom right corners": {
+			bb:       geom.BoundingBox{{0, 3}, {3, 0}},
+			bbox:     geom.BoundingBox{{2, 2}, {5, 5}},
+			expected: true,
+		},
+		"disjoint": {
+			bb:       geom.BoundingBox{{0, 0}, {3, 3}},
+			bbox:     geom.BoundingBox{{4, 0}, {5, 3}},
+			expected: false,
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
+func TestBBoxOf(t *testing.T) {
+	type tcase struct {
+		geom        geom.Geometry
+		expected    geom.BoundingBox
+		expectedErr error
+	}
+	fn := func(t *testing.T, tc tcase) {
+		t.Parallel()
+		bbox, err := geom.BBoxOf(tc.geom)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if bbox != tc.expected {
+			t.Errorf("bbox, expected %v got %v", tc.expected, bbox)
+		}
+	}
+	tests := map[string]tcase{
+		"point": {
+			geom:     geom.Point{1, 2},
+			expected: geom.BoundingBox{{1, 2}, {1, 2}},
+		},
+		"polygon": {
+			geom:     geom.Polygon{{{0, 0}, {4, 0}, {4, 3}}, {{1, 1}, {2, 1}, {2, -1}}},
+			expected: geom.BoundingBox{{0, -1}, {4, 3}},
+		},
+		"collection": {
+			geom:     geom.Collection{geom.Point{-5, 10}, geom.MultiLineString{{{0, 0}, {1, 1}}}},
+			expected: geom.BoundingBox{{-5, 0}, {1, 10}},
+		},
+		"empty": {
+			geom:        geom.LineString{},
+			expectedErr: geom.ErrEmptyGeometry,
+		},
+		"unknown": {
+			geom:        "point",
+			expectedErr: geom.ErrUnknownGeometry,
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
+
 func TestBBoxAttributes(t *testing.T) {
 	bblncmp := func(pt [2]float64, x, y float64) bool { return pt[0] == x && pt[1] == y }
 
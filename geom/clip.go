@@ -0,0 +1,276 @@
+package geom
+
+// ClipLineString clips the line string to the bounding box using the Liang–Barsky algorithm. A line
+// string leaving and re-entering the bounding box is split into multiple line strings. An empty
+// MultiLineString is returned if no part of the line string is inside the bounding box.
+func ClipLineString(ls LineString, bbox BoundingBox) MultiLineString {
+	var (
+		mls MultiLineString
+		cur LineString
+	)
+
+	flush := func() {
+		if len(cur) > 1 {
+			mls = append(mls, cur)
+		}
+		cur = nil
+	}
+
+	for i := 1; i < len(ls); i++ {
+		start, end, ok := clipSegment(ls[i-1], ls[i], bbox)
+		if !ok {
+			flush()
+			continue
+		}
+
+		// continue the current line if the segment starts where the last one ended
+		if len(cur) == 0 || cur[len(cur)-1] != start {
+			flush()
+			cur = LineString{start}
+		}
+		cur = append(cur, end)
+
+		// the segment was cut so the line left the bounding box
+		if end != ls[i] {
+			flush()
+		}
+	}
+	flush()
+
+	return mls
+}
+
+// clipSegment clips the segment from p0 to p1 to the bounding box with the Liang–Barsky algorithm.
+// false is returned if the segment is entirely outside of the bounding box.
+func clipSegment(p0, p1 [2]float64, bbox BoundingBox) (start, end [2]float64, ok bool) {
+	dx, dy := p1[0]-p0[0], p1[1]-p0[1]
+
+	// the parametric values of the clipped segment's start and end
+	t0, t1 := 0.0, 1.0
+
+	// the edge tests in order: left, right, bottom, top
+	ps := [4]float64{-dx, dx, -dy, dy}
+	qs := [4]float64{p0[0] - bbox.MinX(), bbox.MaxX() - p0[0], p0[1] - bbox.MinY(), bbox.MaxY() - p0[1]}
+
+	for i := range ps {
+		p, q := ps[i], qs[i]
+		if p == 0 {
+			// parallel to the edge and outside of it
+			if q < 0 {
+				return start, end, false
+			}
+			continue
+		}
+
+		t := q / p
+		if p < 0 {
+			// entering
+			if t > t1 {
+				return start, end, false
+			}
+			if t > t0 {
+				t0 = t
+			}
+		} else {
+			// leaving
+			if t < t0 {
+				return start, end, false
+			}
+			if t < t1 {
+				t1 = t
+			}
+		}
+	}
+
+	// use the original points when they are not cut so continuing segments can be joined exactly
+	start, end = p0, p1
+	if t0 > 0 {
+		start = [2]float64{p0[0] + t0*dx, p0[1] + t0*dy}
+	}
+	if t1 < 1 {
+		end = [2]float64{p0[0] + t1*dx, p0[1] + t1*dy}
+	}
+
+	return start, end, true
+}
+
+// ClipPolygon clips each ring of the polygon to the bounding box using the Sutherland–Hodgman
+// algorithm. The clipped rings are closed along the edges of the bounding box. Rings left with fewer
+// than 3 points are dropped. If the exterior ring is dropped a nil Polygon is returned.
+func ClipPolygon(p Polygon, bbox BoundingBox) Polygon {
+	var ply Polygon
+	for i, ring := range p {
+		clipped := clipRing(ring, bbox)
+		if len(clipped) < 3 {
+			if i == 0 {
+				// the interior rings can't be holes without an exterior ring
+				return nil
+			}
+			continue
+		}
+		ply = append(ply, clipped)
+	}
+	return ply
+}
+
+// clipRing clips the ring against each edge of the bounding box in turn. the ring is implicitly closed.
+func clipRing(ring [][2]float64, bbox BoundingBox) [][2]float64 {
+	minx, miny, maxx, maxy := bbox.MinX(), bbox.MinY(), bbox.MaxX(), bbox.MaxY()
+
+	// each edge reports if a point is inside of it and where a segment crosses it
+	edges := [4]struct {
+		inside    func(pt [2]float64) bool
+		intersect func(a, b [2]float64) [2]float64
+	}{
+		{ // left
+			inside: func(pt [2]float64) bool { return pt[0] >= minx },
+			intersect: func(a, b [2]float64) [2]float64 {
+				return [2]float64{minx, a[1] + (b[1]-a[1])*(minx-a[0])/(b[0]-a[0])}
+			},
+		},
+		{ // right
+			inside: func(pt [2]float64) bool { return pt[0] <= maxx },
+			intersect: func(a, b [2]float64) [2]float64 {
+				return [2]float64{maxx, a[1] + (b[1]-a[1])*(maxx-a[0])/(b[0]-a[0])}
+			},
+		},
+		{ // bottom
+			inside: func(pt [2]float64) bool { return pt[1] >= miny },
+			intersect: func(a, b [2]float64) [2]float64 {
+				return [2]float64{a[0] + (b[0]-a[0])*(miny-a[1])/(b[1]-a[1]), miny}
+			},
+		},
+		{ // top
+			inside: func(pt [2]float64) bool { return pt[1] <= maxy },
+			intersect: func(a, b [2]float64) [2]float64 {
+				return [2]float64{a[0] + (b[0]-a[0])*(maxy-a[1])/(b[1]-a[1]), maxy}
+			},
+		},
+	}
+
+	out := ring
+	for _, edge := range edges {
+		if len(out) == 0 {
+			break
+		}
+
+		in := out
+		out = make([][2]float64, 0, len(in)+2)
+
+		prev := in[len(in)-1]
+		for _, pt := range in {
+			switch {
+			case edge.inside(pt):
+				if !edge.inside(prev) {
+					out = append(out, edge.intersect(prev, pt))
+				}
+				out = append(out, pt)
+			case edge.inside(prev):
+				out = append(out, edge.intersect(prev, pt))
+			}
+			prev = pt
+		}
+	}
+
+	// a vertex on an edge is both an intersection and an inside point. drop the repeated points
+	var clipped [][2]float64
+	for i, pt := range out {
+		if i > 0 && pt == out[i-1] {
+			continue
+		}
+		clipped = append(clipped, pt)
+	}
+	if n := len(clipped); n > 1 && clipped[0] == clipped[n-1] {
+		clipped = clipped[:n-1]
+	}
+
+	return clipped
+}
+
+// Clip clips the geometry to the bounding box. Points outside of the bounding box are dropped, line
+// strings are clipped with ClipLineString and polygons with ClipPolygon. A nil Geometry is returned
+// if no part of the geometry is inside the bounding box. ErrUnknownGeometry is returned if the
+// geometry is not one of the geom types.
+func Clip(g Geometry, bbox BoundingBox) (Geometry, error) {
+	// Contains expects the min corner first
+	bbox = BoundingBox{{bbox.MinX(), bbox.MinY()}, {bbox.MaxX(), bbox.MaxY()}}
+
+	switch geo := g.(type) {
+	case Point:
+		if !bbox.Contains(geo) {
+			return nil, nil
+		}
+		return geo, nil
+
+	case MultiPoint:
+		var mp MultiPoint
+		for _, pt := range geo {
+			if bbox.Contains(pt) {
+				mp = append(mp, pt)
+			}
+		}
+		if len(mp) == 0 {
+			return nil, nil
+		}
+		return mp, nil
+
+	case LineString:
+		mls := ClipLineString(geo, bbox)
+		switch len(mls) {
+		case 0:
+			return nil, nil
+		case 1:
+			return LineString(mls[0]), nil
+		default:
+			return mls, nil
+		}
+
+	case MultiLineString:
+		var mls MultiLineString
+		for _, ls := range geo {
+			mls = append(mls, ClipLineString(ls, bbox)...)
+		}
+		if len(mls) == 0 {
+			return nil, nil
+		}
+		return mls, nil
+
+	case Polygon:
+		ply := ClipPolygon(geo, bbox)
+		if ply == nil {
+			return nil, nil
+		}
+		return ply, nil
+
+	case MultiPolygon:
+		var mply MultiPolygon
+		for _, p := range geo {
+			if ply := ClipPolygon(p, bbox); ply != nil {
+				mply = append(mply, ply)
+			}
+		}
+		if len(mply) == 0 {
+			return nil, nil
+		}
+		return mply, nil
+
+	case Collection:
+		var col Collection
+		for _, cg := range geo {
+			clipped, err := Clip(cg, bbox)
+			if err != nil {
+				return nil, err
+			}
+			if clipped != nil {
+				col = append(col, clipped)
+			}
+		}
+		if len(col) == 0 {
+			return nil, nil
+		}
+		return col, nil
+
+	default:
+		return nil, ErrUnknownGeometry
+	}
+}
@@ -0,0 +1,95 @@
+package geom_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+func TestClip(t *testing.T) {
+	type tcase struct {
+		geom     geom.Geometry
+		bbox     geom.BoundingBox
+		expected geom.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		t.Parallel()
+		g, err := geom.Clip(tc.geom, tc.bbox)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if !reflect.DeepEqual(g, tc.expected) {
+			t.Errorf("clipped geometry, expected %v got %v", tc.expected, g)
+		}
+	}
+
+	bbox := geom.BoundingBox{{0, 0}, {20, 20}}
+
+	tests := map[string]tcase{
+		"point inside": {
+			geom:     geom.Point{5, 5},
+			bbox:     bbox,
+			expected: geom.Point{5, 5},
+		},
+		"point inside top left and bottom right corners": {
+			geom:     geom.Point{5, 5},
+			bbox:     geom.BoundingBox{{0, 20}, {20, 0}},
+			expected: geom.Point{5, 5},
+		},
+		"point outside": {
+			geom: geom.Point{25, 5},
+			bbox: bbox,
+		},
+		"line inside": {
+			geom:     geom.LineString{{1, 1}, {5, 5}, {10, 2}},
+			bbox:     bbox,
+			expected: geom.LineString{{1, 1}, {5, 5}, {10, 2}},
+		},
+		"line crossing two edges": {
+			geom:     geom.LineString{{-10, 0}, {30, 20}},
+			bbox:     bbox,
+			expected: geom.LineString{{0, 5}, {20, 15}},
+		},
+		"line leaving and re-entering": {
+			geom:     geom.LineString{{5, 5}, {25, 5}, {25, 10}, {5, 10}},
+			bbox:     bbox,
+			expected: geom.MultiLineString{{{5, 5}, {20, 5}}, {{20, 10}, {5, 10}}},
+		},
+		"line outside": {
+			geom: geom.LineString{{-5, -5}, {-5, 30}},
+			bbox: bbox,
+		},
+		"polygon straddling one edge": {
+			geom:     geom.Polygon{{{-5, 5}, {10, 5}, {10, 15}, {-5, 15}}},
+			bbox:     bbox,
+			expected: geom.Polygon{{{0, 5}, {10, 5}, {10, 15}, {0, 15}}},
+		},
+		"polygon with a hole outside": {
+			geom:     geom.Polygon{{{-5, 5}, {10, 5}, {10, 15}, {-5, 15}}, {{-4, 6}, {-2, 6}, {-2, 8}}},
+			bbox:     bbox,
+			expected: geom.Polygon{{{0, 5}, {10, 5}, {10, 15}, {0, 15}}},
+		},
+		"polygon covering the bounding box": {
+			geom:     geom.Polygon{{{-5, -5}, {25, -5}, {25, 25}, {-5, 25}}},
+			bbox:     bbox,
+			// the ring starts where the last edge cut it
+			expected: geom.Polygon{{{0, 20}, {0, 0}, {20, 0}, {20, 20}}},
+		},
+		"polygon outside": {
+			geom: geom.MultiPolygon{{{{30, 30}, {40, 30}, {40, 40}}}},
+			bbox: bbox,
+		},
+		"collection": {
+			geom:     geom.Collection{geom.Point{30, 30}, geom.MultiPoint{{1, 1}, {-1, 1}}},
+			bbox:     bbox,
+			expected: geom.Collection{geom.MultiPoint{{1, 1}}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -11,3 +11,15 @@ const (
 	MultiPolygon    uint32 = 6
 	Collection      uint32 = 7
 )
+
+//	dimension flags
+const (
+	//	ISO WKB flags the dimensions of a geometry using the high bits of the type
+	ISOZFlag uint32 = 0x80000000
+	ISOMFlag uint32 = 0x40000000
+
+	//	ISO SQL/MM offsets the type by the dimensions of the geometry. i.e. 1001 is a PointZ
+	ZOffset  uint32 = 1000
+	MOffset  uint32 = 2000
+	ZMOffset uint32 = 3000
+)
@@ -9,11 +9,39 @@ import (
 	"github.com/go-spatial/tegola/geom/encoding/wkb/internal/consts"
 )
 
-func ByteOrderType(r io.Reader) (byteOrder binary.ByteOrder, typ uint32, err error) {
+// Dimension is the number of values stored for each coordinate of a geometry
+type Dimension uint8
+
+const (
+	XY Dimension = iota
+	XYZ
+	XYM
+	XYZM
+)
+
+// Size is the number of float64 values used to encode a coordinate
+func (d Dimension) Size() int {
+	switch d {
+	case XYZ, XYM:
+		return 3
+	case XYZM:
+		return 4
+	default:
+		return 2
+	}
+}
+
+// HasM reports if the coordinates have a measure
+func (d Dimension) HasM() bool { return d == XYM || d == XYZM }
+
+// ByteOrderType reads the byte order marker and the geometry type. Both the ISO (high bit)
+// and SQL/MM (1000, 2000, 3000 offset) dimension encodings are stripped from the returned
+// type and reported as the dimension.
+func ByteOrderType(r io.Reader) (byteOrder binary.ByteOrder, typ uint32, dim Dimension, err error) {
 	var bom = make([]byte, 1, 1)
 	// the bom is the first byte
 	if _, err = r.Read(bom); err != nil {
-		return byteOrder, typ, err
+		return byteOrder, typ, dim, err
 	}
 
 	if bom[0] == 0 {
@@ -23,15 +51,78 @@ func ByteOrderType(r io.Reader) (byteOrder binary.ByteOrder, typ uint32, err err
 	}
 
 	// Reading the type which is 4 bytes
-	err = binary.Read(r, byteOrder, &typ)
-	return byteOrder, typ, err
+	if err = binary.Read(r, byteOrder, &typ); err != nil {
+		return byteOrder, typ, dim, err
+	}
+
+	typ, dim = splitType(typ)
+
+	return byteOrder, typ, dim, nil
+}
+
+// splitType separates the dimension flags from the geometry type
+func splitType(typ uint32) (uint32, Dimension) {
+	var hasZ, hasM bool
+
+	// ISO high bits
+	if typ&consts.ISOZFlag != 0 {
+		hasZ = true
+	}
+	if typ&consts.ISOMFlag != 0 {
+		hasM = true
+	}
+	typ &^= consts.ISOZFlag | consts.ISOMFlag
+
+	// SQL/MM offsets
+	switch {
+	case typ > consts.ZMOffset && typ < consts.ZMOffset+consts.ZOffset:
+		hasZ, hasM = true, true
+		typ -= consts.ZMOffset
+	case typ > consts.MOffset && typ < consts.MOffset+consts.ZOffset:
+		hasM = true
+		typ -= consts.MOffset
+	case typ > consts.ZOffset && typ < consts.ZOffset+consts.ZOffset:
+		hasZ = true
+		typ -= consts.ZOffset
+	}
+
+	switch {
+	case hasZ && hasM:
+		return typ, XYZM
+	case hasZ:
+		return typ, XYZ
+	case hasM:
+		return typ, XYM
+	default:
+		return typ, XY
+	}
+}
+
+// coord reads a single coordinate. values beyond x and y (i.e. z and m) are dropped. If ms is not nil
+// the m value of a measured coordinate is appended to it.
+func coord(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (pt [2]float64, err error) {
+	if dim == XY {
+		err = binary.Read(r, bom, &pt)
+		return pt, err
+	}
+
+	vals := make([]float64, dim.Size())
+	if err = binary.Read(r, bom, vals); err != nil {
+		return pt, err
+	}
+
+	if ms != nil && dim.HasM() {
+		// m is always the last value
+		*ms = append(*ms, vals[len(vals)-1])
+	}
+
+	return [2]float64{vals[0], vals[1]}, nil
 }
 
-func Point(r io.Reader, bom binary.ByteOrder) (pt geom.Point, err error) {
-	err = binary.Read(r, bom, &pt)
-	return pt, err
+func Point(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (pt geom.Point, err error) {
+	return coord(r, bom, dim, ms)
 }
-func MultiPoint(r io.Reader, bom binary.ByteOrder) (pts geom.MultiPoint, err error) {
+func MultiPoint(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (pts geom.MultiPoint, err error) {
 	var num, typ uint32 // Number of points
 	err = binary.Read(r, bom, &num)
 	if err != nil {
@@ -41,14 +132,14 @@ func MultiPoint(r io.Reader, bom binary.ByteOrder) (pts geom.MultiPoint, err err
 	pts = make([][2]float64, num)
 	for i := range pts {
 
-		bom, typ, err = ByteOrderType(r)
+		bom, typ, dim, err = ByteOrderType(r)
 		if err != nil {
 			return pts, err
 		}
 		if typ != consts.Point {
 			return pts, fmt.Errorf("Expected to find a point in MultiPoint; got type %v instead.", typ)
 		}
-		err = binary.Read(r, bom, &pts[i])
+		pts[i], err = coord(r, bom, dim, ms)
 		if err != nil {
 			return pts, err
 		}
@@ -56,49 +147,49 @@ func MultiPoint(r io.Reader, bom binary.ByteOrder) (pts geom.MultiPoint, err err
 	return pts, err
 }
 
-func LineString(r io.Reader, bom binary.ByteOrder) (ln geom.LineString, err error) {
+func LineString(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (ln geom.LineString, err error) {
 	var num uint32 // Number of points
 	if err = binary.Read(r, bom, &num); err != nil {
 		return ln, err
 	}
 	ln = make([][2]float64, num)
 	for i := range ln {
-		if err = binary.Read(r, bom, &ln[i]); err != nil {
+		if ln[i], err = coord(r, bom, dim, ms); err != nil {
 			return ln, err
 		}
 	}
 	return ln, err
 }
 
-func MultiLineString(r io.Reader, bom binary.ByteOrder) (lns geom.MultiLineString, err error) {
+func MultiLineString(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (lns geom.MultiLineString, err error) {
 	var num uint32
 	if err = binary.Read(r, bom, &num); err != nil {
 		return lns, err
 	}
 	lns = make([][][2]float64, num)
 	for i := range lns {
-		bom, typ, err := ByteOrderType(r)
+		bom, typ, dim, err := ByteOrderType(r)
 		if err != nil {
 			return lns, err
 		}
 		if typ != consts.LineString {
 			return lns, fmt.Errorf("Expected to find a linestring in MultiLineString; got type %v instead.", typ)
 		}
-		if lns[i], err = LineString(r, bom); err != nil {
+		if lns[i], err = LineString(r, bom, dim, ms); err != nil {
 			return lns, err
 		}
 	}
 	return lns, err
 }
 
-func LinerRing(r io.Reader, bom binary.ByteOrder) (rn [][2]float64, err error) {
+func LinerRing(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (rn [][2]float64, err error) {
 	var num uint32 // Number of points
 	if err = binary.Read(r, bom, &num); err != nil {
 		return rn, err
 	}
 	rn = make([][2]float64, num)
 	for i := range rn {
-		if err = binary.Read(r, bom, &rn[i]); err != nil {
+		if rn[i], err = coord(r, bom, dim, ms); err != nil {
 			return rn, err
 		}
 	}
@@ -112,67 +203,67 @@ func LinerRing(r io.Reader, bom binary.ByteOrder) (rn [][2]float64, err error) {
 	return rn, err
 }
 
-func Polygon(r io.Reader, bom binary.ByteOrder) (ply geom.Polygon, err error) {
+func Polygon(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (ply geom.Polygon, err error) {
 	var num uint32
 	if err = binary.Read(r, bom, &num); err != nil {
 		return ply, err
 	}
 	ply = make([][][2]float64, num)
 	for i := range ply {
-		if ply[i], err = LinerRing(r, bom); err != nil {
+		if ply[i], err = LinerRing(r, bom, dim, ms); err != nil {
 			return ply, err
 		}
 	}
 	return ply, err
 }
 
-func MultiPolygon(r io.Reader, bom binary.ByteOrder) (plys geom.MultiPolygon, err error) {
+func MultiPolygon(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (plys geom.MultiPolygon, err error) {
 	var num uint32
 	if err = binary.Read(r, bom, &num); err != nil {
 		return plys, err
 	}
 	plys = make([][][][2]float64, num)
 	for i := range plys {
-		bom, typ, err := ByteOrderType(r)
+		bom, typ, dim, err := ByteOrderType(r)
 		if err != nil {
 			return plys, err
 		}
 		if typ != consts.Polygon {
 			return plys, fmt.Errorf("Expected to find a polygon in MultiPolygon; got type %v instead.", typ)
 		}
-		if plys[i], err = Polygon(r, bom); err != nil {
+		if plys[i], err = Polygon(r, bom, dim, ms); err != nil {
 			return plys, err
 		}
 	}
 	return plys, err
 }
 
-func Collection(r io.Reader, bom binary.ByteOrder) (col geom.Collection, err error) {
+func Collection(r io.Reader, bom binary.ByteOrder, dim Dimension, ms *[]float64) (col geom.Collection, err error) {
 	var num uint32
 	if err = binary.Read(r, bom, &num); err != nil {
 		return col, err
 	}
 	col = make(geom.Collection, num)
 	for i := range col {
-		bom, typ, err := ByteOrderType(r)
+		bom, typ, dim, err := ByteOrderType(r)
 		if err != nil {
 			return col, err
 		}
 		switch typ {
 		case consts.Point:
-			col[i], err = Point(r, bom)
+			col[i], err = Point(r, bom, dim, ms)
 		case consts.LineString:
-			col[i], err = LineString(r, bom)
+			col[i], err = LineString(r, bom, dim, ms)
 		case consts.Polygon:
-			col[i], err = Polygon(r, bom)
+			col[i], err = Polygon(r, bom, dim, ms)
 		case consts.MultiPoint:
-			col[i], err = MultiPoint(r, bom)
+			col[i], err = MultiPoint(r, bom, dim, ms)
 		case consts.MultiLineString:
-			col[i], err = MultiLineString(r, bom)
+			col[i], err = MultiLineString(r, bom, dim, ms)
 		case consts.MultiPolygon:
-			col[i], err = MultiPolygon(r, bom)
+			col[i], err = MultiPolygon(r, bom, dim, ms)
 		case consts.Collection:
-			col[i], err = Collection(r, bom)
+			col[i], err = Collection(r, bom, dim, ms)
 		default:
 			err = fmt.Errorf("Unknown type (%v) found in collection", typ)
 		}
@@ -0,0 +1,249 @@
+package wkb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+// TWKB header metadata flags
+// https://github.com/TWKB/Specification/blob/master/twkb.md
+const (
+	twkbHasBBox      = 0x01
+	twkbHasSize      = 0x02
+	twkbHasIDList    = 0x04
+	twkbHasExtended  = 0x08
+	twkbIsEmpty      = 0x10
+	twkbExtendedHasZ = 0x01
+	twkbExtendedHasM = 0x02
+)
+
+// twkbReader keeps the state needed to decode the delta encoded coordinates of a TWKB geometry.
+type twkbReader struct {
+	r *bytes.Reader
+	// number of values for each coordinate. only the first two are kept.
+	dims int
+	// scale factor for each dimension
+	scales [4]float64
+	// the previous coordinate. coordinates are stored as deltas of the previous one.
+	prev [4]int64
+}
+
+func (tr *twkbReader) varint() (int64, error) {
+	return binary.ReadVarint(tr.r)
+}
+
+func (tr *twkbReader) uvarint() (uint64, error) {
+	return binary.ReadUvarint(tr.r)
+}
+
+func (tr *twkbReader) coord() (pt [2]float64, err error) {
+	for i := 0; i < tr.dims; i++ {
+		d, err := tr.varint()
+		if err != nil {
+			return pt, err
+		}
+		tr.prev[i] += d
+		if i < 2 {
+			pt[i] = float64(tr.prev[i]) / tr.scales[i]
+		}
+	}
+	return pt, nil
+}
+
+func (tr *twkbReader) coords() (pts [][2]float64, err error) {
+	n, err := tr.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	pts = make([][2]float64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		pt, err := tr.coord()
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+func (tr *twkbReader) polygon() (ply geom.Polygon, err error) {
+	n, err := tr.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	ply = make(geom.Polygon, 0, n)
+	for i := uint64(0); i < n; i++ {
+		rn, err := tr.coords()
+		if err != nil {
+			return nil, err
+		}
+		// Remove the last point if it is the same, as the WKB decoder does.
+		if l := len(rn); l > 1 && rn[0] == rn[l-1] {
+			rn = rn[:l-1]
+		}
+		ply = append(ply, rn)
+	}
+	return ply, nil
+}
+
+// skipIDList reads past the id list of a multi geometry or collection. the ids are not
+// part of the geom types so they are dropped.
+func (tr *twkbReader) skipIDList(n uint64) error {
+	for i := uint64(0); i < n; i++ {
+		if _, err := tr.varint(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeTWKB will attempt to decode the geometries encoded as Tiny Well Known Binary (TWKB)
+// into geom.Geometry values. TWKB geometries carry no length, so b may contain any number of
+// geometries one after the other.
+// spec: https://github.com/TWKB/Specification/blob/master/twkb.md
+func DecodeTWKB(b []byte) (geos []geom.Geometry, err error) {
+	r := bytes.NewReader(b)
+	for r.Len() > 0 {
+		geo, err := decodeTWKB(r)
+		if err != nil {
+			return geos, err
+		}
+		geos = append(geos, geo)
+	}
+	return geos, nil
+}
+
+func decodeTWKB(r *bytes.Reader) (geo geom.Geometry, err error) {
+	typPrecision, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	meta, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	typ := uint32(typPrecision & 0x0F)
+	// the precision is a zigzag encoded signed value in the high 4 bits
+	p := int(typPrecision >> 4)
+	precision := (p >> 1) ^ -(p & 1)
+
+	tr := twkbReader{r: r, dims: 2}
+	tr.scales[0] = math.Pow10(precision)
+	tr.scales[1] = tr.scales[0]
+
+	if meta&twkbHasExtended != 0 {
+		ext, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if ext&twkbExtendedHasZ != 0 {
+			tr.scales[tr.dims] = math.Pow10(int(ext>>2) & 0x07)
+			tr.dims++
+		}
+		if ext&twkbExtendedHasM != 0 {
+			tr.scales[tr.dims] = math.Pow10(int(ext>>5) & 0x07)
+			tr.dims++
+		}
+	}
+
+	if meta&twkbHasSize != 0 {
+		if _, err := tr.uvarint(); err != nil {
+			return nil, err
+		}
+	}
+
+	if meta&twkbHasBBox != 0 {
+		// a min and a delta for each dimension
+		for i := 0; i < tr.dims*2; i++ {
+			if _, err := tr.varint(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	empty := meta&twkbIsEmpty != 0
+
+	switch typ {
+	case Point:
+		// geom.Point can not represent an empty point
+		if empty {
+			return nil, nil
+		}
+		pt, err := tr.coord()
+		return geom.Point(pt), err
+	case LineString:
+		if empty {
+			return geom.LineString{}, nil
+		}
+		pts, err := tr.coords()
+		return geom.LineString(pts), err
+	case Polygon:
+		if empty {
+			return geom.Polygon{}, nil
+		}
+		return tr.polygon()
+	case MultiPoint, MultiLineString, MultiPolygon, Collection:
+	default:
+		return nil, ErrUnknownGeometryType{Typ: typ}
+	}
+
+	var n uint64
+	if !empty {
+		if n, err = tr.uvarint(); err != nil {
+			return nil, err
+		}
+		if meta&twkbHasIDList != 0 {
+			if err = tr.skipIDList(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch typ {
+	case MultiPoint:
+		mpt := make(geom.MultiPoint, 0, n)
+		for i := uint64(0); i < n; i++ {
+			pt, err := tr.coord()
+			if err != nil {
+				return nil, err
+			}
+			mpt = append(mpt, pt)
+		}
+		return mpt, nil
+	case MultiLineString:
+		mln := make(geom.MultiLineString, 0, n)
+		for i := uint64(0); i < n; i++ {
+			pts, err := tr.coords()
+			if err != nil {
+				return nil, err
+			}
+			mln = append(mln, pts)
+		}
+		return mln, nil
+	case MultiPolygon:
+		mply := make(geom.MultiPolygon, 0, n)
+		for i := uint64(0); i < n; i++ {
+			ply, err := tr.polygon()
+			if err != nil {
+				return nil, err
+			}
+			mply = append(mply, ply)
+		}
+		return mply, nil
+	default:
+		// each member of a collection is a complete TWKB geometry with its own header
+		col := make(geom.Collection, 0, n)
+		for i := uint64(0); i < n; i++ {
+			g, err := decodeTWKB(r)
+			if err != nil {
+				return nil, err
+			}
+			col = append(col, g)
+		}
+		return col, nil
+	}
+}
@@ -0,0 +1,119 @@
+package wkb_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/encoding/wkb"
+)
+
+func TestDecodeTWKB(t *testing.T) {
+	type tcase struct {
+		bytes []byte
+		// expected is round tripped through WKB so the TWKB decode is compared to the WKB decode
+		expected []geom.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		var expected []geom.Geometry
+		for _, g := range tc.expected {
+			bs, err := wkb.EncodeBytes(g)
+			if err != nil {
+				t.Fatalf("unexpected err encoding wkb: %v", err)
+			}
+			g, err = wkb.DecodeBytes(bs)
+			if err != nil {
+				t.Fatalf("unexpected err decoding wkb: %v", err)
+			}
+			expected = append(expected, g)
+		}
+
+		geos, err := wkb.DecodeTWKB(tc.bytes)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(geos, expected) {
+			t.Errorf("geometries, expected %v got %v", expected, geos)
+		}
+	}
+
+	point := []byte{
+		0x01,       // type 1 point, precision 0
+		0x00,       // no metadata
+		0x02, 0x04, // 1, 2
+	}
+	line := []byte{
+		0x02, // type 2 linestring, precision 0
+		0x00,
+		0x02,       // number of points
+		0x02, 0x02, // 1, 1
+		0x08, 0x08, // delta 4, 4
+	}
+
+	tests := map[string]tcase{
+		"point": {
+			bytes:    point,
+			expected: []geom.Geometry{geom.Point{1, 2}},
+		},
+		"point negative precision with bbox and size": {
+			bytes:    []byte{0x11, 0x03, 0x06, 0x18, 0x00, 0x05, 0x00, 0x18, 0x05},
+			expected: []geom.Geometry{geom.Point{120, -30}},
+		},
+		"linestring": {
+			bytes:    line,
+			expected: []geom.Geometry{geom.LineString{{1, 1}, {5, 5}}},
+		},
+		"linestring precision 1": {
+			bytes:    []byte{0x22, 0x00, 0x03, 0x1e, 0x32, 0x1e, 0x85, 0x01, 0x8e, 0x01, 0x54},
+			expected: []geom.Geometry{geom.LineString{{1.5, 2.5}, {3, -4.2}, {10.1, 0}}},
+		},
+		"polygon precision 2": {
+			bytes: []byte{
+				0x43, 0x00, 0x02,
+				0x05, 0x00, 0x00, 0xd0, 0x0f, 0x00, 0x00, 0xd0, 0x0f, 0xcf, 0x0f, 0x00, 0x00, 0xcf, 0x0f,
+				0x04, 0xc2, 0x03, 0xc2, 0x03, 0x00, 0xfa, 0x01, 0xfa, 0x01, 0x00, 0xf9, 0x01, 0xf9, 0x01,
+			},
+			expected: []geom.Geometry{geom.Polygon{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2.25, 2.25}, {2.25, 3.5}, {3.5, 3.5}, {2.25, 2.25}},
+			}},
+		},
+		"multipoint with id list": {
+			bytes:    []byte{0x04, 0x04, 0x02, 0x0e, 0x12, 0x0a, 0x0a, 0x0f, 0x06},
+			expected: []geom.Geometry{geom.MultiPoint{{5, 5}, {-3, 8}}},
+		},
+		"multilinestring": {
+			bytes:    []byte{0x05, 0x00, 0x02, 0x02, 0x00, 0x00, 0x02, 0x02, 0x02, 0x08, 0x08, 0x02, 0x04},
+			expected: []geom.Geometry{geom.MultiLineString{{{0, 0}, {1, 1}}, {{5, 5}, {6, 7}}}},
+		},
+		"multipolygon": {
+			bytes: []byte{
+				0x06, 0x00, 0x02,
+				0x01, 0x04, 0x00, 0x00, 0x02, 0x00, 0x00, 0x02, 0x01, 0x01,
+				0x01, 0x04, 0x0a, 0x0a, 0x02, 0x00, 0x00, 0x02, 0x01, 0x01,
+			},
+			expected: []geom.Geometry{geom.MultiPolygon{
+				{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+				{{{5, 5}, {6, 5}, {6, 6}, {5, 5}}},
+			}},
+		},
+		"collection": {
+			bytes:    bytes.Join([][]byte{{0x07, 0x00, 0x02}, point, line}, nil),
+			expected: []geom.Geometry{geom.Collection{geom.Point{1, 2}, geom.LineString{{1, 1}, {5, 5}}}},
+		},
+		"multiple geometries": {
+			bytes:    bytes.Join([][]byte{point, line}, nil),
+			expected: []geom.Geometry{geom.Point{1, 2}, geom.LineString{{1, 1}, {5, 5}}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -8,8 +8,10 @@ package wkb
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/go-spatial/tegola/geom"
 	"github.com/go-spatial/tegola/geom/encoding/wkb/internal/consts"
@@ -53,38 +55,130 @@ func DecodeBytes(b []byte) (geo geom.Geometry, err error) {
 
 // Decode will attempt to decode a geometry encoded as WKB into a geom.Geometry.
 func Decode(r io.Reader) (geo geom.Geometry, err error) {
+	geo, _, err = decodeGeometry(r, nil)
+	return geo, err
+}
+
+// DecodeType will read just the geometry type of a geometry encoded as WKB. The coordinates are not decoded.
+// The ISO and SQL/MM dimension flags are stripped from the returned type.
+func DecodeType(b []byte) (typ uint32, err error) {
+	_, typ, _, err = decode.ByteOrderType(bytes.NewReader(b))
+	return typ, err
+}
+
+// DecodeBytesMeasures will attempt to decode a geometry encoded as WKB into a geom.Geometry. The M
+// values of a measured geometry are returned in the order the vertices are encoded. ms is empty if
+// the geometry is not measured.
+func DecodeBytesMeasures(b []byte) (geo geom.Geometry, ms []float64, err error) {
+	ms = []float64{}
+	geo, _, err = decodeGeometry(bytes.NewReader(b), &ms)
+	return geo, ms, err
+}
+
+// decodeGeometry decodes a single geometry. if ms is not nil the M values of the geometry are appended to it.
+func decodeGeometry(r io.Reader, ms *[]float64) (geo geom.Geometry, typ uint32, err error) {
 
-	bom, typ, err := decode.ByteOrderType(r)
+	bom, typ, dim, err := decode.ByteOrderType(r)
 	if err != nil {
-		return nil, err
+		return nil, typ, err
 	}
 	switch typ {
 	case Point:
-		pt, err := decode.Point(r, bom)
-		return geom.Point(pt), err
+		pt, err := decode.Point(r, bom, dim, ms)
+		return geom.Point(pt), typ, err
 	case MultiPoint:
-		mpt, err := decode.MultiPoint(r, bom)
-		return geom.MultiPoint(mpt), err
+		mpt, err := decode.MultiPoint(r, bom, dim, ms)
+		return geom.MultiPoint(mpt), typ, err
 	case LineString:
-		ln, err := decode.LineString(r, bom)
-		return geom.LineString(ln), err
+		ln, err := decode.LineString(r, bom, dim, ms)
+		return geom.LineString(ln), typ, err
 	case MultiLineString:
-		mln, err := decode.MultiLineString(r, bom)
-		return geom.MultiLineString(mln), err
+		mln, err := decode.MultiLineString(r, bom, dim, ms)
+		return geom.MultiLineString(mln), typ, err
 	case Polygon:
-		pl, err := decode.Polygon(r, bom)
-		return geom.Polygon(pl), err
+		pl, err := decode.Polygon(r, bom, dim, ms)
+		return geom.Polygon(pl), typ, err
 	case MultiPolygon:
-		mpl, err := decode.MultiPolygon(r, bom)
-		return geom.MultiPolygon(mpl), err
+		mpl, err := decode.MultiPolygon(r, bom, dim, ms)
+		return geom.MultiPolygon(mpl), typ, err
 	case Collection:
-		col, err := decode.Collection(r, bom)
-		return col, err
+		col, err := decode.Collection(r, bom, dim, ms)
+		return col, typ, err
 	default:
-		return nil, ErrUnknownGeometryType{typ}
+		return nil, typ, ErrUnknownGeometryType{typ}
 	}
 }
 
+// DecodeRecord describes where in a byte slice a geometry was decoded from.
+type DecodeRecord struct {
+	// Offset is the index of the first byte of the geometry
+	Offset int
+	// Consumed is the number of bytes the geometry was decoded from
+	Consumed int
+	// Type is the WKB geometry type, without the dimension flags
+	Type uint32
+}
+
+// ErrTrailingBytes is returned by DecodeAll when bytes remain which could not be decoded as a geometry.
+type ErrTrailingBytes struct {
+	// Offset is the index of the first byte that could not be decoded
+	Offset int
+	// Len is the number of remaining bytes
+	Len int
+	Err error
+}
+
+func (e ErrTrailingBytes) Error() string {
+	return fmt.Sprintf("unable to decode %v trailing bytes at offset %v: %v", e.Len, e.Offset, e.Err)
+}
+
+// DecodeAll decodes consecutive WKB geometries until all of the bytes have been consumed. A record
+// is returned for each decoded geometry which can be used to find where decoding drifted from the data.
+// If bytes remain which can not be decoded, the decoded geometries and records are returned along with
+// an ErrTrailingBytes error.
+func DecodeAll(b []byte) (geos []geom.Geometry, records []DecodeRecord, err error) {
+	buff := bytes.NewReader(b)
+
+	for buff.Len() > 0 {
+		offset := len(b) - buff.Len()
+
+		geo, typ, err := decodeGeometry(buff, nil)
+		if err != nil {
+			return geos, records, ErrTrailingBytes{
+				Offset: offset,
+				Len:    len(b) - offset,
+				Err:    err,
+			}
+		}
+
+		geos = append(geos, geo)
+		records = append(records, DecodeRecord{
+			Offset:   offset,
+			Consumed: len(b) - buff.Len() - offset,
+			Type:     typ,
+		})
+	}
+
+	return geos, records, nil
+}
+
+// DecodeHexWKB decodes the WKB geometries from a hex encoded string, as returned by many databases for
+// geometry columns. An optional 0x prefix and any whitespace in the string are ignored.
+func DecodeHexWKB(s string) ([]geom.Geometry, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	geos, _, err := DecodeAll(b)
+	return geos, err
+}
+
 func _encode(en *encode.Encoder, g geom.Geometry) error {
 	switch geo := g.(type) {
 	case geom.Pointer:
@@ -1,10 +1,15 @@
 package wkb_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gdey/tbltest"
+	"github.com/go-spatial/tegola/geom"
 	"github.com/go-spatial/tegola/geom/encoding/wkb"
 	"github.com/go-spatial/tegola/geom/encoding/wkb/internal/tcase"
 )
@@ -47,3 +52,383 @@ func TestWKBDecode(t *testing.T) {
 		tbltest.Cases(tcases...).Run(fn)
 	}
 }
+
+func TestWKBDecodeDimensions(t *testing.T) {
+	// encode writes the values as little endian WKB preceded by the byte order marker
+	encode := func(vals ...interface{}) []byte {
+		buff := new(bytes.Buffer)
+		buff.WriteByte(1)
+		for _, v := range vals {
+			if err := binary.Write(buff, binary.LittleEndian, v); err != nil {
+				panic(err)
+			}
+		}
+		return buff.Bytes()
+	}
+
+	type tcase struct {
+		bytes    []byte
+		expected geom.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, err := wkb.DecodeBytes(tc.bytes)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g)
+		}
+	}
+
+	tests := map[string]tcase{
+		"iso point z": {
+			bytes:    encode(uint32(0x80000001), []float64{1, 2, 3}),
+			expected: geom.Point{1, 2},
+		},
+		"iso point m": {
+			bytes:    encode(uint32(0x40000001), []float64{1, 2, 3}),
+			expected: geom.Point{1, 2},
+		},
+		"iso polygon zm": {
+			bytes: encode(
+				uint32(0xC0000003),
+				uint32(1), // number of rings
+				uint32(4), // number of points
+				[]float64{
+					30, 10, 1, 5,
+					40, 40, 2, 6,
+					20, 40, 3, 7,
+					30, 10, 1, 5,
+				},
+			),
+			expected: geom.Polygon{{{30, 10}, {40, 40}, {20, 40}}},
+		},
+		"sql/mm point z": {
+			bytes:    encode(uint32(1001), []float64{1, 2, 3}),
+			expected: geom.Point{1, 2},
+		},
+		"sql/mm linestring zm": {
+			bytes:    encode(uint32(3002), uint32(2), []float64{1, 2, 3, 4, 5, 6, 7, 8}),
+			expected: geom.LineString{{1, 2}, {5, 6}},
+		},
+		"iso multipoint z": {
+			bytes: encode(
+				uint32(0x80000004),
+				uint32(2), // number of points
+				byte(1), uint32(0x80000001), []float64{1, 2, 3},
+				byte(1), uint32(0x80000001), []float64{4, 5, 6},
+			),
+			expected: geom.MultiPoint{{1, 2}, {4, 5}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestWKBDecodeMixedByteOrder(t *testing.T) {
+	// geometry writes a geometry header, with the byte order marker for the byte order, followed by the values
+	geometry := func(bom binary.ByteOrder, typ uint32, vals ...interface{}) []byte {
+		buff := new(bytes.Buffer)
+		if bom == binary.LittleEndian {
+			buff.WriteByte(1)
+		} else {
+			buff.WriteByte(0)
+		}
+		vals = append([]interface{}{typ}, vals...)
+		for _, v := range vals {
+			if b, ok := v.([]byte); ok {
+				buff.Write(b)
+				continue
+			}
+			if err := binary.Write(buff, bom, v); err != nil {
+				panic(err)
+			}
+		}
+		return buff.Bytes()
+	}
+
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	type tcase struct {
+		bytes    []byte
+		expected geom.Geometry
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, err := wkb.DecodeBytes(tc.bytes)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g)
+		}
+	}
+
+	// the rings of a polygon have no header of their own so they share the polygon's byte order
+	triangle := func(bom binary.ByteOrder, o float64) []byte {
+		return geometry(bom, wkb.Polygon, uint32(1), uint32(4), o, o, o+1, o, o+1, o+1, o, o)
+	}
+
+	tests := map[string]tcase{
+		"multipolygon": {
+			bytes: geometry(le, wkb.MultiPolygon, uint32(2), triangle(be, 0), triangle(le, 5)),
+			expected: geom.MultiPolygon{
+				{{{0, 0}, {1, 0}, {1, 1}}},
+				{{{5, 5}, {6, 5}, {6, 6}}},
+			},
+		},
+		"multipoint": {
+			bytes:    geometry(be, wkb.MultiPoint, uint32(2), geometry(le, wkb.Point, 1.0, 2.0), geometry(be, wkb.Point, 3.0, 4.0)),
+			expected: geom.MultiPoint{{1, 2}, {3, 4}},
+		},
+		"multilinestring": {
+			bytes:    geometry(le, wkb.MultiLineString, uint32(2), geometry(be, wkb.LineString, uint32(2), 0.0, 0.0, 1.0, 1.0), geometry(le, wkb.LineString, uint32(2), 2.0, 2.0, 3.0, 3.0)),
+			expected: geom.MultiLineString{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}}},
+		},
+		"collection": {
+			bytes:    geometry(be, wkb.Collection, uint32(2), geometry(le, wkb.Point, 1.0, 2.0), triangle(le, 0)),
+			expected: geom.Collection{geom.Point{1, 2}, geom.Polygon{{{0, 0}, {1, 0}, {1, 1}}}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestWKBDecodeAll(t *testing.T) {
+	point := []byte{
+		0x01,                   // byte order marker little
+		0x01, 0x00, 0x00, 0x00, // type 1 point
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, // x 1
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, // y 2
+	}
+
+	join := func(bs ...[]byte) []byte { return bytes.Join(bs, nil) }
+
+	type tcase struct {
+		bytes            []byte
+		expectedGeos     []geom.Geometry
+		expectedRecords  []wkb.DecodeRecord
+		expectedTrailing *wkb.ErrTrailingBytes
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		geos, records, err := wkb.DecodeAll(tc.bytes)
+		if tc.expectedTrailing != nil {
+			e, ok := err.(wkb.ErrTrailingBytes)
+			if !ok {
+				t.Fatalf("expected ErrTrailingBytes got %v", err)
+			}
+			if e.Offset != tc.expectedTrailing.Offset || e.Len != tc.expectedTrailing.Len {
+				t.Errorf("trailing bytes, expected offset %v len %v got offset %v len %v", tc.expectedTrailing.Offset, tc.expectedTrailing.Len, e.Offset, e.Len)
+			}
+		} else if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(geos, tc.expectedGeos) {
+			t.Errorf("geometries, expected %v got %v", tc.expectedGeos, geos)
+		}
+
+		if !reflect.DeepEqual(records, tc.expectedRecords) {
+			t.Errorf("records, expected %+v got %+v", tc.expectedRecords, records)
+		}
+	}
+
+	tests := map[string]tcase{
+		"single geometry": {
+			bytes:           point,
+			expectedGeos:    []geom.Geometry{geom.Point{1, 2}},
+			expectedRecords: []wkb.DecodeRecord{{Offset: 0, Consumed: 21, Type: wkb.Point}},
+		},
+		"two geometries": {
+			bytes:        join(point, point),
+			expectedGeos: []geom.Geometry{geom.Point{1, 2}, geom.Point{1, 2}},
+			expectedRecords: []wkb.DecodeRecord{
+				{Offset: 0, Consumed: 21, Type: wkb.Point},
+				{Offset: 21, Consumed: 21, Type: wkb.Point},
+			},
+		},
+		"trailing bytes": {
+			bytes:            join(point, []byte{0x01, 0x02, 0x03}),
+			expectedGeos:     []geom.Geometry{geom.Point{1, 2}},
+			expectedRecords:  []wkb.DecodeRecord{{Offset: 0, Consumed: 21, Type: wkb.Point}},
+			expectedTrailing: &wkb.ErrTrailingBytes{Offset: 21, Len: 3},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestDecodeHexWKB(t *testing.T) {
+	polygon := geom.Polygon{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		{{2, 2}, {2, 4}, {4, 4}},
+	}
+	bs, err := wkb.EncodeBytes(polygon)
+	if err != nil {
+		t.Fatalf("unexpected err encoding wkb: %v", err)
+	}
+	expected, err := wkb.DecodeBytes(bs)
+	if err != nil {
+		t.Fatalf("unexpected err decoding wkb: %v", err)
+	}
+	hexPolygon := hex.EncodeToString(bs)
+
+	type tcase struct {
+		hex      string
+		expected []geom.Geometry
+		err      bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		geos, err := wkb.DecodeHexWKB(tc.hex)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected err got nil")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(geos, tc.expected) {
+			t.Errorf("geometries, expected %v got %v", tc.expected, geos)
+		}
+	}
+
+	tests := map[string]tcase{
+		"polygon": {
+			hex:      hexPolygon,
+			expected: []geom.Geometry{expected},
+		},
+		"upper case with prefix": {
+			hex:      "0X" + strings.ToUpper(hexPolygon),
+			expected: []geom.Geometry{expected},
+		},
+		"whitespace": {
+			hex:      " 0x" + hexPolygon[:20] + "\n\t" + hexPolygon[20:] + " ",
+			expected: []geom.Geometry{expected},
+		},
+		"invalid hex": {
+			hex: "0xzz",
+			err: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestWKBDecodePointAccessors(t *testing.T) {
+	// POINT (1.5 -2.25) little endian
+	geo, err := wkb.DecodeHexWKB("0101000000000000000000f83f00000000000002c0")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(geo) != 1 {
+		t.Fatalf("geometries, expected 1 got %v", len(geo))
+	}
+
+	pt, ok := geo[0].(geom.Point)
+	if !ok {
+		t.Fatalf("geometry, expected geom.Point got %T", geo[0])
+	}
+	if pt.X() != 1.5 {
+		t.Errorf("x, expected 1.5 got %v", pt.X())
+	}
+	if pt.Y() != -2.25 {
+		t.Errorf("y, expected -2.25 got %v", pt.Y())
+	}
+}
+
+func TestWKBDecodeMeasures(t *testing.T) {
+	encode := func(vals ...interface{}) []byte {
+		buff := new(bytes.Buffer)
+		buff.WriteByte(1)
+		for _, v := range vals {
+			if err := binary.Write(buff, binary.LittleEndian, v); err != nil {
+				panic(err)
+			}
+		}
+		return buff.Bytes()
+	}
+
+	type tcase struct {
+		bytes      []byte
+		expected   geom.Geometry
+		expectedMs []float64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		g, ms, err := wkb.DecodeBytesMeasures(tc.bytes)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !reflect.DeepEqual(g, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, g)
+		}
+
+		if !reflect.DeepEqual(ms, tc.expectedMs) {
+			t.Errorf("measures, expected %v got %v", tc.expectedMs, ms)
+		}
+	}
+
+	tests := map[string]tcase{
+		"iso linestring m": {
+			bytes:      encode(uint32(0x40000002), uint32(3), []float64{1, 2, 10, 3, 4, 20, 5, 6, 30}),
+			expected:   geom.LineString{{1, 2}, {3, 4}, {5, 6}},
+			expectedMs: []float64{10, 20, 30},
+		},
+		"sql/mm linestring m": {
+			bytes:      encode(uint32(2002), uint32(2), []float64{1, 2, 10, 3, 4, 20}),
+			expected:   geom.LineString{{1, 2}, {3, 4}},
+			expectedMs: []float64{10, 20},
+		},
+		"iso linestring zm": {
+			bytes:      encode(uint32(0xC0000002), uint32(2), []float64{1, 2, 100, 10, 3, 4, 200, 20}),
+			expected:   geom.LineString{{1, 2}, {3, 4}},
+			expectedMs: []float64{10, 20},
+		},
+		"iso point m": {
+			bytes:      encode(uint32(0x40000001), []float64{1, 2, 3}),
+			expected:   geom.Point{1, 2},
+			expectedMs: []float64{3},
+		},
+		"linestring z": {
+			bytes:      encode(uint32(0x80000002), uint32(2), []float64{1, 2, 100, 3, 4, 200}),
+			expected:   geom.LineString{{1, 2}, {3, 4}},
+			expectedMs: []float64{},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
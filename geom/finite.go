@@ -0,0 +1,20 @@
+package geom
+
+import "math"
+
+// IsFinite reports whether all of the coordinates of the geometry are finite, neither NaN nor infinite.
+// A corrupt geometry can decode to such coordinates, which have no place on a tile grid.
+func IsFinite(g Geometry) (bool, error) {
+	points, err := geometryPoints(nil, g)
+	if err != nil {
+		return false, err
+	}
+	for _, pt := range points {
+		for _, v := range pt {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
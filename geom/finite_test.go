@@ -0,0 +1,54 @@
+package geom_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+func TestIsFinite(t *testing.T) {
+	type tcase struct {
+		geom     geom.Geometry
+		expected bool
+	}
+	fn := func(t *testing.T, tc tcase) {
+		t.Parallel()
+		got, err := geom.IsFinite(tc.geom)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if got != tc.expected {
+			t.Errorf("failed, expected %v got %v", tc.expected, got)
+		}
+	}
+	tests := map[string]tcase{
+		"point": {
+			geom:     geom.Point{1, 2},
+			expected: true,
+		},
+		"nan point": {
+			geom:     geom.Point{math.NaN(), 2},
+			expected: false,
+		},
+		"infinite line string": {
+			geom:     geom.LineString{{0, 0}, {1, math.Inf(1)}},
+			expected: false,
+		},
+		"nan polygon in a collection": {
+			geom: geom.Collection{
+				geom.Point{1, 2},
+				geom.Polygon{{{0, 0}, {1, 0}, {math.NaN(), 1}}},
+			},
+			expected: false,
+		},
+		"multi polygon": {
+			geom:     geom.MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}}}},
+			expected: true,
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) { fn(t, tc) })
+	}
+}
@@ -6,6 +6,9 @@ import "errors"
 // ErrUnknownGeometry is returned when the geometry type is unknown or unsupported.
 var ErrUnknownGeometry = errors.New("unknown geometry")
 
+// ErrEmptyGeometry is returned when an operation needs at least one point and the geometry has none.
+var ErrEmptyGeometry = errors.New("empty geometry")
+
 // Geometry is an object with a spatial reference.
 // if a method accepts a Geometry type it's only expected to support the geom types in this package
 type Geometry interface{}
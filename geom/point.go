@@ -12,6 +12,16 @@ func (p Point) XY() [2]float64 {
 	return p
 }
 
+// X returns the x coordinate of the point
+func (p Point) X() float64 {
+	return p[0]
+}
+
+// Y returns the y coordinate of the point
+func (p Point) Y() float64 {
+	return p[1]
+}
+
 // SetXY sets a pair of coordinates
 func (p *Point) SetXY(xy [2]float64) (err error) {
 	if p == nil {
@@ -1,6 +1,13 @@
 package slippy
 
-import "math"
+import (
+	"math"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+// MaxLatitude is the maximum latitude covered by Web Mercator tiles
+const MaxLatitude = 85.0511287798066
 
 func NewTile(z, x, y uint64, buffer float64, srid uint64) *Tile {
 	return &Tile{
@@ -72,3 +79,51 @@ func (t *Tile) BufferedExtent() (bufferedExtent [2][2]float64, srid uint64) {
 
 	return bufferedExtent, t.SRID
 }
+
+// TileFromLonLat returns the x and y tile coordinates of the tile containing the WGS84 lon / lat point at the provided zoom.
+// It uses the standard Web Mercator (slippy map) tile math. Points outside of the Web Mercator bounds are clamped to the edge tiles.
+func TileFromLonLat(lon, lat float64, zoom uint) (x, y uint) {
+	// the number of tiles along each axis
+	n := math.Exp2(float64(zoom))
+
+	// clamp the latitude to the bounds of the projection
+	lat = math.Max(-MaxLatitude, math.Min(MaxLatitude, lat))
+	latRad := lat * math.Pi / 180.0
+
+	tx := math.Floor((lon + 180.0) / 360.0 * n)
+	ty := math.Floor((1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n)
+
+	return uint(clampTile(tx, n)), uint(clampTile(ty, n))
+}
+
+// TileBounds returns the WGS84 bounds of the tile as [[minLon, minLat], [maxLon, maxLat]].
+// It is the inverse of TileFromLonLat, the bounds can be passed to atlas.TilesForBounds.
+func TileBounds(z, x, y uint) *geom.BoundingBox {
+	n := math.Exp2(float64(z))
+
+	return &geom.BoundingBox{
+		{tileLon(float64(x), n), tileLat(float64(y+1), n)},
+		{tileLon(float64(x+1), n), tileLat(float64(y), n)},
+	}
+}
+
+// tileLon returns the longitude of the left edge of the tile column x
+func tileLon(x, n float64) float64 {
+	return x/n*360.0 - 180.0
+}
+
+// tileLat returns the latitude of the top edge of the tile row y
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180.0 / math.Pi
+}
+
+// clampTile restricts the tile coordinate v to the range of valid tile coordinates [0, n-1]
+func clampTile(v, n float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > n-1 {
+		return n - 1
+	}
+	return v
+}
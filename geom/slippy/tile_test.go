@@ -1,9 +1,11 @@
 package slippy_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
 	"github.com/go-spatial/tegola/geom/slippy"
 )
 
@@ -61,3 +63,83 @@ func TestBufferedExtent(t *testing.T) {
 		}
 	}
 }
+
+func TestTileFromLonLat(t *testing.T) {
+	type tcase struct {
+		lon, lat float64
+		zoom     uint
+		x, y     uint
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		x, y := slippy.TileFromLonLat(tc.lon, tc.lat, tc.zoom)
+		if x != tc.x || y != tc.y {
+			t.Errorf("expected %v/%v got %v/%v", tc.x, tc.y, x, y)
+		}
+	}
+
+	tests := map[string]tcase{
+		"null island zoom 0": {lon: 0, lat: 0, zoom: 0, x: 0, y: 0},
+		"null island zoom 1": {lon: 0, lat: 0, zoom: 1, x: 1, y: 1},
+		"london zoom 10":     {lon: -0.1276, lat: 51.5072, zoom: 10, x: 511, y: 340},
+		"berlin zoom 12":     {lon: 13.4050, lat: 52.5200, zoom: 12, x: 2200, y: 1343},
+		"san francisco":      {lon: -122.4194, lat: 37.7749, zoom: 16, x: 10482, y: 25331},
+		"max bounds":         {lon: 180, lat: -90, zoom: 2, x: 3, y: 3},
+		"min bounds":         {lon: -180, lat: 90, zoom: 2, x: 0, y: 0},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestTileBounds(t *testing.T) {
+	type tcase struct {
+		z, x, y  uint
+		expected geom.BoundingBox
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		bounds := slippy.TileBounds(tc.z, tc.x, tc.y)
+
+		for i := range bounds {
+			for j := range bounds[i] {
+				if math.Abs(bounds[i][j]-tc.expected[i][j]) > 1e-9 {
+					t.Errorf("expected %v got %v", tc.expected, bounds)
+					return
+				}
+			}
+		}
+
+		// the bounds center should map back to the same tile
+		x, y := slippy.TileFromLonLat((bounds.MinX()+bounds.MaxX())/2, (bounds.MinY()+bounds.MaxY())/2, tc.z)
+		if x != tc.x || y != tc.y {
+			t.Errorf("round trip, expected %v/%v got %v/%v", tc.x, tc.y, x, y)
+		}
+	}
+
+	tests := map[string]tcase{
+		"zoom 0": {
+			z: 0, x: 0, y: 0,
+			expected: geom.BoundingBox{{-180, -slippy.MaxLatitude}, {180, slippy.MaxLatitude}},
+		},
+		"zoom 1 south east": {
+			z: 1, x: 1, y: 1,
+			expected: geom.BoundingBox{{0, -slippy.MaxLatitude}, {180, 0}},
+		},
+		"london zoom 10": {
+			z: 10, x: 511, y: 340,
+			expected: geom.BoundingBox{{-0.3515625, 51.39920565355377}, {0, 51.6180165487737}},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Package singleflight coalesces concurrent calls for the same key into a single call.
+package singleflight
+
+import "sync"
+
+// call is an in-flight or completed Do call
+type call struct {
+	wg sync.WaitGroup
+
+	val interface{}
+	err error
+}
+
+// Group tracks the in-flight calls by key. The zero value is ready to use.
+type Group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do executes fn and returns its results, making sure only one execution is in-flight for a
+// given key at a time. If a duplicate call comes in, the duplicate caller waits for the original
+// to complete and receives the same results. shared reports whether the results were given to
+// more than one caller.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+
+	if c, ok := g.m[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	g.m[key] = c
+	g.mu.Unlock()
+
+	c.val, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.m, key)
+	g.mu.Unlock()
+
+	return c.val, c.err, false
+}
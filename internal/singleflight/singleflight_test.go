@@ -0,0 +1,73 @@
+package singleflight_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/singleflight"
+)
+
+func TestDo(t *testing.T) {
+	var g singleflight.Group
+
+	v, err, shared := g.Do("key", func() (interface{}, error) {
+		return "bar", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if v.(string) != "bar" {
+		t.Errorf("value, expected bar got %v", v)
+	}
+	if shared {
+		t.Errorf("expected the result to not be shared")
+	}
+
+	someErr := errors.New("some error")
+	_, err, _ = g.Do("key", func() (interface{}, error) {
+		return nil, someErr
+	})
+	if err != someErr {
+		t.Errorf("err, expected %v got %v", someErr, err)
+	}
+}
+
+func TestDoDuplicates(t *testing.T) {
+	const callers = 10
+
+	var g singleflight.Group
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err, _ := g.Do("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "bar", nil
+			})
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if v.(string) != "bar" {
+				t.Errorf("value, expected bar got %v", v)
+			}
+		}()
+	}
+
+	// give the callers time to join the in-flight call
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("calls, expected 1 got %v", calls)
+	}
+}
@@ -0,0 +1,123 @@
+package mvt
+
+import (
+	"fmt"
+
+	"github.com/go-spatial/tegola"
+)
+
+// the approximate encoded sizes, in bytes, used by EstimateTileSize
+const (
+	// the layer's name, version and extent fields
+	estimateLayerOverhead = 16
+	// the feature's message tag and length, type field and the tags and geometry field headers
+	estimateFeatureOverhead = 8
+	// the key and value indexes of a tag
+	estimateTagSize = 2
+	// a vertex's x and y deltas. most deltas between neighbouring vertices fit in two bytes each
+	estimateVertexSize = 4
+	// a MoveTo, LineTo or ClosePath command integer
+	estimateCommandSize = 1
+	// the field tags and lengths wrapping each key and value of the layer
+	estimateKeyValueOverhead = 4
+	// the size of a numeric or boolean value
+	estimateNumericValueSize = 8
+)
+
+// EstimateTileSize approximates the number of bytes the features encode to as a single layer of an
+// MVT from their vertex, tag and property counts, without encoding them. The estimate is cheap to
+// compute so it can be used to decide whether the features should be simplified further before
+// they're encoded. Clipping and simplification during encoding make the encoded tile smaller than
+// the estimate.
+func EstimateTileSize(features ...Feature) int {
+	if len(features) == 0 {
+		return 0
+	}
+
+	size := estimateLayerOverhead
+
+	// the keys and values are shared by the features of the layer
+	keys := map[string]struct{}{}
+	vals := map[string]struct{}{}
+
+	for _, f := range features {
+		size += estimateFeatureOverhead
+		if f.ID != nil {
+			size += 1 + varintSize(*f.ID)
+		}
+
+		for k, v := range f.Tags {
+			size += estimateTagSize
+
+			if _, ok := keys[k]; !ok {
+				keys[k] = struct{}{}
+				size += estimateKeyValueOverhead + len(k)
+			}
+
+			vkey := fmt.Sprintf("%T:%v", v, v)
+			if _, ok := vals[vkey]; !ok {
+				vals[vkey] = struct{}{}
+				size += estimateKeyValueOverhead
+				if s, ok := v.(string); ok {
+					size += len(s)
+				} else {
+					size += estimateNumericValueSize
+				}
+			}
+		}
+
+		size += estimateGeometrySize(f.Geometry)
+	}
+
+	return size
+}
+
+// estimateGeometrySize approximates the number of bytes of a geometry's encoded commands
+func estimateGeometrySize(g tegola.Geometry) int {
+	// each line starts with a MoveTo followed by a LineTo
+	line := func(l tegola.LineString) int {
+		return 2*estimateCommandSize + len(l.Subpoints())*estimateVertexSize
+	}
+	// each ring is closed with a ClosePath
+	polygon := func(p tegola.Polygon) (size int) {
+		for _, l := range p.Sublines() {
+			size += line(l) + estimateCommandSize
+		}
+		return size
+	}
+
+	switch geo := g.(type) {
+	case tegola.Point, tegola.Point3:
+		return estimateCommandSize + estimateVertexSize
+	case tegola.MultiPoint:
+		return estimateCommandSize + len(geo.Points())*estimateVertexSize
+	case tegola.LineString:
+		return line(geo)
+	case tegola.MultiLine:
+		var size int
+		for _, l := range geo.Lines() {
+			size += line(l)
+		}
+		return size
+	case tegola.Polygon:
+		return polygon(geo)
+	case tegola.MultiPolygon:
+		var size int
+		for _, p := range geo.Polygons() {
+			size += polygon(p)
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// varintSize returns the number of bytes v is encoded in as a protobuf varint
+func varintSize(v uint64) int {
+	size := 1
+	for v >= 0x80 {
+		v >>= 7
+		size++
+	}
+	return size
+}
@@ -0,0 +1,105 @@
+package mvt
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+)
+
+func TestEstimateTileSize(t *testing.T) {
+	tile := tegola.NewTile(14, 8185, 5449)
+	bbox := tile.BoundingBox()
+	width, height := bbox.Maxx-bbox.Minx, bbox.Maxy-bbox.Miny
+
+	// pt returns the point at the fractions fx and fy of the tile's width and height
+	pt := func(fx, fy float64) basic.Point {
+		return basic.Point{bbox.Minx + fx*width, bbox.Miny + fy*height}
+	}
+
+	classes := []string{"primary", "secondary", "residential"}
+
+	// a sample of roads, buildings and points of interest spread over the tile
+	var features []Feature
+	for i := 0; i < 40; i++ {
+		var line basic.Line
+		for j := 0; j < 25; j++ {
+			line = append(line, pt(0.05+0.9*float64(j)/25, 0.05+0.9*float64(i)/40+0.01*math.Sin(float64(j))))
+		}
+
+		id := uint64(i + 1)
+		features = append(features, Feature{
+			ID:       &id,
+			Geometry: line,
+			Tags: map[string]interface{}{
+				"class": classes[i%len(classes)],
+				"name":  fmt.Sprintf("road %v", i),
+				"lanes": int64(1 + i%4),
+			},
+		})
+	}
+	for i := 0; i < 30; i++ {
+		cx, cy := 0.1+0.8*float64(i%6)/6, 0.1+0.8*float64(i/6)/5
+		var ring basic.Line
+		for j := 0; j < 10; j++ {
+			a := 2 * math.Pi * float64(j) / 10
+			ring = append(ring, pt(cx+0.03*math.Cos(a), cy+0.03*math.Sin(a)))
+		}
+
+		id := uint64(100 + i)
+		features = append(features, Feature{
+			ID:       &id,
+			Geometry: basic.Polygon{ring},
+			Tags: map[string]interface{}{
+				"building": "yes",
+				"height":   float64(3 * (1 + i%5)),
+			},
+		})
+	}
+	for i := 0; i < 50; i++ {
+		id := uint64(200 + i)
+		features = append(features, Feature{
+			ID:       &id,
+			Geometry: pt(0.02+0.96*float64(i)/50, 0.5+0.4*math.Cos(float64(i))),
+			Tags: map[string]interface{}{
+				"amenity": classes[i%2],
+			},
+		})
+	}
+
+	layer := Layer{
+		Name:         "sample",
+		DontSimplify: true,
+	}
+	layer.AddFeatures(features...)
+
+	var mvtTile Tile
+	if err := mvtTile.AddLayers(&layer); err != nil {
+		t.Fatalf("err adding layer: %v", err)
+	}
+
+	vtile, err := mvtTile.VTile(context.Background(), tile)
+	if err != nil {
+		t.Fatalf("err encoding tile: %v", err)
+	}
+	b, err := proto.Marshal(vtile)
+	if err != nil {
+		t.Fatalf("err marshalling tile: %v", err)
+	}
+
+	estimate := EstimateTileSize(features...)
+
+	// the estimate is expected to be within a quarter of the encoded size
+	if diff := math.Abs(float64(estimate-len(b))) / float64(len(b)); diff > 0.25 {
+		t.Errorf("estimate, expected within 25%% of %v bytes got %v (%.0f%%)", len(b), estimate, diff*100)
+	}
+
+	if size := EstimateTileSize(); size != 0 {
+		t.Errorf("estimate of no features, expected 0 got %v", size)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/basic"
@@ -87,6 +88,16 @@ func NewFeatures(geo tegola.Geometry, tags map[string]interface{}) (f []Feature)
 
 // VTileFeature will return a vectorTile.Feature that would represent the Feature
 func (f *Feature) VTileFeature(ctx context.Context, keys []string, vals []interface{}, tile *tegola.Tile, simplify bool) (tf *vectorTile.Tile_Feature, err error) {
+	var simplifier Simplifier
+	if simplify {
+		simplifier = DefaultSimplifier
+	}
+	return f.vTileFeature(ctx, keys, vals, tile, simplifier, QuantizeTruncate)
+}
+
+// vTileFeature will return a vectorTile.Feature that would represent the Feature. If simplifier
+// is nil the geometry is not simplified.
+func (f *Feature) vTileFeature(ctx context.Context, keys []string, vals []interface{}, tile *tegola.Tile, simplifier Simplifier, q Quantization) (tf *vectorTile.Tile_Feature, err error) {
 	tf = new(vectorTile.Tile_Feature)
 	tf.Id = f.ID
 
@@ -94,7 +105,7 @@ func (f *Feature) VTileFeature(ctx context.Context, keys []string, vals []interf
 		return tf, err
 	}
 
-	geo, gtype, err := encodeGeometry(ctx, f.Geometry, tile, simplify)
+	geo, gtype, err := encodeGeometry(ctx, f.Geometry, tile, simplifier, q)
 	if err != nil {
 		return tf, err
 	}
@@ -162,6 +173,9 @@ type cursor struct {
 
 	// Disabling scaling Use this when using clipping and scaling
 	DisableScaling bool
+
+	// How scaled points are converted to the tile's integer grid
+	Quantization Quantization
 }
 
 func NewCursor(tile *tegola.Tile) *cursor {
@@ -195,7 +209,12 @@ func (c *cursor) GetDeltaPointAndUpdate(p tegola.Point) (dx, dy int64) {
 }
 
 func (c *cursor) scalept(g tegola.Point) basic.Point {
-	pt, err := c.tile.ToPixel(tegola.WebMercator, [2]float64{g.X(), g.Y()})
+	toPixel := c.tile.ToPixel
+	if c.Quantization == QuantizeNearest {
+		toPixel = c.tile.ToPixelNearest
+	}
+
+	pt, err := toPixel(tegola.WebMercator, [2]float64{g.X(), g.Y()})
 	if err != nil {
 		panic(err)
 	}
@@ -568,8 +587,8 @@ func (c *cursor) ClosePath() uint32 {
 }
 
 // encodeGeometry will take a tegola.Geometry type and encode it according to the
-// mapbox vector_tile spec.
-func encodeGeometry(ctx context.Context, geom tegola.Geometry, tile *tegola.Tile, simplify bool) (g []uint32, vtyp vectorTile.Tile_GeomType, err error) {
+// mapbox vector_tile spec. If simplifier is nil the geometry is not simplified.
+func encodeGeometry(ctx context.Context, geom tegola.Geometry, tile *tegola.Tile, simplifier Simplifier, q Quantization) (g []uint32, vtyp vectorTile.Tile_GeomType, err error) {
 
 	if geom == nil {
 		return nil, vectorTile.Tile_UNKNOWN, ErrNilGeometryType
@@ -579,13 +598,17 @@ func encodeGeometry(ctx context.Context, geom tegola.Geometry, tile *tegola.Tile
 	c := NewCursor(tile)
 	// We are scaling separately, no need to scale in cursor.
 	c.DisableScaling = true
+	c.Quantization = q
 
 	// Project Geom
 
 	// TODO: gdey: We need to separate out the transform, simplification, and clipping from the encoding process. #224
 
 	geo := c.ScaleGeo(geom)
-	sg := SimplifyGeometry(geo, tile.ZEpislon(), simplify)
+	var sg tegola.Geometry = geo
+	if simplifier != nil {
+		sg = simplifier.Simplify(geo, tile.ZEpislon())
+	}
 
 	pbb, err := tile.PixelBufferedBounds()
 	if err != nil {
@@ -657,6 +680,17 @@ func encodeGeometry(ctx context.Context, geom tegola.Geometry, tile *tegola.Tile
 	}
 }
 
+// sortedTagKeys returns the keys of the tags in sorted order. the tags are encoded in key order
+// so identical features always encode to identical bytes.
+func sortedTagKeys(tags map[string]interface{}) []string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // keyvalMapsFromFeatures returns a key map and value map, to help with the translation
 // to mapbox tile format. In the Tile format, the Tile contains a mapping of all the unique
 // keys and values, and then each feature contains a vector map to these two. This is an
@@ -664,7 +698,8 @@ func encodeGeometry(ctx context.Context, geom tegola.Geometry, tile *tegola.Tile
 func keyvalMapsFromFeatures(features []Feature) (keyMap []string, valMap []interface{}, err error) {
 	var didFind bool
 	for _, f := range features {
-		for k, v := range f.Tags {
+		for _, k := range sortedTagKeys(f.Tags) {
+			v := f.Tags[k]
 			didFind = false
 			for _, mk := range keyMap {
 				if k == mk {
@@ -887,7 +922,8 @@ func keyvalTagsMap(keyMap []string, valueMap []interface{}, f *Feature) (tags []
 
 	var kidx, vidx int64
 
-	for key, val := range f.Tags {
+	for _, key := range sortedTagKeys(f.Tags) {
+		val := f.Tags[key]
 
 		kidx, vidx = -1, -1 // Set to known not found value.
 
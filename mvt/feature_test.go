@@ -77,7 +77,7 @@ func TestEncodeGeometry(t *testing.T) {
 		return &bpt
 	}
 	fn := func(i int, tcase tc) {
-		g, gtype, err := encodeGeometry(context.Background(), tcase.geo, tile, true)
+		g, gtype, err := encodeGeometry(context.Background(), tcase.geo, tile, DefaultSimplifier, QuantizeTruncate)
 		if tcase.eerr != err {
 			t.Errorf("[%v] error, Expected %v Got %v", i, tcase.eerr, err)
 		}
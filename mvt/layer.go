@@ -44,6 +44,18 @@ func init() {
 	}
 }
 
+// Quantization is how a layer's coordinates are converted to the tile's integer grid
+type Quantization int
+
+const (
+	// QuantizeTruncate truncates the pixel coordinates towards zero. This is the default.
+	QuantizeTruncate Quantization = iota
+	// QuantizeNearest rounds the pixel coordinates to the nearest grid point. Vertices of adjacent
+	// features which are a hair apart, i.e. from sources with different precisions, land on the same
+	// grid point rather than either side of a pixel boundary, avoiding gaps along shared edges.
+	QuantizeNearest
+)
+
 // Layer describes a layer in the tile. Each layer can have multiple features
 // which describe drawing.
 type Layer struct {
@@ -56,6 +68,14 @@ type Layer struct {
 	DontSimplify bool
 	// MaxSimplificationZoom is the zoom level at which point simplification is turned off. if value is zero Max is set to 14. If you do not want to simplify at any level set DontSimplify to true.
 	MaxSimplificationZoom uint
+	// Simplifier is the algorithm used to simplify the layer's geometries. If nil the DefaultSimplifier is used.
+	Simplifier Simplifier
+	// SimplifyTolerance is the tolerance, in pixels, the layer's geometries are simplified with. If zero the tolerance of the tile's zoom is used.
+	SimplifyTolerance float64
+	// Quantization is how the coordinates are converted to the tile's integer grid. Defaults to QuantizeTruncate.
+	Quantization Quantization
+	// SpecVersion is the version of the tile spec the layer is encoded with, 1 or 2. Defaults to 2.
+	SpecVersion uint
 }
 
 func valMapToVTileValue(valMap []interface{}) (vt []*vectorTile.Tile_Value) {
@@ -67,13 +87,16 @@ func valMapToVTileValue(valMap []interface{}) (vt []*vectorTile.Tile_Value) {
 
 // VTileLayer returns a vectorTile Tile_Layer object that represents this layer.
 func (l *Layer) VTileLayer(ctx context.Context, tile *tegola.Tile) (*vectorTile.Tile_Layer, error) {
-	kmap, vmap, err := keyvalMapsFromFeatures(l.features)
+	// coerce the tag values into types supported by the vector tile spec
+	lfeatures := coerceFeatureTags(l.features)
+
+	kmap, vmap, err := keyvalMapsFromFeatures(lfeatures)
 	if err != nil {
 		return nil, err
 	}
 	valmap := valMapToVTileValue(vmap)
-	var features = make([]*vectorTile.Tile_Feature, 0, len(l.features))
-	for _, f := range l.features {
+	var features = make([]*vectorTile.Tile_Feature, 0, len(lfeatures))
+	for _, f := range lfeatures {
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
@@ -84,7 +107,18 @@ func (l *Layer) VTileLayer(ctx context.Context, tile *tegola.Tile) (*vectorTile.
 
 		simplify = simplify && tile.Z < int(l.MaxSimplificationZoom)
 
-		vtf, err := f.VTileFeature(ctx, kmap, vmap, tile, simplify)
+		var simplifier Simplifier
+		if simplify {
+			simplifier = l.Simplifier
+			if simplifier == nil {
+				simplifier = DefaultSimplifier
+			}
+			if l.SimplifyTolerance > 0 {
+				simplifier = fixedTolerance{Simplifier: simplifier, tolerance: l.SimplifyTolerance}
+			}
+		}
+
+		vtf, err := f.vTileFeature(ctx, kmap, vmap, tile, simplifier, l.Quantization)
 		if err != nil {
 			switch err {
 			case context.Canceled:
@@ -111,7 +145,12 @@ func (l *Layer) VTileLayer(ctx context.Context, tile *tegola.Tile) (*vectorTile.
 }
 
 //Version is the version of tile spec this layer is from.
-func (*Layer) Version() int { return 2 }
+func (l *Layer) Version() int {
+	if l.SpecVersion == 0 {
+		return 2
+	}
+	return int(l.SpecVersion)
+}
 
 // Extent defaults to 4096
 func (l *Layer) Extent() int {
@@ -1,9 +1,13 @@
 package mvt
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"testing"
+	"time"
 
 	"context"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/basic"
 	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/golang/protobuf/proto"
 )
 
 func newTileLayer(name string, keys []string, values []*vectorTile.Tile_Value, features []*vectorTile.Tile_Feature) *vectorTile.Tile_Layer {
@@ -247,3 +252,346 @@ func TestLayer(t *testing.T) {
 		},
 	).Run(fn)
 }
+
+func TestLayerTagCoercion(t *testing.T) {
+	tile := tegola.NewTile(0, 0, 0)
+
+	pt, err := tile.FromPixel(tegola.WebMercator, [2]float64{1, 1})
+	if err != nil {
+		t.Fatalf("error trying to convert pixel to WebMercator: %v", err)
+	}
+	geo := basic.Point(pt)
+
+	ts := time.Date(2018, time.March, 2, 15, 4, 5, 0, time.UTC)
+
+	str := func(s string) *vectorTile.Tile_Value { return &vectorTile.Tile_Value{StringValue: &s} }
+	i64 := func(i int64) *vectorTile.Tile_Value { return &vectorTile.Tile_Value{IntValue: &i} }
+	u64 := func(i uint64) *vectorTile.Tile_Value { return &vectorTile.Tile_Value{UintValue: &i} }
+	dbl := func(f float64) *vectorTile.Tile_Value { return &vectorTile.Tile_Value{DoubleValue: &f} }
+	bl := func(b bool) *vectorTile.Tile_Value { return &vectorTile.Tile_Value{BoolValue: &b} }
+
+	type tcase struct {
+		value    interface{}
+		expected *vectorTile.Tile_Value // nil if the tag is expected to be dropped
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		l := Layer{Name: "coercion"}
+		l.AddFeatures(Feature{
+			Geometry: &geo,
+			Tags: map[string]interface{}{
+				"tag": tc.value,
+			},
+		})
+
+		vt, err := l.VTileLayer(context.Background(), tile)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if tc.expected == nil {
+			if len(vt.Values) != 0 {
+				t.Errorf("values, expected none got %v", vt.Values)
+			}
+			return
+		}
+
+		if len(vt.Values) != 1 {
+			t.Fatalf("values length, expected 1 got %v", len(vt.Values))
+		}
+
+		if !reflect.DeepEqual(vt.Values[0], tc.expected) {
+			t.Errorf("value, expected %v got %v", tc.expected, vt.Values[0])
+		}
+	}
+
+	tests := map[string]tcase{
+		"string": {
+			value:    "foo",
+			expected: str("foo"),
+		},
+		"int": {
+			value:    int(-42),
+			expected: i64(-42),
+		},
+		"int64": {
+			value:    int64(42),
+			expected: i64(42),
+		},
+		"uint": {
+			value:    uint(42),
+			expected: u64(42),
+		},
+		"float64": {
+			value:    float64(1.5),
+			expected: dbl(1.5),
+		},
+		"bool": {
+			value:    true,
+			expected: bl(true),
+		},
+		"time": {
+			value:    ts,
+			expected: str("2018-03-02T15:04:05Z"),
+		},
+		"time pointer": {
+			value:    &ts,
+			expected: str("2018-03-02T15:04:05Z"),
+		},
+		"bytes": {
+			value:    []byte("foo"),
+			expected: str("Zm9v"),
+		},
+		"unsupported": {
+			value: struct{ a int }{a: 1},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestLayerDeterministicTags(t *testing.T) {
+	tile := tegola.NewTile(0, 0, 0)
+
+	pt, err := tile.FromPixel(tegola.WebMercator, [2]float64{1, 1})
+	if err != nil {
+		t.Fatalf("error trying to convert pixel to WebMercator: %v", err)
+	}
+	geo := basic.Point(pt)
+
+	encode := func() []byte {
+		// a new map each time so the tags are iterated in a different order
+		tags := map[string]interface{}{}
+		for i := 0; i < 20; i++ {
+			tags[fmt.Sprintf("key%02d", i)] = i
+		}
+
+		l := Layer{Name: "tags"}
+		l.AddFeatures(Feature{
+			Geometry: &geo,
+			Tags:     tags,
+		})
+
+		vt, err := l.VTileLayer(context.Background(), tile)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if !sort.StringsAreSorted(vt.Keys) {
+			t.Errorf("keys, expected sorted got %v", vt.Keys)
+		}
+
+		b, err := proto.Marshal(vt)
+		if err != nil {
+			t.Fatalf("unexpected err marshalling: %v", err)
+		}
+		return b
+	}
+
+	expected := encode()
+	for i := 0; i < 10; i++ {
+		if b := encode(); !bytes.Equal(b, expected) {
+			t.Fatalf("[%v] encoded layer, expected %v got %v", i, expected, b)
+		}
+	}
+}
+
+func TestLayerQuantization(t *testing.T) {
+	tile := tegola.NewTile(0, 0, 0)
+
+	toWebMercator := func(x, y float64) [2]float64 {
+		pt, err := tile.FromPixel(tegola.WebMercator, [2]float64{x, y})
+		if err != nil {
+			t.Fatalf("error trying to convert pixel to WebMercator: %v", err)
+		}
+		return pt
+	}
+
+	// the polygons share the edge at x = 100. the edge's coordinates differ by a fraction of a
+	// millimeter, as they would when read from sources with different precisions
+	const offset = 0.0001
+	left := toWebMercator(50, 50)
+	edgeTop, edgeBottom := toWebMercator(100, 50), toWebMercator(100, 150)
+	right := toWebMercator(150, 150)
+
+	west := basic.Polygon{{
+		{left[0], left[1]},
+		{edgeTop[0] - offset, edgeTop[1]},
+		{edgeBottom[0] - offset, edgeBottom[1]},
+		{left[0], edgeBottom[1]},
+	}}
+	east := basic.Polygon{{
+		{edgeTop[0] + offset, edgeTop[1]},
+		{right[0], edgeTop[1]},
+		{right[0], right[1]},
+		{edgeBottom[0] + offset, edgeBottom[1]},
+	}}
+
+	// vertexXs decodes the x values of the feature's vertices
+	vertexXs := func(f *vectorTile.Tile_Feature) (xs []int64) {
+		zigzag := func(v uint32) int64 { return int64(int32(v>>1) ^ -int32(v&1)) }
+
+		var x int64
+		for i := 0; i < len(f.Geometry); {
+			cmd, count := f.Geometry[i]&0x7, int(f.Geometry[i]>>3)
+			i++
+			if cmd == cmdClosePath {
+				continue
+			}
+			for j := 0; j < count; j++ {
+				x += zigzag(f.Geometry[i])
+				xs = append(xs, x)
+				i += 2
+			}
+		}
+		return xs
+	}
+
+	type tcase struct {
+		quantization Quantization
+		// the x of the shared edge in each polygon
+		expectedWest int64
+		expectedEast int64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		l := Layer{
+			Name:         "quantization",
+			DontSimplify: true,
+			Quantization: tc.quantization,
+		}
+		l.AddFeatures(
+			Feature{Geometry: west},
+			Feature{Geometry: east},
+		)
+
+		vt, err := l.VTileLayer(context.Background(), tile)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if len(vt.Features) != 2 {
+			t.Fatalf("features, expected 2 got %v", len(vt.Features))
+		}
+
+		var westEdge, eastEdge int64 = math.MinInt64, math.MaxInt64
+		for _, x := range vertexXs(vt.Features[0]) {
+			if x > westEdge {
+				westEdge = x
+			}
+		}
+		for _, x := range vertexXs(vt.Features[1]) {
+			if x < eastEdge {
+				eastEdge = x
+			}
+		}
+
+		if westEdge != tc.expectedWest || eastEdge != tc.expectedEast {
+			t.Errorf("shared edge, expected west %v east %v got west %v east %v", tc.expectedWest, tc.expectedEast, westEdge, eastEdge)
+		}
+	}
+
+	tests := map[string]tcase{
+		"truncate leaves a gap": {
+			quantization: QuantizeTruncate,
+			expectedWest: 99,
+			expectedEast: 100,
+		},
+		"nearest shares the edge": {
+			quantization: QuantizeNearest,
+			expectedWest: 100,
+			expectedEast: 100,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// recordingSimplifier records the tolerance of each Simplify call and returns the geometry unmodified
+type recordingSimplifier struct {
+	tolerances []float64
+}
+
+func (rs *recordingSimplifier) Simplify(g tegola.Geometry, tolerance float64) tegola.Geometry {
+	rs.tolerances = append(rs.tolerances, tolerance)
+	return g
+}
+
+func TestLayerSimplifier(t *testing.T) {
+	type tcase struct {
+		tile          *tegola.Tile
+		dontSimplify  bool
+		expectedCalls int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		fromPixel := func(x, y float64) basic.Point {
+			pt, err := tc.tile.FromPixel(tegola.WebMercator, [2]float64{x, y})
+			if err != nil {
+				t.Fatalf("error trying to convert %v,%v to WebMercator: %v", x, y, err)
+			}
+			return basic.Point(pt)
+		}
+
+		rs := &recordingSimplifier{}
+		l := Layer{
+			Name:         "simplifier",
+			DontSimplify: tc.dontSimplify,
+			Simplifier:   rs,
+		}
+		l.AddFeatures(
+			Feature{
+				Geometry: &basic.Line{fromPixel(1, 1), fromPixel(10, 10), fromPixel(20, 10)},
+			},
+			Feature{
+				Geometry: &basic.Polygon{
+					{fromPixel(3, 6), fromPixel(8, 12), fromPixel(20, 34)},
+				},
+			},
+		)
+
+		if _, err := l.VTileLayer(context.Background(), tc.tile); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if len(rs.tolerances) != tc.expectedCalls {
+			t.Fatalf("simplify calls, expected %v got %v", tc.expectedCalls, len(rs.tolerances))
+		}
+
+		for i, tol := range rs.tolerances {
+			if tol != tc.tile.ZEpislon() {
+				t.Errorf("call (%v) tolerance, expected %v got %v", i, tc.tile.ZEpislon(), tol)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"simplify": {
+			tile:          tegola.NewTile(2, 1, 1),
+			expectedCalls: 2,
+		},
+		"dont simplify": {
+			tile:         tegola.NewTile(2, 1, 1),
+			dontSimplify: true,
+		},
+		"above max simplification zoom": {
+			tile: tegola.NewTile(tegola.MaxZ, 1, 1),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package mvt
+
+import "github.com/go-spatial/tegola"
+
+// Simplifier reduces the number of points in a geometry. Geometries are provided
+// in tile pixel coordinates and the tolerance is in pixels.
+type Simplifier interface {
+	Simplify(g tegola.Geometry, tolerance float64) tegola.Geometry
+}
+
+// DouglasPeucker simplifies geometries using the Douglas-Peucker algorithm.
+type DouglasPeucker struct{}
+
+// Simplify implements the Simplifier interface.
+func (DouglasPeucker) Simplify(g tegola.Geometry, tolerance float64) tegola.Geometry {
+	return SimplifyGeometry(g, tolerance, true)
+}
+
+// DefaultSimplifier is used by layers which have not set a Simplifier.
+var DefaultSimplifier Simplifier = DouglasPeucker{}
+
+// fixedTolerance simplifies geometries with its tolerance in place of the tolerance of the tile's zoom.
+type fixedTolerance struct {
+	Simplifier
+	tolerance float64
+}
+
+// Simplify implements the Simplifier interface.
+func (s fixedTolerance) Simplify(g tegola.Geometry, tolerance float64) tegola.Geometry {
+	return s.Simplifier.Simplify(g, s.tolerance)
+}
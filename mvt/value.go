@@ -0,0 +1,72 @@
+package mvt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+// coerceTagValue maps a Go value onto one of the value types supported by the
+// vector tile spec (string, float, double, int, uint, sint, bool). The returned
+// bool is false when the value can not be represented and should be dropped.
+//
+// time.Time values are encoded as RFC3339 strings and []byte values are base64
+// encoded. nil values are passed through as they are skipped during encoding.
+func coerceTagValue(v interface{}) (interface{}, bool) {
+	switch t := v.(type) {
+	case nil:
+		return nil, true
+
+	case string, bool, int8, int16, int32, int64, uint8, uint16, uint32, uint64, float32, float64:
+		return t, true
+
+	case int:
+		return int64(t), true
+
+	case uint:
+		return uint64(t), true
+
+	case time.Time:
+		return t.Format(time.RFC3339), true
+
+	case *time.Time:
+		if t == nil {
+			return nil, true
+		}
+		return t.Format(time.RFC3339), true
+
+	case []byte:
+		return base64.StdEncoding.EncodeToString(t), true
+
+	case fmt.Stringer:
+		return t.String(), true
+
+	default:
+		return nil, false
+	}
+}
+
+// coerceFeatureTags returns a copy of the provided features with their tag values
+// coerced to vector tile value types. Tags which can not be coerced are dropped
+// and a warning is logged. The provided features are not modified.
+func coerceFeatureTags(features []Feature) []Feature {
+	coerced := make([]Feature, len(features))
+	for i, f := range features {
+		if f.Tags != nil {
+			tags := make(map[string]interface{}, len(f.Tags))
+			for k, v := range f.Tags {
+				cv, ok := coerceTagValue(v)
+				if !ok {
+					log.Warnf("dropping tag (%v) with unsupported value type (%T)", k, v)
+					continue
+				}
+				tags[k] = cv
+			}
+			f.Tags = tags
+		}
+		coerced[i] = f
+	}
+	return coerced
+}
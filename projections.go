@@ -1,8 +1,9 @@
 package tegola
 
 const (
-	WebMercator = 3857
-	WGS84       = 4326
+	WebMercator   = 3857
+	WGS84         = 4326
+	WorldMercator = 3395
 )
 
 var (
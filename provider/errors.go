@@ -17,3 +17,12 @@ type ErrUnableToConvertFeatureID struct {
 func (e ErrUnableToConvertFeatureID) Error() string {
 	return fmt.Sprintf("unable to convert feature id %+v to uint64", e.val)
 }
+
+type ErrInvalidSQLFilter struct {
+	Filter string
+	Reason string
+}
+
+func (e ErrInvalidSQLFilter) Error() string {
+	return fmt.Sprintf("provider: invalid sql filter (%v): %v", e.Filter, e.Reason)
+}
@@ -0,0 +1,247 @@
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/maths/webmercator"
+)
+
+type featureCollection struct {
+	Type     string           `json:"type"`
+	Features []geojsonFeature `json:"features"`
+}
+
+type geojsonFeature struct {
+	ID         interface{}            `json:"id"`
+	Geometry   *geojsonGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geojsonGeometry struct {
+	Type        string             `json:"type"`
+	Coordinates json.RawMessage    `json:"coordinates"`
+	Geometries  []*geojsonGeometry `json:"geometries"`
+}
+
+var errInvalidPosition = errors.New("geojson: a position requires at least 2 values")
+
+//	position converts a GeoJSON position into a point. additional values (i.e. altitude) are dropped
+func position(pos []float64) ([2]float64, error) {
+	if len(pos) < 2 {
+		return [2]float64{}, errInvalidPosition
+	}
+	return [2]float64{pos[0], pos[1]}, nil
+}
+
+func positions(poss [][]float64) ([][2]float64, error) {
+	pts := make([][2]float64, 0, len(poss))
+	for i := range poss {
+		pt, err := position(poss[i])
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+//	ring converts a GeoJSON linear ring into a polygon ring. GeoJSON rings are closed
+//	while geom rings are implicitly closed so the last position is dropped.
+func ring(poss [][]float64) ([][2]float64, error) {
+	pts, err := positions(poss)
+	if err != nil {
+		return nil, err
+	}
+	if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	return pts, nil
+}
+
+func polygon(rings [][][]float64) (geom.Polygon, error) {
+	ply := make(geom.Polygon, 0, len(rings))
+	for i := range rings {
+		r, err := ring(rings[i])
+		if err != nil {
+			return nil, err
+		}
+		ply = append(ply, r)
+	}
+	return ply, nil
+}
+
+//	decodeGeometry converts a GeoJSON geometry object into a geom.Geometry.
+//	a nil geometry is returned for a null geometry
+func decodeGeometry(g *geojsonGeometry) (geom.Geometry, error) {
+	if g == nil {
+		return nil, nil
+	}
+
+	switch g.Type {
+	case "Point":
+		var pos []float64
+		if err := json.Unmarshal(g.Coordinates, &pos); err != nil {
+			return nil, err
+		}
+		pt, err := position(pos)
+		return geom.Point(pt), err
+
+	case "MultiPoint":
+		var poss [][]float64
+		if err := json.Unmarshal(g.Coordinates, &poss); err != nil {
+			return nil, err
+		}
+		pts, err := positions(poss)
+		return geom.MultiPoint(pts), err
+
+	case "LineString":
+		var poss [][]float64
+		if err := json.Unmarshal(g.Coordinates, &poss); err != nil {
+			return nil, err
+		}
+		pts, err := positions(poss)
+		return geom.LineString(pts), err
+
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &lines); err != nil {
+			return nil, err
+		}
+		mln := make(geom.MultiLineString, 0, len(lines))
+		for i := range lines {
+			pts, err := positions(lines[i])
+			if err != nil {
+				return nil, err
+			}
+			mln = append(mln, pts)
+		}
+		return mln, nil
+
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+			return nil, err
+		}
+		return polygon(rings)
+
+	case "MultiPolygon":
+		var plys [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &plys); err != nil {
+			return nil, err
+		}
+		mply := make(geom.MultiPolygon, 0, len(plys))
+		for i := range plys {
+			ply, err := polygon(plys[i])
+			if err != nil {
+				return nil, err
+			}
+			mply = append(mply, ply)
+		}
+		return mply, nil
+
+	case "GeometryCollection":
+		col := make(geom.Collection, 0, len(g.Geometries))
+		for i := range g.Geometries {
+			geo, err := decodeGeometry(g.Geometries[i])
+			if err != nil {
+				return nil, err
+			}
+			if geo == nil {
+				continue
+			}
+			col = append(col, geo)
+		}
+		return col, nil
+
+	default:
+		return nil, ErrUnsupportedGeometryType{g.Type}
+	}
+}
+
+//	toWebMercator reprojects a WGS84 geometry to WebMercator
+func toWebMercator(g geom.Geometry) geom.Geometry {
+	pt := func(p [2]float64) [2]float64 {
+		return [2]float64{webmercator.PLonToX(p[0]), webmercator.PLatToY(p[1])}
+	}
+	pts := func(ps [][2]float64) [][2]float64 {
+		out := make([][2]float64, len(ps))
+		for i := range ps {
+			out[i] = pt(ps[i])
+		}
+		return out
+	}
+	lines := func(ls [][][2]float64) [][][2]float64 {
+		out := make([][][2]float64, len(ls))
+		for i := range ls {
+			out[i] = pts(ls[i])
+		}
+		return out
+	}
+
+	switch geo := g.(type) {
+	case geom.Point:
+		return geom.Point(pt(geo))
+	case geom.MultiPoint:
+		return geom.MultiPoint(pts(geo))
+	case geom.LineString:
+		return geom.LineString(pts(geo))
+	case geom.MultiLineString:
+		return geom.MultiLineString(lines(geo))
+	case geom.Polygon:
+		return geom.Polygon(lines(geo))
+	case geom.MultiPolygon:
+		mply := make(geom.MultiPolygon, len(geo))
+		for i := range geo {
+			mply[i] = lines(geo[i])
+		}
+		return mply
+	case geom.Collection:
+		col := make(geom.Collection, len(geo))
+		for i := range geo {
+			col[i] = toWebMercator(geo[i])
+		}
+		return col
+	default:
+		return g
+	}
+}
+
+//	boundingBox computes the bounding box of the geometry
+func boundingBox(g geom.Geometry) geom.BoundingBox {
+	var points [][2]float64
+
+	var collect func(g geom.Geometry)
+	collect = func(g geom.Geometry) {
+		switch geo := g.(type) {
+		case geom.Point:
+			points = append(points, geo)
+		case geom.MultiPoint:
+			points = append(points, geo...)
+		case geom.LineString:
+			points = append(points, geo...)
+		case geom.MultiLineString:
+			for i := range geo {
+				points = append(points, geo[i]...)
+			}
+		case geom.Polygon:
+			for i := range geo {
+				points = append(points, geo[i]...)
+			}
+		case geom.MultiPolygon:
+			for i := range geo {
+				for j := range geo[i] {
+					points = append(points, geo[i][j]...)
+				}
+			}
+		case geom.Collection:
+			for i := range geo {
+				collect(geo[i])
+			}
+		}
+	}
+	collect(g)
+
+	return geom.NewBBox(points...)
+}
@@ -0,0 +1,34 @@
+package geojson
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingLayerName = errors.New("geojson: layer is missing 'name'")
+)
+
+type ErrInvalidFilePath struct {
+	FilePath string
+}
+
+func (e ErrInvalidFilePath) Error() string {
+	return fmt.Sprintf("geojson: invalid filepath: %v", e.FilePath)
+}
+
+type ErrLayerNotFound struct {
+	LayerName string
+}
+
+func (e ErrLayerNotFound) Error() string {
+	return fmt.Sprintf("geojson: layer (%v) not found", e.LayerName)
+}
+
+type ErrUnsupportedGeometryType struct {
+	Type string
+}
+
+func (e ErrUnsupportedGeometryType) Error() string {
+	return fmt.Sprintf("geojson: unsupported geometry type (%v)", e.Type)
+}
@@ -0,0 +1,239 @@
+//	Package geojson implements a provider which serves features from static GeoJSON FeatureCollection files.
+//	The FeatureCollections are loaded into memory when the provider is initialized which makes this
+//	provider suitable for testing and small overlays.
+package geojson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/webmercator"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/util/dict"
+)
+
+const Name = "geojson"
+
+//	config keys
+const (
+	ConfigKeyLayers    = "layers"
+	ConfigKeyLayerName = "name"
+	ConfigKeyFilePath  = "filepath"
+)
+
+func init() {
+	provider.Register(Name, NewTileProvider, nil)
+}
+
+//	NewTileProvider loads the FeatureCollection of each configured layer into memory
+func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
+	layers, ok := config[ConfigKeyLayers].([]map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected %v to be a []map[string]interface{}", ConfigKeyLayers)
+	}
+
+	p := Provider{
+		layers: make(map[string]Layer, len(layers)),
+	}
+
+	for i, v := range layers {
+		layerConf := dict.M(v)
+
+		layerName, err := layerConf.String(ConfigKeyLayerName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) we got the following error trying to get the layer's name field: %v", i, err)
+		}
+		if layerName == "" {
+			return nil, ErrMissingLayerName
+		}
+
+		if _, ok := p.layers[layerName]; ok {
+			return nil, fmt.Errorf("layer name (%v) is duplicated", layerName)
+		}
+
+		filepath, err := layerConf.String(ConfigKeyFilePath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+		if filepath == "" {
+			return nil, ErrInvalidFilePath{filepath}
+		}
+
+		layer, err := loadLayer(layerName, filepath)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		p.layers[layerName] = layer
+	}
+
+	return &p, nil
+}
+
+//	loadLayer reads the FeatureCollection at filepath and reprojects the features to WebMercator
+func loadLayer(name, filepath string) (Layer, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return Layer{}, err
+	}
+	defer f.Close()
+
+	var fc featureCollection
+	if err = json.NewDecoder(f).Decode(&fc); err != nil {
+		return Layer{}, fmt.Errorf("error decoding %v: %v", filepath, err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return Layer{}, fmt.Errorf("expected type FeatureCollection got %v", fc.Type)
+	}
+
+	layer := Layer{
+		name:     name,
+		features: make([]feature, 0, len(fc.Features)),
+	}
+
+	for i, f := range fc.Features {
+		geo, err := decodeGeometry(f.Geometry)
+		if err != nil {
+			return Layer{}, fmt.Errorf("feature (%v): %v", i, err)
+		}
+		//	features with a null geometry can't be placed in a tile
+		if geo == nil {
+			continue
+		}
+
+		//	GeoJSON coordinates are always WGS84
+		geo = toWebMercator(geo)
+
+		id, err := featureID(f.ID, i)
+		if err != nil {
+			return Layer{}, fmt.Errorf("feature (%v): %v", i, err)
+		}
+
+		layer.features = append(layer.features, feature{
+			id:       id,
+			geometry: geo,
+			bbox:     boundingBox(geo),
+			tags:     properties(f.Properties),
+		})
+
+		if layer.geomType == nil {
+			layer.geomType = geo
+		}
+	}
+
+	return layer, nil
+}
+
+//	featureID converts the GeoJSON id member into a feature id. features without an id are numbered
+//	by their (1 based) position in the FeatureCollection
+func featureID(v interface{}, idx int) (uint64, error) {
+	if v == nil {
+		return uint64(idx + 1), nil
+	}
+
+	return provider.ConvertFeatureID(v)
+}
+
+//	properties converts the GeoJSON properties into feature tags. values which can't be encoded
+//	as an MVT value (objects and arrays) are encoded as JSON strings
+func properties(props map[string]interface{}) map[string]interface{} {
+	tags := make(map[string]interface{}, len(props))
+
+	for k, v := range props {
+		switch val := v.(type) {
+		case nil:
+			continue
+		case string, float64, bool:
+			tags[k] = val
+		default:
+			b, err := json.Marshal(val)
+			if err != nil {
+				log.Warnf("unable to encode property (%v) value (%v): %v", k, val, err)
+				continue
+			}
+			tags[k] = string(b)
+		}
+	}
+
+	return tags
+}
+
+//	Provider serves features from in memory GeoJSON FeatureCollections
+type Provider struct {
+	//	map of layer name and corresponding layer
+	layers map[string]Layer
+}
+
+func (p *Provider) Layers() ([]provider.LayerInfo, error) {
+	ls := make([]provider.LayerInfo, 0, len(p.layers))
+	for _, l := range p.layers {
+		ls = append(ls, l)
+	}
+
+	return ls, nil
+}
+
+func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	l, ok := p.layers[layer]
+	if !ok {
+		return ErrLayerNotFound{layer}
+	}
+
+	//	read the tile extent
+	bufferedExtent, tileSRID := tile.BufferedExtent()
+
+	tileBBox := geom.NewBBox(bufferedExtent[0], bufferedExtent[1])
+
+	//	the features are stored in WebMercator. convert the tile extent if necessary
+	switch tileSRID {
+	case tegola.WebMercator:
+	case tegola.WGS84:
+		tileBBox = geom.NewBBox(
+			[2]float64{webmercator.PLonToX(bufferedExtent[0][0]), webmercator.PLatToY(bufferedExtent[0][1])},
+			[2]float64{webmercator.PLonToX(bufferedExtent[1][0]), webmercator.PLatToY(bufferedExtent[1][1])},
+		)
+	default:
+		return fmt.Errorf("unsupported tile SRID (%v)", tileSRID)
+	}
+
+	for i := range l.features {
+		//	check if the context cancelled or timed out
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !intersects(tileBBox, l.features[i].bbox) {
+			continue
+		}
+
+		//	copy the tags so callers can't modify the source feature
+		tags := make(map[string]interface{}, len(l.features[i].tags))
+		for k, v := range l.features[i].tags {
+			tags[k] = v
+		}
+
+		f := provider.Feature{
+			ID:       l.features[i].id,
+			Geometry: l.features[i].geometry,
+			SRID:     tegola.WebMercator,
+			Tags:     tags,
+		}
+
+		if err := fn(&f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//	intersects reports whether the two bounding boxes overlap
+func intersects(a, b geom.BoundingBox) bool {
+	return a.MinX() <= b.MaxX() && a.MaxX() >= b.MinX() &&
+		a.MinY() <= b.MaxY() && a.MaxY() >= b.MinY()
+}
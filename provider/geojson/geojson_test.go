@@ -0,0 +1,141 @@
+package geojson_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/geojson"
+)
+
+const testFilePath = "testdata/points_polygons.geojson"
+
+func TestNewTileProvider(t *testing.T) {
+	type tcase struct {
+		config      map[string]interface{}
+		expectedErr bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		p, err := geojson.NewTileProvider(tc.config)
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("expected an error got nil")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		lys, err := p.Layers()
+		if err != nil {
+			t.Fatalf("unable to fetch provider layers: %v", err)
+		}
+
+		if len(lys) != 1 {
+			t.Fatalf("layer count, expected 1 got %v", len(lys))
+		}
+
+		if lys[0].SRID() != tegola.WebMercator {
+			t.Errorf("layer srid, expected %v got %v", tegola.WebMercator, lys[0].SRID())
+		}
+	}
+
+	tests := map[string]tcase{
+		"valid": {
+			config: map[string]interface{}{
+				"layers": []map[string]interface{}{
+					{"name": "places", "filepath": testFilePath},
+				},
+			},
+		},
+		"missing name": {
+			config: map[string]interface{}{
+				"layers": []map[string]interface{}{
+					{"filepath": testFilePath},
+				},
+			},
+			expectedErr: true,
+		},
+		"missing file": {
+			config: map[string]interface{}{
+				"layers": []map[string]interface{}{
+					{"name": "places", "filepath": "testdata/missing.geojson"},
+				},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestTileFeatures(t *testing.T) {
+	type tcase struct {
+		tile        *slippy.Tile
+		expectedIDs []uint64
+	}
+
+	p, err := geojson.NewTileProvider(map[string]interface{}{
+		"layers": []map[string]interface{}{
+			{"name": "places", "filepath": testFilePath},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating provider: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		var ids []uint64
+		err := p.TileFeatures(context.Background(), "places", tc.tile, func(f *provider.Feature) error {
+			if f.SRID != tegola.WebMercator {
+				t.Errorf("feature srid, expected %v got %v", tegola.WebMercator, f.SRID)
+			}
+			ids = append(ids, f.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if !reflect.DeepEqual(ids, tc.expectedIDs) {
+			t.Errorf("feature ids, expected %v got %v", tc.expectedIDs, ids)
+		}
+	}
+
+	tests := map[string]tcase{
+		"world": {
+			tile:        slippy.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expectedIDs: []uint64{1, 2, 3},
+		},
+		"san francisco": {
+			tile:        slippy.NewTile(16, 10482, 25331, 64, tegola.WebMercator),
+			expectedIDs: []uint64{1, 3},
+		},
+		"berlin": {
+			tile:        slippy.NewTile(12, 2200, 1343, 64, tegola.WebMercator),
+			expectedIDs: []uint64{2},
+		},
+		"empty ocean": {
+			tile: slippy.NewTile(8, 10, 120, 64, tegola.WebMercator),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
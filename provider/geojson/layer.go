@@ -0,0 +1,26 @@
+package geojson
+
+import (
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
+)
+
+type Layer struct {
+	name string
+	//	the geometry of the first feature. used to report the layer's geometry type
+	geomType geom.Geometry
+	//	the layer's features, reprojected to WebMercator
+	features []feature
+}
+
+func (l Layer) Name() string            { return l.name }
+func (l Layer) GeomType() geom.Geometry { return l.geomType }
+func (l Layer) SRID() uint64            { return tegola.WebMercator }
+
+//	feature is an in memory feature with its bounding box precomputed for filtering
+type feature struct {
+	id       uint64
+	geometry geom.Geometry
+	bbox     geom.BoundingBox
+	tags     map[string]interface{}
+}
@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"math"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/encoding/wkb"
+	"github.com/go-spatial/tegola/internal/log"
 )
 
 type envelopeType uint8
@@ -108,15 +112,31 @@ type BinaryHeader struct {
 	srsid      int32
 	envelope   []float64
 	headerSize int // total bytes in header
+	// the blob has no header and is raw WKB
+	headerless bool
 }
 
 // NewBinaryHeader decodes the data into the BinaryHeader
 func NewBinaryHeader(data []byte) (*BinaryHeader, error) {
+	return decodeBinaryHeader(data, true)
+}
+
+// NewBinaryHeaderFast decodes the data into the BinaryHeader without decoding the envelope. The Size
+// of the header is computed from the envelope type, so the geometry following the header is found as
+// it is with NewBinaryHeader, but the header has no Envelope. This saves decoding the envelope of every
+// row when the envelope isn't used, i.e. when a spatial index limits the rows to a tile.
+func NewBinaryHeaderFast(data []byte) (*BinaryHeader, error) {
+	return decodeBinaryHeader(data, false)
+}
+
+// decodeBinaryHeader decodes the data into the BinaryHeader. The envelope is only decoded if envelope
+// is true.
+func decodeBinaryHeader(data []byte, envelope bool) (*BinaryHeader, error) {
 	if len(data) < 8 {
 		return nil, errors.New("not enough bytes to decode header")
 	}
 
-	var bh BinaryHeader
+	bh := BinaryHeader{headerSize: 8}
 	bh.magic[0] = data[0]
 	bh.magic[1] = data[1]
 	bh.version = data[2]
@@ -124,6 +144,10 @@ func NewBinaryHeader(data []byte) (*BinaryHeader, error) {
 	en := bh.flags.Endian()
 	bh.srsid = int32(en.Uint32(data[4 : 4+4]))
 
+	if bh.magic[0] != Magic[0] || bh.magic[1] != Magic[1] {
+		return &bh, errors.New("invalid magic number")
+	}
+
 	bytes := data[8:]
 	et := bh.flags.Envelope()
 	if et == EnvelopeTypeInvalid {
@@ -135,7 +159,16 @@ func NewBinaryHeader(data []byte) (*BinaryHeader, error) {
 	num := et.NumberOfElements()
 	// there are 8 bytes per float64 value and we need num of them.
 	if len(bytes) < (num * 8) {
-		return nil, errors.New("not enough bytes to decode header")
+		return &bh, ErrShortEnvelope{
+			EnvelopeType: et.String(),
+			Expected:     num * 8,
+			Got:          len(bytes),
+		}
+	}
+
+	bh.headerSize += num * 8
+	if !envelope {
+		return &bh, nil
 	}
 
 	bh.envelope = make([]float64, 0, num)
@@ -143,13 +176,48 @@ func NewBinaryHeader(data []byte) (*BinaryHeader, error) {
 		bits := en.Uint64(bytes[i*8 : (i*8)+8])
 		bh.envelope = append(bh.envelope, math.Float64frombits(bits))
 	}
-	if bh.magic[0] != Magic[0] || bh.magic[1] != Magic[1] {
-		return &bh, errors.New("invalid magic number")
-	}
 	return &bh, nil
 
 }
 
+// IsRawWKB reports if the geometry blob is raw WKB without the gpkg binary header, as older or hand built
+// SQLite spatial tables store their geometries. Raw WKB starts with its byte order, 0x00 or 0x01, rather
+// than the header's magic number.
+func IsRawWKB(blob []byte) bool {
+	return len(blob) > 0 && (blob[0] == 0x00 || blob[0] == 0x01)
+}
+
+// newGeometryHeader decodes the BinaryHeader of a geometry blob. For raw WKB (see IsRawWKB) an empty
+// header with a Size of 0 and an undefined SRS is returned. Some writers set an envelope type in the
+// flags but leave the envelope out. When the blob is too short for the envelope and WKB follows the
+// fixed part of the header, the header is decoded as having no envelope.
+func newGeometryHeader(blob []byte) (*BinaryHeader, error) {
+	return geometryHeader(blob, true)
+}
+
+// newGeometryHeaderFast decodes the BinaryHeader of a geometry blob as newGeometryHeader does without
+// decoding the envelope (see NewBinaryHeaderFast).
+func newGeometryHeaderFast(blob []byte) (*BinaryHeader, error) {
+	return geometryHeader(blob, false)
+}
+
+// geometryHeader decodes the BinaryHeader of a geometry blob. The envelope is only decoded if envelope
+// is true.
+func geometryHeader(blob []byte, envelope bool) (*BinaryHeader, error) {
+	if IsRawWKB(blob) {
+		return &BinaryHeader{headerless: true}, nil
+	}
+
+	h, err := decodeBinaryHeader(blob, envelope)
+	if _, ok := err.(ErrShortEnvelope); ok && IsRawWKB(blob[8:]) {
+		log.Debugf("decoding geometry header without its missing envelope: %v", err)
+		h.flags &^= maskEnvelopeType
+		return h, nil
+	}
+
+	return h, err
+}
+
 // Magic is the magic number encode in the header. It should be 0x4750
 func (h *BinaryHeader) Magic() [2]byte {
 	if h == nil {
@@ -182,6 +250,15 @@ func (h *BinaryHeader) SRSId() int32 {
 	return h.srsid
 }
 
+// SRSDefined reports if the SRS id references a defined coordinate reference system. The gpkg spec
+// reserves srs_id -1 for undefined geographic and 0 for undefined Cartesian coordinate reference systems.
+func (h *BinaryHeader) SRSDefined() bool {
+	if h == nil {
+		return false
+	}
+	return h.srsid > 0
+}
+
 // Envelope is the bounding box of the feature, used for searching. If the EnvelopeType is EvelopeTypeNone, then there isn't a envelope encoded
 // and a search without an index will need to be preformed. This is to save space.
 func (h *BinaryHeader) Envelope() []float64 {
@@ -191,6 +268,42 @@ func (h *BinaryHeader) Envelope() []float64 {
 	return h.envelope
 }
 
+// EnvelopeRaw returns the X and Y bounds of the envelope in the order the gpkg spec encodes them
+// (minx, maxx, miny, maxy). The Z and M bounds of 3D and 4D envelopes are left out. ok is false if
+// there isn't an envelope encoded.
+func (h *BinaryHeader) EnvelopeRaw() (minx, maxx, miny, maxy float64, ok bool) {
+	if h == nil || len(h.envelope) < 4 {
+		return 0, 0, 0, 0, false
+	}
+	return h.envelope[0], h.envelope[1], h.envelope[2], h.envelope[3], true
+}
+
+// Extent returns the X and Y bounds of the envelope. ok is false if there isn't an envelope encoded or
+// its bounds are NaN, which is how the gpkg spec encodes the envelope of an empty geometry.
+func (h *BinaryHeader) Extent() (extent geom.BoundingBox, ok bool) {
+	minx, maxx, miny, maxy, ok := h.EnvelopeRaw()
+	if !ok {
+		return extent, false
+	}
+	for _, v := range []float64{minx, maxx, miny, maxy} {
+		if math.IsNaN(v) {
+			return extent, false
+		}
+	}
+	return geom.BoundingBox{{minx, miny}, {maxx, maxy}}, true
+}
+
+// Intersects reports whether the X and Y bounds of the envelope intersect the extent, including
+// their edges, so rows outside of a tile can be skipped without decoding their geometry. If there
+// isn't an envelope encoded the geometry may be anywhere, so true is returned.
+func (h *BinaryHeader) Intersects(extent geom.BoundingBox) bool {
+	minx, maxx, miny, maxy, ok := h.EnvelopeRaw()
+	if !ok {
+		return true
+	}
+	return extent.Intersects(geom.BoundingBox{{minx, miny}, {maxx, maxy}})
+}
+
 // IsGeometryEmpty tells us if the geometry should be considered empty.
 func (h *BinaryHeader) IsGeometryEmpty() bool {
 	if h == nil {
@@ -209,8 +322,19 @@ func (h *BinaryHeader) IsStandardGeometry() bool {
 
 // Size is the size of the header in bytes.
 func (h *BinaryHeader) Size() int {
-	if h == nil {
+	if h == nil || h.headerless {
 		return 0
 	}
-	return (len(h.envelope) * 8) + 8
+	return h.headerSize
+}
+
+// PeekGeometryType reads the type of the WKB geometry in a gpkg geometry blob without decoding the
+// geometry's coordinates. The returned type is one of the wkb geometry types (i.e. wkb.Point).
+func PeekGeometryType(blob []byte) (uint32, error) {
+	h, err := newGeometryHeader(blob)
+	if err != nil {
+		return 0, err
+	}
+
+	return wkb.DecodeType(blob[h.Size():])
 }
@@ -3,9 +3,13 @@ package gpkg
 import (
 	"encoding/binary"
 	"errors"
+	"math"
 	"reflect"
 	"strconv"
 	"testing"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/encoding/wkb"
 )
 
 func fmt8Bit(n byte) string {
@@ -262,7 +266,7 @@ func TestBinaryHeader(t *testing.T) {
 				0x2C, 0xC9, 0xBC, 0xE5, 0xD6, 0xF2, 0x42, 0x40, // MinY
 				0x20, 0xC2, 0x2E, 0x86, 0xB8, 0xF8, 0x42, 0x40, // MaxY
 			},
-			err: errors.New("not enough bytes to decode header"),
+			err: ErrShortEnvelope{EnvelopeType: "XYZM", Expected: 64, Got: 32},
 		},
 		"invalid envelope type": tcase{
 			bytes: []byte{
@@ -307,3 +311,493 @@ func TestBinaryHeader(t *testing.T) {
 		t.Run(name, func(t *testing.T) { fn(t, tc) })
 	}
 }
+
+func TestPeekGeometryType(t *testing.T) {
+	type tcase struct {
+		bytes    []byte
+		expected uint32
+		err      error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		typ, err := PeekGeometryType(tc.bytes)
+		if tc.err != nil {
+			if err == nil || err.Error() != tc.err.Error() {
+				t.Errorf("error, expected %v got %v", tc.err, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("error, expected nil got %v", err)
+		}
+
+		if typ != tc.expected {
+			t.Errorf("geometry type, expected %v got %v", tc.expected, typ)
+		}
+	}
+
+	// the geometry bodies are truncated after the type word. decoding the coordinates would fail
+	tests := map[string]tcase{
+		"little endian point with envelope": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x03,                   // Flags -- LittleEndian, XY
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0xE5, 0x6D, 0xFA, 0xB6, 0x67, 0xB6, 0x37, 0x40, // MinX
+				0xC1, 0xAB, 0xB0, 0xD0, 0xB9, 0xCB, 0x37, 0x40, // MaxX
+				0x2C, 0xC9, 0xBC, 0xE5, 0xD6, 0xF2, 0x42, 0x40, // MinY
+				0x20, 0xC2, 0x2E, 0x86, 0xB8, 0xF8, 0x42, 0x40, // MaxY
+				0x01,                   // WKB byte order -- LittleEndian
+				0x01, 0x00, 0x00, 0x00, // WKB type -- Point
+			},
+			expected: wkb.Point,
+		},
+		"big endian polygon": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x00,                   // Flags -- BigEndian, no envelope
+				0x00, 0x00, 0x10, 0xE6, // srs_id
+				0x00,                   // WKB byte order -- BigEndian
+				0x00, 0x00, 0x00, 0x03, // WKB type -- Polygon
+			},
+			expected: wkb.Polygon,
+		},
+		"iso polygon z": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x01,                   // Flags -- LittleEndian, no envelope
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x01,                   // WKB byte order -- LittleEndian
+				0x03, 0x00, 0x00, 0x80, // WKB type -- Polygon with the ISO Z flag
+			},
+			expected: wkb.Polygon,
+		},
+		"bad magic": {
+			bytes: []byte{
+				0x50, 0x47, // Magic number
+				0x00,                   // Version
+				0x01,                   // Flags -- LittleEndian, no envelope
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x01,
+				0x01, 0x00, 0x00, 0x00,
+			},
+			err: errors.New("invalid magic number"),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestBinaryHeaderSRSDefined(t *testing.T) {
+	type tcase struct {
+		srsid    int32
+		expected bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		data := []byte{
+			0x47, 0x50, // Magic number
+			0x00, // Version
+			0x01, // Flags -- LittleEndian, no envelope
+			0x00, 0x00, 0x00, 0x00,
+		}
+		binary.LittleEndian.PutUint32(data[4:], uint32(tc.srsid))
+
+		bh, err := NewBinaryHeader(data)
+		if err != nil {
+			t.Fatalf("error, expected nil got %v", err)
+		}
+
+		if bh.SRSId() != tc.srsid {
+			t.Errorf("SRS Id, expected %v got %v", tc.srsid, bh.SRSId())
+		}
+		if bh.SRSDefined() != tc.expected {
+			t.Errorf("SRS defined, expected %v got %v", tc.expected, bh.SRSDefined())
+		}
+	}
+
+	tests := map[string]tcase{
+		"undefined geographic": {
+			srsid:    -1,
+			expected: false,
+		},
+		"undefined cartesian": {
+			srsid:    0,
+			expected: false,
+		},
+		"wgs84": {
+			srsid:    4326,
+			expected: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestBinaryHeaderEnvelopeRaw(t *testing.T) {
+	type tcase struct {
+		bytes []byte
+		// nil if no envelope is expected
+		expected *[4]float64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		bh, err := NewBinaryHeader(tc.bytes)
+		if err != nil {
+			t.Fatalf("error, expected nil got %v", err)
+		}
+
+		minx, maxx, miny, maxy, ok := bh.EnvelopeRaw()
+		if ok != (tc.expected != nil) {
+			t.Fatalf("ok, expected %v got %v", tc.expected != nil, ok)
+		}
+		if !ok {
+			return
+		}
+
+		if got := [4]float64{minx, maxx, miny, maxy}; got != *tc.expected {
+			t.Errorf("envelope, expected %v got %v", *tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no envelope": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x01,                   // Flags -- LittleEndian, no envelope
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+			},
+		},
+		// the spec orders the bounds minx, maxx, miny, maxy
+		"XY": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x03,                   // Flags -- LittleEndian, XY
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0xE5, 0x6D, 0xFA, 0xB6, 0x67, 0xB6, 0x37, 0x40, // MinX
+				0xC1, 0xAB, 0xB0, 0xD0, 0xB9, 0xCB, 0x37, 0x40, // MaxX
+				0x2C, 0xC9, 0xBC, 0xE5, 0xD6, 0xF2, 0x42, 0x40, // MinY
+				0x20, 0xC2, 0x2E, 0x86, 0xB8, 0xF8, 0x42, 0x40, // MaxY
+			},
+			expected: &[4]float64{23.712520061626396, 23.79580406487708, 37.89718314855631, 37.94313123019333},
+		},
+		"XYZ": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x05,                   // Flags -- LittleEndian, XYZ
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, // MinX 1
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, // MaxX 2
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x40, // MinY 3
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x40, // MaxY 4
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x14, 0x40, // MinZ 5
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x40, // MaxZ 6
+			},
+			expected: &[4]float64{1, 2, 3, 4},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestBinaryHeaderExtent(t *testing.T) {
+	type tcase struct {
+		bytes    []byte
+		expected geom.BoundingBox
+		ok       bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		bh, err := NewBinaryHeader(tc.bytes)
+		if err != nil {
+			t.Fatalf("error, expected nil got %v", err)
+		}
+
+		extent, ok := bh.Extent()
+		if ok != tc.ok {
+			t.Fatalf("ok, expected %v got %v", tc.ok, ok)
+		}
+		if extent != tc.expected {
+			t.Errorf("extent, expected %v got %v", tc.expected, extent)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no envelope": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x01,                   // Flags -- LittleEndian, no envelope
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+			},
+		},
+		"XY": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x03,                   // Flags -- LittleEndian, XY
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, // MinX 1
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, // MaxX 2
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x40, // MinY 3
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x40, // MaxY 4
+			},
+			expected: geom.BoundingBox{{1, 3}, {2, 4}},
+			ok:       true,
+		},
+		// the spec encodes the envelope of an empty geometry as NaN
+		"NaN": {
+			bytes: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x13,                   // Flags -- LittleEndian, XY, empty
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x7F, // MinX NaN
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x7F, // MaxX NaN
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x7F, // MinY NaN
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x7F, // MaxY NaN
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestBinaryHeaderIntersects(t *testing.T) {
+	// the envelope's X bounds are 1 to 2 and its Y bounds are 3 to 4
+	withEnvelope := []byte{
+		0x47, 0x50, // Magic number
+		0x00,                   // Version
+		0x03,                   // Flags -- LittleEndian, XY
+		0xE6, 0x10, 0x00, 0x00, // srs_id
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, // MinX 1
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, // MaxX 2
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x40, // MinY 3
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x40, // MaxY 4
+	}
+	noEnvelope := []byte{
+		0x47, 0x50, // Magic number
+		0x00,                   // Version
+		0x01,                   // Flags -- LittleEndian, no envelope
+		0xE6, 0x10, 0x00, 0x00, // srs_id
+	}
+
+	type tcase struct {
+		bytes    []byte
+		extent   geom.BoundingBox
+		expected bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		bh, err := NewBinaryHeader(tc.bytes)
+		if err != nil {
+			t.Fatalf("error, expected nil got %v", err)
+		}
+
+		if got := bh.Intersects(tc.extent); got != tc.expected {
+			t.Errorf("intersects, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"inside": {
+			bytes:    withEnvelope,
+			extent:   geom.BoundingBox{{0, 0}, {10, 10}},
+			expected: true,
+		},
+		"overlapping": {
+			bytes:    withEnvelope,
+			extent:   geom.BoundingBox{{1.5, 3.5}, {10, 10}},
+			expected: true,
+		},
+		"touching": {
+			bytes:    withEnvelope,
+			extent:   geom.BoundingBox{{2, 4}, {10, 10}},
+			expected: true,
+		},
+		"outside": {
+			bytes:    withEnvelope,
+			extent:   geom.BoundingBox{{5, 5}, {10, 10}},
+			expected: false,
+		},
+		// without an envelope the geometry may be anywhere
+		"no envelope": {
+			bytes:    noEnvelope,
+			extent:   geom.BoundingBox{{5, 5}, {10, 10}},
+			expected: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// headerBlob returns a little endian geometry blob of the point with an envelope of the envelope type
+func headerBlob(t testing.TB, et envelopeType, pt geom.Point) []byte {
+	blob := []byte{
+		0x47, 0x50, // Magic number
+		0x00,                   // Version
+		0x01 | byte(et)<<1,     // Flags -- LittleEndian, envelope type
+		0xE6, 0x10, 0x00, 0x00, // srs_id
+	}
+	for i := 0; i < et.NumberOfElements(); i++ {
+		// the envelope values aren't checked, each is the point's X
+		v := make([]byte, 8)
+		binary.LittleEndian.PutUint64(v, math.Float64bits(pt[0]))
+		blob = append(blob, v...)
+	}
+
+	b, err := wkb.EncodeBytes(pt)
+	if err != nil {
+		t.Fatalf("err encoding wkb: %v", err)
+	}
+
+	return append(blob, b...)
+}
+
+func TestGeometryHeaderFast(t *testing.T) {
+	type tcase struct {
+		blob []byte
+		size int
+	}
+
+	pt := geom.Point{23.7, 37.9}
+
+	// the flags claim an XY envelope which the blob leaves out
+	missing := headerBlob(t, EnvelopeTypeNone, pt)
+	missing[3] = 0x01 | byte(EnvelopeTypeXY)<<1
+
+	rawWKB, err := wkb.EncodeBytes(pt)
+	if err != nil {
+		t.Fatalf("err encoding wkb: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		full, err := newGeometryHeader(tc.blob)
+		if err != nil {
+			t.Fatalf("full header error, expected nil got %v", err)
+		}
+		fast, err := newGeometryHeaderFast(tc.blob)
+		if err != nil {
+			t.Fatalf("fast header error, expected nil got %v", err)
+		}
+
+		// the geometry is found at the same offset without decoding the envelope
+		if fast.Size() != tc.size || full.Size() != tc.size {
+			t.Errorf("header size, expected %v got %v (full %v)", tc.size, fast.Size(), full.Size())
+		}
+		if fast.Envelope() != nil {
+			t.Errorf("envelope, expected nil got %v", fast.Envelope())
+		}
+		if fast.SRSId() != full.SRSId() {
+			t.Errorf("SRS Id, expected %v got %v", full.SRSId(), fast.SRSId())
+		}
+		if fast.EnvelopeType() != full.EnvelopeType() {
+			t.Errorf("envelope type, expected %v got %v", full.EnvelopeType(), fast.EnvelopeType())
+		}
+
+		geo, err := wkb.DecodeBytes(tc.blob[fast.Size():])
+		if err != nil {
+			t.Fatalf("err decoding geometry: %v", err)
+		}
+		if !reflect.DeepEqual(geo, pt) {
+			t.Errorf("geometry, expected %v got %v", pt, geo)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no envelope": {
+			blob: headerBlob(t, EnvelopeTypeNone, pt),
+			size: 8,
+		},
+		"XY": {
+			blob: headerBlob(t, EnvelopeTypeXY, pt),
+			size: 8 + 4*8,
+		},
+		"XYZ": {
+			blob: headerBlob(t, EnvelopeTypeXYZ, pt),
+			size: 8 + 6*8,
+		},
+		"XYM": {
+			blob: headerBlob(t, EnvelopeTypeXYM, pt),
+			size: 8 + 6*8,
+		},
+		"XYZM": {
+			blob: headerBlob(t, EnvelopeTypeXYZM, pt),
+			size: 8 + 8*8,
+		},
+		"missing envelope": {
+			blob: missing,
+			size: 8,
+		},
+		"raw wkb": {
+			blob: rawWKB,
+			size: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestNewBinaryHeaderFastShortEnvelope(t *testing.T) {
+	blob := headerBlob(t, EnvelopeTypeXYZM, geom.Point{1, 2})[:8+16]
+
+	_, expected := NewBinaryHeader(blob)
+	if _, err := NewBinaryHeaderFast(blob); !reflect.DeepEqual(err, expected) {
+		t.Errorf("error, expected %v got %v", expected, err)
+	}
+}
+
+func BenchmarkBinaryHeader(b *testing.B) {
+	blob := headerBlob(b, EnvelopeTypeXYZM, geom.Point{23.7, 37.9})
+
+	for name, decode := range map[string]func([]byte) (*BinaryHeader, error){
+		"full": NewBinaryHeader,
+		"fast": NewBinaryHeaderFast,
+	} {
+		decode := decode
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := decode(blob); err != nil {
+					b.Fatalf("err decoding header: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gpkg
+
+import (
+	"sync"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+//	defaultMaxCachedBounds is the number of feature bounds cached per layer
+const defaultMaxCachedBounds = 1 << 16
+
+//	boundsCache holds the bounds of a layer's features, keyed by feature id, for the geometries
+//	encoded without an envelope so they are only decoded once to compute their bounds. the cache
+//	holds up to max bounds, once full an arbitrary entry is evicted for each new one
+type boundsCache struct {
+	sync.RWMutex
+	bounds map[uint64]geom.BoundingBox
+	max    int
+}
+
+func newBoundsCache(max int) *boundsCache {
+	return &boundsCache{
+		bounds: map[uint64]geom.BoundingBox{},
+		max:    max,
+	}
+}
+
+func (c *boundsCache) get(id uint64) (geom.BoundingBox, bool) {
+	if c == nil {
+		return geom.BoundingBox{}, false
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	bbox, ok := c.bounds[id]
+	return bbox, ok
+}
+
+func (c *boundsCache) set(id uint64, bbox geom.BoundingBox) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.bounds[id]; !ok && len(c.bounds) >= c.max {
+		for k := range c.bounds {
+			delete(c.bounds, k)
+			break
+		}
+	}
+
+	c.bounds[id] = bbox
+}
+
+//	reset drops the cached bounds, i.e. when the provider's database is closed
+func (c *boundsCache) reset() {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.bounds = map[uint64]geom.BoundingBox{}
+}
+
+//	envelopeBounds returns the bounds of the header's envelope. false is returned if the header has no envelope
+func envelopeBounds(h *BinaryHeader) (geom.BoundingBox, bool) {
+	// the envelope is ordered minx, maxx, miny, maxy followed by the z and m ranges
+	env := h.Envelope()
+	if h.EnvelopeType() == EnvelopeTypeNone || len(env) < 4 {
+		return geom.BoundingBox{}, false
+	}
+
+	return geom.BoundingBox{{env[0], env[2]}, {env[1], env[3]}}, true
+}
@@ -0,0 +1,80 @@
+package gpkg
+
+import (
+	"testing"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+func TestBoundsCache(t *testing.T) {
+	type tcase struct {
+		max int
+		ids []uint64
+		// if the cache is reset after setting the ids
+		reset bool
+		// the number of cached bounds
+		expected int
+		// the id which is expected to be cached
+		expectedID uint64
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		c := newBoundsCache(tc.max)
+		for _, id := range tc.ids {
+			c.set(id, geom.BoundingBox{{float64(id), float64(id)}, {float64(id), float64(id)}})
+		}
+		if tc.reset {
+			c.reset()
+		}
+
+		if len(c.bounds) != tc.expected {
+			t.Errorf("cached bounds, expected %v got %v", tc.expected, len(c.bounds))
+		}
+		if tc.expected == 0 {
+			return
+		}
+
+		bbox, ok := c.get(tc.expectedID)
+		if !ok {
+			t.Fatalf("bounds of %v, expected to be cached", tc.expectedID)
+		}
+		if expected := (geom.BoundingBox{{float64(tc.expectedID), float64(tc.expectedID)}, {float64(tc.expectedID), float64(tc.expectedID)}}); bbox != expected {
+			t.Errorf("bounds of %v, expected %v got %v", tc.expectedID, expected, bbox)
+		}
+	}
+
+	tests := map[string]tcase{
+		"under max": {
+			max:        3,
+			ids:        []uint64{1, 2},
+			expected:   2,
+			expectedID: 2,
+		},
+		// the newest bounds are cached once full
+		"over max": {
+			max:        2,
+			ids:        []uint64{1, 2, 3, 4},
+			expected:   2,
+			expectedID: 4,
+		},
+		// updating an entry doesn't evict another once full
+		"same id": {
+			max:        2,
+			ids:        []uint64{1, 2, 2},
+			expected:   2,
+			expectedID: 1,
+		},
+		"reset": {
+			max:   2,
+			ids:   []uint64{1, 2},
+			reset: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
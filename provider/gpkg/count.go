@@ -0,0 +1,93 @@
+// +build cgo
+
+package gpkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
+)
+
+//	FeatureCount returns the number of features of the layer. when bbox is not nil only the features
+//	whose bounds intersect it, in the layer's SRID, are counted. counting all of the features reads
+//	the entire table so the count is cached the first time it's read
+func (p *Provider) FeatureCount(ctx context.Context, layer string, bbox *geom.BoundingBox) (uint64, error) {
+	pLayer, ok := p.layers[layer]
+	if !ok {
+		return 0, ErrLayerNotFound{layer}
+	}
+
+	if bbox != nil {
+		return countFeatures(ctx, p, pLayer, *bbox)
+	}
+
+	if pLayer.count == nil {
+		return countAllFeatures(ctx, p, pLayer)
+	}
+
+	pLayer.count.Lock()
+	defer pLayer.count.Unlock()
+
+	if pLayer.count.ok {
+		return pLayer.count.count, nil
+	}
+
+	count, err := countAllFeatures(ctx, p, pLayer)
+	if err != nil {
+		return 0, err
+	}
+
+	pLayer.count.count, pLayer.count.ok = count, true
+
+	return count, nil
+}
+
+//	countAllFeatures counts the rows of the layer's table, or custom SQL over the whole world, with a geometry
+func countAllFeatures(ctx context.Context, p *Provider, pLayer Layer) (uint64, error) {
+	var qtext string
+	if pLayer.tablename != "" {
+		qtext = fmt.Sprintf("SELECT count(*) FROM %v WHERE `%v` IS NOT NULL;", pLayer.tablename, pLayer.geomFieldname)
+	} else {
+		// TODO(arolek): this assumes WGS84. should be more flexible
+		customSQL := replaceTokens(pLayer.sql, 0, geom.BoundingBox{{180.0, 85.0511}, {-180.0, -85.0511}})
+		qtext = fmt.Sprintf("SELECT count(*) FROM (%v);", strings.TrimRight(strings.TrimSpace(customSQL), ";"))
+	}
+
+	var count uint64
+	if err := p.db.QueryRowContext(ctx, qtext).Scan(&count); err != nil {
+		log.Errorf("error during query: %v - %v", qtext, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+//	countFeatures counts the layer's features intersecting the extent. tables with a spatial index are
+//	counted with the index, other layers are counted as their features are read for a tile
+func countFeatures(ctx context.Context, p *Provider, pLayer Layer, extent geom.BoundingBox) (uint64, error) {
+	if pLayer.tablename != "" && pLayer.spatialIndex {
+		rtreeTablename := fmt.Sprintf("rtree_%v_%v", pLayer.tablename, pLayer.geomFieldname)
+		qtext := fmt.Sprintf("SELECT count(*) FROM %v l JOIN %v si ON l.`%v` = si.id WHERE l.`%v` IS NOT NULL AND !BBOX!;", pLayer.tablename, rtreeTablename, pLayer.idFieldname, pLayer.geomFieldname)
+		qtext = replaceTokens(qtext, 0, extent)
+
+		var count uint64
+		if err := p.db.QueryRowContext(ctx, qtext).Scan(&count); err != nil {
+			log.Errorf("error during query: %v - %v", qtext, err)
+			return 0, err
+		}
+
+		return count, nil
+	}
+
+	var count uint64
+	err := readFeatures(ctx, p.db, pLayer, 0, extent, "", func(f *provider.Feature) error {
+		count++
+		return nil
+	})
+
+	return count, err
+}
@@ -7,6 +7,8 @@ import (
 
 var (
 	ErrMissingLayerName = errors.New("gpkg: layer is missing 'name'")
+	//	ErrMissingExtensionCode is returned when an extended geometry blob is too short to hold its extension code
+	ErrMissingExtensionCode = errors.New("gpkg: extended geometry blob is missing its extension code")
 )
 
 type ErrInvalidFilePath struct {
@@ -16,3 +18,94 @@ type ErrInvalidFilePath struct {
 func (e ErrInvalidFilePath) Error() string {
 	return fmt.Sprintf("gpkg: invalid filepath: %v", e.FilePath)
 }
+
+//	ErrShortEnvelope is returned when a geometry blob is too short to hold the envelope its header flags declare
+type ErrShortEnvelope struct {
+	EnvelopeType string
+	Expected     int
+	Got          int
+}
+
+func (e ErrShortEnvelope) Error() string {
+	return fmt.Sprintf("gpkg: not enough bytes to decode header: the %v envelope needs %v bytes, got %v", e.EnvelopeType, e.Expected, e.Got)
+}
+
+type ErrMissingTable struct {
+	Table string
+}
+
+func (e ErrMissingTable) Error() string {
+	return fmt.Sprintf("gpkg: missing the required table (%v)", e.Table)
+}
+
+type ErrInvalidApplicationID struct {
+	ApplicationID int64
+}
+
+func (e ErrInvalidApplicationID) Error() string {
+	return fmt.Sprintf("gpkg: invalid application_id (%#x), expected the ASCII of GPKG, GP11 or GP10", e.ApplicationID)
+}
+
+type ErrInvalidUserVersion struct {
+	UserVersion int64
+}
+
+func (e ErrInvalidUserVersion) Error() string {
+	return fmt.Sprintf("gpkg: invalid user_version (%v), expected 10200 (version 1.2.0) or later", e.UserVersion)
+}
+
+type ErrLayerNotFound struct {
+	LayerName string
+}
+
+func (e ErrLayerNotFound) Error() string {
+	return fmt.Sprintf("gpkg: layer (%v) not found", e.LayerName)
+}
+
+type ErrInvalidBusyTimeout struct {
+	BusyTimeout int64
+}
+
+func (e ErrInvalidBusyTimeout) Error() string {
+	return fmt.Sprintf("gpkg: invalid busy_timeout (%v), expected a non negative number of milliseconds", e.BusyTimeout)
+}
+
+type ErrInvalidMeasures struct {
+	LayerName string
+	Measures  string
+}
+
+func (e ErrInvalidMeasures) Error() string {
+	return fmt.Sprintf("gpkg: layer (%v) has an invalid measures value (%v). expected %v or %v", e.LayerName, e.Measures, MeasuresMinMax, MeasuresVertices)
+}
+
+//	ErrGeomFieldNotFound is returned when a layer's table has no geometry column of the configured name
+type ErrGeomFieldNotFound struct {
+	LayerName     string
+	Tablename     string
+	GeomFieldname string
+}
+
+func (e ErrGeomFieldNotFound) Error() string {
+	return fmt.Sprintf("gpkg: layer (%v) table (%v) has no geometry column (%v)", e.LayerName, e.Tablename, e.GeomFieldname)
+}
+
+//	ErrAttributesTableNotFound is returned when the table joined to a layer is not an attributes table
+type ErrAttributesTableNotFound struct {
+	LayerName string
+	Tablename string
+}
+
+func (e ErrAttributesTableNotFound) Error() string {
+	return fmt.Sprintf("gpkg: layer (%v) attributes table (%v) not found", e.LayerName, e.Tablename)
+}
+
+//	ErrExtensionDecoderNotFound is returned when an extended geometry blob has an extension code no
+//	decoder is registered for. see RegisterExtensionDecoder
+type ErrExtensionDecoderNotFound struct {
+	Code uint32
+}
+
+func (e ErrExtensionDecoderNotFound) Error() string {
+	return fmt.Sprintf("gpkg: no decoder registered for the geometry extension code (%#08x)", e.Code)
+}
@@ -0,0 +1,47 @@
+package gpkg
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+// ExtensionDecoder decodes the extension data of an extended geometry blob, the bytes following its
+// extension code, to a geometry.
+type ExtensionDecoder func(data []byte) (geom.Geometry, error)
+
+var (
+	extensionDecodersLock sync.RWMutex
+	extensionDecoders     = map[uint32]ExtensionDecoder{}
+)
+
+// RegisterExtensionDecoder registers the decoder of the extended geometry blobs, the blobs with the
+// extended GeoPackageBinary flag set, with the extension code. The code is the 4 bytes following the
+// blob's header read as a big endian uint32, so the code of an extension named "ABCD" is 0x41424344.
+// A decoder registered for the same code is replaced.
+func RegisterExtensionDecoder(code uint32, fn func([]byte) (geom.Geometry, error)) {
+	extensionDecodersLock.Lock()
+	defer extensionDecodersLock.Unlock()
+
+	extensionDecoders[code] = fn
+}
+
+// decodeExtendedGeometry decodes the extended geometry blob with the decoder registered for its
+// extension code.
+func decodeExtendedGeometry(h *BinaryHeader, blob []byte) (geom.Geometry, error) {
+	data := blob[h.Size():]
+	if len(data) < 4 {
+		return nil, ErrMissingExtensionCode
+	}
+	code := binary.BigEndian.Uint32(data)
+
+	extensionDecodersLock.RLock()
+	fn, ok := extensionDecoders[code]
+	extensionDecodersLock.RUnlock()
+	if !ok {
+		return nil, ErrExtensionDecoderNotFound{code}
+	}
+
+	return fn(data[4:])
+}
@@ -0,0 +1,84 @@
+// +build cgo
+
+package gpkg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/geom"
+)
+
+func TestDecodeExtendedGeometry(t *testing.T) {
+	type tcase struct {
+		blob        []byte
+		expected    geom.Geometry
+		expectedErr error
+		// the extension data the decoder is invoked with. nil if it's not invoked
+		expectedData []byte
+	}
+
+	// the stub decodes the extension data bytes to the coordinates of a point
+	var invokedWith []byte
+	RegisterExtensionDecoder(0x41424344, func(data []byte) (geom.Geometry, error) {
+		invokedWith = data
+		return geom.Point{float64(data[0]), float64(data[1])}, nil
+	})
+
+	fn := func(t *testing.T, tc tcase) {
+		invokedWith = nil
+
+		_, geo, err := decodeGeometry(tc.blob)
+		if err != tc.expectedErr {
+			t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+		}
+		if !reflect.DeepEqual(invokedWith, tc.expectedData) {
+			t.Errorf("decoder data, expected %v got %v", tc.expectedData, invokedWith)
+		}
+		if !reflect.DeepEqual(geo, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, geo)
+		}
+	}
+
+	tests := map[string]tcase{
+		"registered extension": {
+			blob: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x21,                   // Flags -- LittleEndian, no envelope, extended
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x41, 0x42, 0x43, 0x44, // extension code ABCD
+				0x03, 0x04, // extension data
+			},
+			expected:     geom.Point{3, 4},
+			expectedData: []byte{0x03, 0x04},
+		},
+		"unregistered extension": {
+			blob: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x21,                   // Flags -- LittleEndian, no envelope, extended
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x57, 0x58, 0x59, 0x5A, // extension code WXYZ
+			},
+			expectedErr: ErrExtensionDecoderNotFound{Code: 0x5758595A},
+		},
+		"missing extension code": {
+			blob: []byte{
+				0x47, 0x50, // Magic number
+				0x00,                   // Version
+				0x21,                   // Flags -- LittleEndian, no envelope, extended
+				0xE6, 0x10, 0x00, 0x00, // srs_id
+				0x41, 0x42,
+			},
+			expectedErr: ErrMissingExtensionCode,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
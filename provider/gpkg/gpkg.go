@@ -3,10 +3,12 @@
 package gpkg
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/basic"
@@ -21,6 +23,8 @@ const (
 	DefaultSRID          = tegola.WebMercator
 	DefaultIDFieldName   = "fid"
 	DefaultGeomFieldName = "geom"
+	// the milliseconds a query waits on a locked database before failing
+	DefaultBusyTimeout = 5000
 )
 
 //	config keys
@@ -31,25 +35,132 @@ const (
 	ConfigKeyTableName   = "tablename"
 	ConfigKeySQL         = "sql"
 	ConfigKeyGeomIDField = "id_fieldname"
+	ConfigKeyGeomField   = "geometry_fieldname"
 	ConfigKeyFields      = "fields"
+	ConfigKeyMeasures    = "measures"
+	ConfigKeyIDHash      = "id_hash"
+	ConfigKeyBusyTimeout = "busy_timeout"
+	ConfigKeyWAL         = "wal"
+	ConfigKeyJoin        = "join"
+	ConfigKeyJoinKey     = "key"
+	ConfigKeyAttrKey     = "attributes_key"
+	ConfigKeyEmptyPoint  = "empty_as_point"
 )
 
-func decodeGeometry(bytes []byte) (*BinaryHeader, geom.Geometry, error) {
-	h, err := NewBinaryHeader(bytes)
+//	decodeGeometry decodes the geometry blob's header and the single geometry which follows it. some
+//	GeoPackage writers pad the blob, so bytes following the geometry are ignored. a blob without the
+//	gpkg header is decoded as raw WKB. extended geometry blobs are decoded by the decoder registered
+//	for their extension code (see RegisterExtensionDecoder)
+func decodeGeometry(blob []byte) (*BinaryHeader, geom.Geometry, error) {
+	return decodeGeometryWithHeader(blob, newGeometryHeader)
+}
+
+//	decodeGeometryWithHeader decodes the geometry blob as decodeGeometry does with the header decoded
+//	by header, i.e. newGeometryHeaderFast when the envelope isn't needed
+func decodeGeometryWithHeader(blob []byte, header func([]byte) (*BinaryHeader, error)) (*BinaryHeader, geom.Geometry, error) {
+	h, err := header(blob)
 	if err != nil {
-		log.Error("error decoding geometry header: %v", err)
+		log.Errorf("error decoding geometry header: %v", err)
 		return h, nil, err
 	}
 
-	geo, err := wkb.DecodeBytes(bytes[h.Size():])
+	if !h.IsStandardGeometry() {
+		geo, err := decodeExtendedGeometry(h, blob)
+		if err != nil {
+			log.Errorf("error decoding extended geometry: %v", err)
+		}
+		return h, geo, err
+	}
+
+	r := bytes.NewReader(blob[h.Size():])
+	geo, err := wkb.Decode(r)
 	if err != nil {
-		log.Error("error decoding geometry: %v", err)
+		log.Errorf("error decoding geometry: %v", err)
 		return h, nil, err
 	}
 
+	if r.Len() > 0 {
+		log.Debugf("ignoring %v trailing bytes in geometry blob (%v bytes). header size: %v", r.Len(), len(blob), h.Size())
+	}
+
 	return h, geo, nil
 }
 
+//	decodeMeasuredGeometry decodes the geometry and the M values of its vertices. the M values of
+//	extended geometry blobs are not decoded
+func decodeMeasuredGeometry(bytes []byte) (*BinaryHeader, geom.Geometry, []float64, error) {
+	return decodeMeasuredGeometryWithHeader(bytes, newGeometryHeader)
+}
+
+//	decodeMeasuredGeometryWithHeader decodes the geometry blob as decodeMeasuredGeometry does with the
+//	header decoded by header
+func decodeMeasuredGeometryWithHeader(bytes []byte, header func([]byte) (*BinaryHeader, error)) (*BinaryHeader, geom.Geometry, []float64, error) {
+	h, err := header(bytes)
+	if err != nil {
+		log.Errorf("error decoding geometry header: %v", err)
+		return h, nil, nil, err
+	}
+
+	if !h.IsStandardGeometry() {
+		geo, err := decodeExtendedGeometry(h, bytes)
+		if err != nil {
+			log.Errorf("error decoding extended geometry: %v", err)
+		}
+		return h, geo, nil, err
+	}
+
+	geo, ms, err := wkb.DecodeBytesMeasures(bytes[h.Size():])
+	if err != nil {
+		log.Errorf("error decoding geometry: %v", err)
+		return h, nil, nil, err
+	}
+
+	return h, geo, ms, nil
+}
+
+//	featureBounds returns the bounds of a feature's geometry blob for filtering features outside of
+//	the tile. the bounds are read from the envelope when the blob has one. otherwise the geometry is
+//	decoded, and returned so it's not decoded again, and its bounds are cached by the feature's id.
+//	false is returned if the bounds are unknown, i.e. for an empty geometry
+func featureBounds(cache *boundsCache, id interface{}, geomData []byte) (geom.BoundingBox, bool, geom.Geometry, error) {
+	h, err := newGeometryHeader(geomData)
+	if err != nil {
+		return geom.BoundingBox{}, false, nil, err
+	}
+	if h.IsGeometryEmpty() {
+		return geom.BoundingBox{}, false, nil, nil
+	}
+
+	if bbox, ok := envelopeBounds(h); ok {
+		return bbox, true, nil, nil
+	}
+
+	fid, err := provider.ConvertFeatureID(id)
+	hasID := id != nil && err == nil
+	if hasID {
+		if bbox, ok := cache.get(fid); ok {
+			return bbox, true, nil, nil
+		}
+	}
+
+	_, geo, err := decodeGeometry(geomData)
+	if err != nil {
+		return geom.BoundingBox{}, false, nil, err
+	}
+
+	bbox, err := geom.BBoxOf(geo)
+	if err != nil {
+		// the geometry can't be bounded so it's not filtered
+		return geom.BoundingBox{}, false, geo, nil
+	}
+
+	if hasID {
+		cache.set(fid, bbox)
+	}
+
+	return bbox, true, geo, nil
+}
+
 type Provider struct {
 	// path to the geopackage file
 	Filepath string
@@ -75,7 +186,19 @@ func (p *Provider) Layers() ([]provider.LayerInfo, error) {
 	return ls, nil
 }
 
+//	SRID returns the layer's srs_id as declared in gpkg_geometry_columns, or for custom SQL layers in the
+//	config. implements provider.SRIDer
+func (p *Provider) SRID(layer string) uint64 {
+	return p.layers[layer].srid
+}
+
 func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	return p.TileFeaturesWithFilter(ctx, layer, "", tile, fn)
+}
+
+// TileFeaturesWithFilter streams the layer's features matching the SQL filter to fn. an empty filter
+// matches all features. implements provider.SQLFilterer
+func (p *Provider) TileFeaturesWithFilter(ctx context.Context, layer string, filter string, tile provider.Tile, fn func(f *provider.Feature) error) error {
 	log.Debugf("fetching layer %v", layer)
 
 	pLayer := p.layers[layer]
@@ -108,32 +231,63 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 		}
 	}
 
+	z, _, _ := tile.ZXY()
+
+	return readFeatures(ctx, p.db, pLayer, z, tileBBox, filter, fn)
+}
+
+//	readFeatures queries the layer's features intersecting the extent, which is in the layer's SRID,
+//	from the gpkg database. the geometry blobs are decoded and the attribute columns are added as tags.
+//	each feature is passed to fn. when filter is not empty only the rows matching the filter are read
+func readFeatures(ctx context.Context, db *sql.DB, pLayer Layer, zoom uint64, extent geom.BoundingBox, filter string, fn func(f *provider.Feature) error) error {
 	var qtext string
 
 	if pLayer.tablename != "" {
 		// If layer was specified via "tablename" in config, construct query.
-		rtreeTablename := fmt.Sprintf("rtree_%v_geom", pLayer.tablename)
+		// the spatial index table is named after the table and geometry column (rtree_<t>_<c>) per the gpkg spec
+		rtreeTablename := fmt.Sprintf("rtree_%v_%v", pLayer.tablename, pLayer.geomFieldname)
 
-		selectClause := fmt.Sprintf("SELECT `%v` AS fid, `%v` AS geom", pLayer.idFieldname, pLayer.geomFieldname)
+		// the columns are aliased to their own names so the returned column names match the layer's id and
+		// geometry field names. sqlite names the unaliased columns of a view after the column expression
+		selectClause := fmt.Sprintf("SELECT l.`%[1]v` AS `%[1]v`, l.`%[2]v` AS `%[2]v`", pLayer.idFieldname, pLayer.geomFieldname)
 
 		for _, tf := range pLayer.tagFieldnames {
-			selectClause += fmt.Sprintf(", `%v`", tf)
+			selectClause += fmt.Sprintf(", l.`%[1]v` AS `%[1]v`", tf)
 		}
 
-		// l - layer table, si - spatial index
-		qtext = fmt.Sprintf("%v FROM %v l JOIN %v si ON l.%v = si.id WHERE geom IS NOT NULL AND !BBOX!", selectClause, pLayer.tablename, rtreeTablename, pLayer.idFieldname)
+		// the rows of the attributes table are left joined so features without a matching row are kept
+		var joinClause string
+		if j := pLayer.join; j != nil {
+			for _, f := range j.fieldnames {
+				selectClause += fmt.Sprintf(", a.`%[1]v` AS `%[1]v`", f)
+			}
+			joinClause = fmt.Sprintf(" LEFT JOIN `%v` a ON l.`%v` = a.`%v`", j.tablename, j.key, j.attributesKey)
+		}
+
+		if pLayer.spatialIndex {
+			// l - layer table, si - spatial index, a - attributes table
+			qtext = fmt.Sprintf("%v FROM %v l JOIN %v si ON l.`%v` = si.id%v WHERE l.`%v` IS NOT NULL AND !BBOX!", selectClause, pLayer.tablename, rtreeTablename, pLayer.idFieldname, joinClause, pLayer.geomFieldname)
+		} else {
+			// views have no spatial index. the rows are limited to the extent by their bounds below
+			qtext = fmt.Sprintf("%v FROM %v l%v WHERE l.`%v` IS NOT NULL", selectClause, pLayer.tablename, joinClause, pLayer.geomFieldname)
+		}
 
-		z, _, _ := tile.ZXY()
-		qtext = replaceTokens(qtext, z, tileBBox)
 	} else {
 		// If layer was specified via "sql" in config, collect it
-		z, _, _ := tile.ZXY()
-		qtext = replaceTokens(pLayer.sql, z, tileBBox)
+		qtext = pLayer.sql
+	}
+
+	// the tokens are bound as parameters so the query text, and its prepared statement, is the same for each tile
+	qtext, args := parameterizeTokens(qtext, zoom, extent)
+
+	if filter != "" {
+		qtext = fmt.Sprintf("SELECT * FROM (%v) AS tegola_filtered WHERE (%v)", strings.TrimRight(strings.TrimSpace(qtext), ";"), filter)
 	}
 
-	log.Debugf("qtext: %v", qtext)
+	log.Debugf("qtext: %v, args: %v", qtext, args)
 
-	rows, err := p.db.Query(qtext)
+	//	use the request context so the query is interrupted if the request is canceled
+	rows, err := pLayer.stmts.query(ctx, db, qtext, args...)
 	if err != nil {
 		log.Errorf("err during query: %v - %v", qtext, err)
 		return err
@@ -145,6 +299,26 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 		return err
 	}
 
+	//	the date columns are read by their declared type in the table schema
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	declTypes := make([]string, len(cols))
+	for i := range colTypes {
+		declTypes[i] = strings.ToUpper(colTypes[i].DatabaseTypeName())
+	}
+
+	idIdx, geomIdx := -1, -1
+	for i := range cols {
+		switch cols[i] {
+		case pLayer.idFieldname:
+			idIdx = i
+		case pLayer.geomFieldname:
+			geomIdx = i
+		}
+	}
+
 	for rows.Next() {
 		// check if the context cancelled or timed out
 		if ctx.Err() != nil {
@@ -162,6 +336,27 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 			return err
 		}
 
+		// skip the features outside of the extent before decoding their geometries. the rows of
+		// tables with a spatial index are already limited to the extent, custom SQL queries aren't
+		var boundsGeo geom.Geometry
+		if geomIdx >= 0 && !pLayer.spatialIndex {
+			if geomData, ok := vals[geomIdx].([]byte); ok {
+				var id interface{}
+				if idIdx >= 0 {
+					id = vals[idIdx]
+				}
+
+				bbox, ok, geo, err := featureBounds(pLayer.bounds, id, geomData)
+				if err != nil {
+					return err
+				}
+				if ok && !extent.Intersects(bbox) {
+					continue
+				}
+				boundsGeo = geo
+			}
+		}
+
 		feature := provider.Feature{
 			Tags: map[string]interface{}{},
 		}
@@ -177,7 +372,7 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 
 			switch cols[i] {
 			case pLayer.idFieldname:
-				feature.ID, err = provider.ConvertFeatureID(vals[i])
+				feature.ID, err = featureID(vals[i], pLayer.idHash)
 				if err != nil {
 					return err
 				}
@@ -191,12 +386,42 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 					return errors.New("unexpected column type for geom field. expected blob")
 				}
 
-				h, geo, err := decodeGeometry(geomData)
-				if err != nil {
-					return err
+				// the rows are limited to the extent by now so the envelopes aren't decoded again
+				var h *BinaryHeader
+				var geo geom.Geometry
+				switch {
+				case pLayer.emptyAsPoint && emptyWithExtent(geomData):
+					// the empty geometry is marked by a point at the center of its envelope
+					if h, err = newGeometryHeader(geomData); err != nil {
+						return err
+					}
+					ext, _ := h.Extent()
+					geo = geom.Point{(ext.MinX() + ext.MaxX()) / 2, (ext.MinY() + ext.MaxY()) / 2}
+				case boundsGeo != nil && pLayer.measures == "":
+					// the geometry was already decoded to compute its bounds
+					if h, err = newGeometryHeaderFast(geomData); err != nil {
+						return err
+					}
+					geo = boundsGeo
+				case pLayer.measures != "":
+					var ms []float64
+					h, geo, ms, err = decodeMeasuredGeometryWithHeader(geomData, newGeometryHeaderFast)
+					if err != nil {
+						return err
+					}
+					addMeasureTags(feature.Tags, pLayer.measures, ms)
+				default:
+					h, geo, err = decodeGeometryWithHeader(geomData, newGeometryHeaderFast)
+					if err != nil {
+						return err
+					}
 				}
 
-				feature.SRID = uint64(h.SRSId())
+				// an undefined SRS (-1 or 0) is assumed to be the layer's SRS
+				feature.SRID = pLayer.srid
+				if h.SRSDefined() {
+					feature.SRID = uint64(h.SRSId())
+				}
 				feature.Geometry = geo
 
 			case "minx", "miny", "maxx", "maxy", "min_zoom", "max_zoom":
@@ -204,6 +429,16 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 				continue
 
 			default:
+				// the values of date columns are normalized to RFC3339 strings
+				if declTypes[i] == declTypeDate || declTypes[i] == declTypeDateTime {
+					if s, ok := dateTag(vals[i], declTypes[i]); ok {
+						feature.Tags[cols[i]] = s
+					} else {
+						log.Warnf("unable to read the %v column (%v) value %v as a date", declTypes[i], cols[i], vals[i])
+					}
+					continue
+				}
+
 				// Grab any non-nil, non-id, non-bounding box, & non-geometry column as a tag
 				switch v := vals[i].(type) {
 				case []uint8:
@@ -231,11 +466,52 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 	return nil
 }
 
-// Close will close the Provider's database connection
+//	emptyWithExtent reports if the geometry blob is an empty geometry with an envelope
+func emptyWithExtent(geomData []byte) bool {
+	h, err := newGeometryHeader(geomData)
+	if err != nil || !h.IsGeometryEmpty() {
+		return false
+	}
+	_, ok := h.Extent()
+	return ok
+}
+
+// Close will close the Provider's database connection and drop the cached feature bounds
 func (p *Provider) Close() error {
+	for _, l := range p.layers {
+		l.bounds.reset()
+	}
 	return p.db.Close()
 }
 
+//	HealthCheck verifies the GeoPackage database is reachable
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+//	hasSpatialIndex reports if the rtree spatial index of the table's geometry column exists
+func hasSpatialIndex(db *sql.DB, tablename, geomFieldname string) (bool, error) {
+	var count int
+	qtext := "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?;"
+	if err := db.QueryRow(qtext, fmt.Sprintf("rtree_%v_%v", tablename, geomFieldname)).Scan(&count); err != nil {
+		log.Errorf("error during query: %v - %v", qtext, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+//	isAttributesTable reports if the table is registered in gpkg_contents as an attributes table, a
+//	table of non-spatial rows
+func isAttributesTable(db *sql.DB, tablename string) (bool, error) {
+	var count int
+	qtext := "SELECT count(*) FROM gpkg_contents WHERE data_type = 'attributes' AND table_name = ?;"
+	if err := db.QueryRow(qtext, tablename).Scan(&count); err != nil {
+		log.Errorf("error during query: %v - %v", qtext, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
 type GeomTableDetails struct {
 	geomFieldname string
 	geomType      geom.Geometry
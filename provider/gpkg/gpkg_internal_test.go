@@ -0,0 +1,329 @@
+// +build cgo
+
+package gpkg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/encoding/wkb"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// gpkgGeometry encodes the geometry as a gpkg geometry blob without an envelope
+func gpkgGeometry(t *testing.T, srsID uint32, g geom.Geometry) []byte {
+	wkbBytes, err := wkb.EncodeBytes(g)
+	if err != nil {
+		t.Fatalf("err encoding wkb: %v", err)
+	}
+
+	header := []byte{
+		Magic[0], Magic[1],
+		0x00, // version
+		0x01, // little endian, no envelope
+		byte(srsID), byte(srsID >> 8), byte(srsID >> 16), byte(srsID >> 24),
+	}
+
+	return append(header, wkbBytes...)
+}
+
+// gpkgGeometryEnvelope encodes the geometry as a gpkg geometry blob with an XY envelope
+func gpkgGeometryEnvelope(t *testing.T, srsID uint32, g geom.Geometry, env geom.BoundingBox) []byte {
+	blob := gpkgGeometry(t, srsID, g)
+
+	// little endian, XY envelope
+	blob[3] = 0x01 | byte(EnvelopeTypeXY)<<1
+
+	var envelope []byte
+	for _, v := range []float64{env.MinX(), env.MaxX(), env.MinY(), env.MaxY()} {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+		envelope = append(envelope, b...)
+	}
+
+	return append(blob[:8], append(envelope, blob[8:]...)...)
+}
+
+func TestDecodeGeometryTrailingBytes(t *testing.T) {
+	polygon := geom.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}
+
+	wkbBytes, err := wkb.EncodeBytes(polygon)
+	if err != nil {
+		t.Fatalf("err encoding wkb: %v", err)
+	}
+	expected, err := wkb.DecodeBytes(wkbBytes)
+	if err != nil {
+		t.Fatalf("err decoding wkb: %v", err)
+	}
+
+	// padding which doesn't decode as a geometry
+	blob := append(gpkgGeometry(t, tegola.WGS84, polygon), 0x01, 0xff, 0xff, 0xff, 0x7f, 0x00, 0x00, 0x00)
+
+	h, geo, err := decodeGeometry(blob)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if h.SRSId() != tegola.WGS84 {
+		t.Errorf("srs id, expected %v got %v", tegola.WGS84, h.SRSId())
+	}
+	if !reflect.DeepEqual(geo, expected) {
+		t.Errorf("geometry, expected %v got %v", expected, geo)
+	}
+}
+
+func TestDecodeGeometryShortEnvelope(t *testing.T) {
+	type tcase struct {
+		blob        []byte
+		expected    geom.Geometry
+		expectedErr error
+	}
+
+	point := geom.Point{23.7, 37.9}
+
+	// the flags claim an XY envelope which the blob leaves out
+	missing := gpkgGeometry(t, tegola.WGS84, point)
+	missing[3] = 0x01 | byte(EnvelopeTypeXY)<<1
+
+	// the blob ends part way through the XY envelope
+	truncated := gpkgGeometryEnvelope(t, tegola.WGS84, point, geom.BoundingBox{{23.7, 37.9}, {23.7, 37.9}})[:8+16]
+
+	fn := func(t *testing.T, tc tcase) {
+		h, geo, err := decodeGeometry(tc.blob)
+		if tc.expectedErr != nil {
+			if err != tc.expectedErr {
+				t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if h.EnvelopeType() != EnvelopeTypeNone {
+			t.Errorf("envelope type, expected %v got %v", EnvelopeTypeNone, h.EnvelopeType())
+		}
+		if !reflect.DeepEqual(geo, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, geo)
+		}
+	}
+
+	tests := map[string]tcase{
+		"missing envelope": {
+			blob:     missing,
+			expected: point,
+		},
+		"truncated envelope": {
+			blob:        truncated,
+			expectedErr: ErrShortEnvelope{EnvelopeType: "XY", Expected: 32, Got: 16},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestDecodeGeometryRawWKB(t *testing.T) {
+	polygon := geom.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}
+
+	// raw WKB without the gpkg binary header
+	wkbBytes, err := wkb.EncodeBytes(polygon)
+	if err != nil {
+		t.Fatalf("err encoding wkb: %v", err)
+	}
+	expected, err := wkb.DecodeBytes(wkbBytes)
+	if err != nil {
+		t.Fatalf("err decoding wkb: %v", err)
+	}
+
+	h, geo, err := decodeGeometry(wkbBytes)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if h.SRSDefined() {
+		t.Errorf("srs, expected undefined got %v", h.SRSId())
+	}
+	if !reflect.DeepEqual(geo, expected) {
+		t.Errorf("geometry, expected %v got %v", expected, geo)
+	}
+
+	// the bounds of the headerless geometry are computed from the geometry
+	bbox, ok, _, err := featureBounds(newBoundsCache(defaultMaxCachedBounds), int64(1), wkbBytes)
+	if err != nil {
+		t.Fatalf("unexpected err reading bounds: %v", err)
+	}
+	if expectedBBox := (geom.BoundingBox{{0, 0}, {10, 10}}); !ok || bbox != expectedBBox {
+		t.Errorf("bounds, expected %v got %v (%v)", expectedBBox, bbox, ok)
+	}
+}
+
+func TestReadFeaturesBounds(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("err opening db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err = db.Exec("CREATE TABLE points (fid INTEGER PRIMARY KEY, geom BLOB, name TEXT)"); err != nil {
+		t.Fatalf("err creating table: %v", err)
+	}
+
+	rows := []struct {
+		fid  uint64
+		geom []byte
+	}{
+		{fid: 1, geom: gpkgGeometry(t, tegola.WGS84, geom.Point{10, 20})},
+		{fid: 2, geom: gpkgGeometry(t, tegola.WGS84, geom.Point{100, 50})},
+		// the envelope is used instead of decoding the geometry
+		{fid: 3, geom: gpkgGeometryEnvelope(t, tegola.WGS84, geom.Point{120, 60}, geom.BoundingBox{{120, 60}, {120, 60}})},
+	}
+	for _, r := range rows {
+		if _, err = db.Exec("INSERT INTO points (fid, geom, name) VALUES (?, ?, ?)", r.fid, r.geom, "point"); err != nil {
+			t.Fatalf("err inserting row: %v", err)
+		}
+	}
+
+	// custom SQL layers are not limited to the extent by the spatial index
+	layer := Layer{
+		name:          "points",
+		sql:           "SELECT fid, geom, name FROM points",
+		idFieldname:   DefaultIDFieldName,
+		geomFieldname: DefaultGeomFieldName,
+		geomType:      geom.Point{},
+		srid:          tegola.WGS84,
+		bounds:        newBoundsCache(defaultMaxCachedBounds),
+	}
+
+	// two tile requests for the same extent
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			// the geometry of feature 2 can't be decoded. the second request only succeeds if its
+			// cached bounds are used instead of decoding it again
+			corrupt := append(gpkgGeometry(t, tegola.WGS84, geom.Point{100, 50})[:8], 0x01, 0xff, 0xff, 0xff, 0xff)
+			if _, err = db.Exec("UPDATE points SET geom = ? WHERE fid = 2", corrupt); err != nil {
+				t.Fatalf("err updating row: %v", err)
+			}
+		}
+
+		var ids []uint64
+		err = readFeatures(context.Background(), db, layer, 5, geom.BoundingBox{{0, 0}, {50, 50}}, "", func(f *provider.Feature) error {
+			ids = append(ids, f.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("[%v] err reading features: %v", i, err)
+		}
+
+		if expected := []uint64{1}; !reflect.DeepEqual(ids, expected) {
+			t.Errorf("[%v] feature ids, expected %v got %v", i, expected, ids)
+		}
+	}
+
+	expected := map[uint64]geom.BoundingBox{
+		1: {{10, 20}, {10, 20}},
+		2: {{100, 50}, {100, 50}},
+	}
+	if !reflect.DeepEqual(layer.bounds.bounds, expected) {
+		t.Errorf("cached bounds, expected %v got %v", expected, layer.bounds.bounds)
+	}
+}
+
+func TestReadFeatures(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("err opening db: %v", err)
+	}
+	defer db.Close()
+	// each connection to an in memory database gets its own database
+	db.SetMaxOpenConns(1)
+
+	stmts := []string{
+		"CREATE TABLE points (fid INTEGER PRIMARY KEY, geom BLOB, name TEXT)",
+		"CREATE VIRTUAL TABLE rtree_points_geom USING rtree(id, minx, maxx, miny, maxy)",
+	}
+	for _, stmt := range stmts {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("err executing (%v): %v", stmt, err)
+		}
+	}
+
+	rows := []struct {
+		fid   uint64
+		srsID int32
+		pt    geom.Point
+		name  string
+	}{
+		{fid: 1, srsID: tegola.WGS84, pt: geom.Point{10, 20}, name: "inside"},
+		{fid: 2, srsID: tegola.WGS84, pt: geom.Point{100, 50}, name: "outside"},
+		// undefined geographic srs_id, the layer's SRID is assumed
+		{fid: 3, srsID: -1, pt: geom.Point{30, 40}, name: "undefined srs"},
+	}
+	for _, r := range rows {
+		if _, err = db.Exec("INSERT INTO points (fid, geom, name) VALUES (?, ?, ?)", r.fid, gpkgGeometry(t, uint32(r.srsID), r.pt), r.name); err != nil {
+			t.Fatalf("err inserting row: %v", err)
+		}
+		if _, err = db.Exec("INSERT INTO rtree_points_geom VALUES (?, ?, ?, ?, ?)", r.fid, r.pt[0], r.pt[0], r.pt[1], r.pt[1]); err != nil {
+			t.Fatalf("err inserting rtree row: %v", err)
+		}
+	}
+
+	layer := Layer{
+		name:          "points",
+		tablename:     "points",
+		tagFieldnames: []string{"name"},
+		idFieldname:   DefaultIDFieldName,
+		geomFieldname: DefaultGeomFieldName,
+		geomType:      geom.Point{},
+		srid:          tegola.WGS84,
+		spatialIndex:  true,
+	}
+
+	read := func(filter string) []provider.Feature {
+		var features []provider.Feature
+		err := readFeatures(context.Background(), db, layer, 5, geom.BoundingBox{{0, 0}, {50, 50}}, filter, func(f *provider.Feature) error {
+			features = append(features, *f)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err reading features with filter (%v): %v", filter, err)
+		}
+		return features
+	}
+
+	inside := provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{10, 20},
+		SRID:     tegola.WGS84,
+		Tags: map[string]interface{}{
+			"name": "inside",
+		},
+	}
+	undefinedSRS := provider.Feature{
+		ID:       3,
+		Geometry: geom.Point{30, 40},
+		SRID:     tegola.WGS84,
+		Tags: map[string]interface{}{
+			"name": "undefined srs",
+		},
+	}
+
+	if features, expected := read(""), []provider.Feature{inside, undefinedSRS}; !reflect.DeepEqual(features, expected) {
+		t.Errorf("features, expected %+v got %+v", expected, features)
+	}
+
+	if features, expected := read("name = 'inside'"), []provider.Feature{inside}; !reflect.DeepEqual(features, expected) {
+		t.Errorf("filtered features, expected %+v got %+v", expected, features)
+	}
+}
@@ -32,10 +32,43 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 		return nil, ErrInvalidFilePath{filepath}
 	}
 
-	db, err := sql.Open("sqlite3", filepath)
+	busyTimeout := int64(DefaultBusyTimeout)
+	busyTimeout, err = m.Int64(ConfigKeyBusyTimeout, &busyTimeout)
 	if err != nil {
 		return nil, err
 	}
+	if busyTimeout < 0 {
+		return nil, ErrInvalidBusyTimeout{busyTimeout}
+	}
+
+	wal := false
+	wal, err = m.Bool(ConfigKeyWAL, &wal)
+	if err != nil {
+		return nil, err
+	}
+
+	//	every connection of the pool waits up to the busy timeout on a locked database
+	dsnSep := "?"
+	if strings.Contains(filepath, "?") {
+		dsnSep = "&"
+	}
+	db, err := sql.Open("sqlite3", fmt.Sprintf("%v%v_busy_timeout=%v", filepath, dsnSep, busyTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	//	in WAL mode reads don't block each other or wait on writes to the file. the journal
+	//	mode is persisted in the file so the file is converted once for all of the connections
+	if wal {
+		var mode string
+		if err := db.QueryRow("PRAGMA journal_mode=WAL;").Scan(&mode); err != nil {
+			return nil, fmt.Errorf("gpkg: setting the journal mode of (%v): %v", filepath, err)
+		}
+		//	the journal mode is left unchanged when it can't be changed, i.e. for a read only file
+		if !strings.EqualFold(mode, "wal") {
+			log.Warnf("the journal mode of (%v) could not be set to WAL, using %v", filepath, mode)
+		}
+	}
 
 	p := Provider{
 		Filepath: filepath,
@@ -50,7 +83,9 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 		FROM
 			gpkg_contents c JOIN gpkg_geometry_columns gc ON c.table_name == gc.table_name
 		WHERE
-			c.data_type = 'features';`
+			c.data_type = 'features'
+		ORDER BY
+			gc.rowid;`
 
 	rows, err := p.db.Query(qtext)
 	if err != nil {
@@ -59,8 +94,8 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 	}
 	defer rows.Close()
 
-	//	container for tracking metadata for each table with a geometry
-	geomTableDetails := make(map[string]GeomTableDetails)
+	//	container for tracking metadata for each geometry column of each table, in the order they are registered
+	geomTableDetails := make(map[string][]GeomTableDetails)
 
 	//	iterate each row extracting meta data about each table
 	for rows.Next() {
@@ -79,13 +114,13 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 			return nil, err
 		}
 
-		geomTableDetails[tablename.String] = GeomTableDetails{
+		geomTableDetails[tablename.String] = append(geomTableDetails[tablename.String], GeomTableDetails{
 			geomFieldname: geomCol.String,
 			geomType:      tg,
 			srid:          uint64(srid.Int64),
 			//	the extent of the layer's features
 			bbox: geom.BoundingBox{{minX.Float64, minY.Float64}, {maxX.Float64, maxY.Float64}},
-		}
+		})
 	}
 
 	layers, ok := config[ConfigKeyLayers].([]map[string]interface{})
@@ -126,14 +161,47 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
 		}
 
+		var geomFieldname string
+		geomFieldname, err = layerConf.String(ConfigKeyGeomField, &geomFieldname)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyGeomField, err)
+		}
+
 		tagFieldnames, err := layerConf.StringSlice(ConfigKeyFields)
 		if err != nil {
 			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyFields, err)
 		}
 
+		var measures string
+		measures, err = layerConf.String(ConfigKeyMeasures, &measures)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyMeasures, err)
+		}
+		if !isValidMeasures(measures) {
+			return nil, ErrInvalidMeasures{LayerName: layerName, Measures: measures}
+		}
+
+		var idHash bool
+		idHash, err = layerConf.Bool(ConfigKeyIDHash, &idHash)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyIDHash, err)
+		}
+
+		var emptyAsPoint bool
+		emptyAsPoint, err = layerConf.Bool(ConfigKeyEmptyPoint, &emptyAsPoint)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyEmptyPoint, err)
+		}
+
 		//	layer container. will be added to the provider after it's configured
 		layer := Layer{
-			name: layerName,
+			name:         layerName,
+			measures:     measures,
+			idHash:       idHash,
+			emptyAsPoint: emptyAsPoint,
+			bounds:       newBoundsCache(defaultMaxCachedBounds),
+			count:        &featureCount{},
+			stmts:        newStmtCache(),
 		}
 
 		if layerConf[ConfigKeyTableName] != nil {
@@ -142,13 +210,47 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 				return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
 			}
 
+			//	a table can have more than one geometry column. the first one registered is used
+			//	unless the geometry field is configured
+			var details GeomTableDetails
+			if columns := geomTableDetails[tablename]; len(columns) > 0 {
+				details = columns[0]
+			}
+			if geomFieldname != "" {
+				found := false
+				for _, column := range geomTableDetails[tablename] {
+					if column.geomFieldname == geomFieldname {
+						details, found = column, true
+						break
+					}
+				}
+				if !found {
+					return nil, ErrGeomFieldNotFound{
+						LayerName:     layerName,
+						Tablename:     tablename,
+						GeomFieldname: geomFieldname,
+					}
+				}
+			}
+
 			layer.tablename = tablename
 			layer.tagFieldnames = tagFieldnames
-			layer.geomFieldname = geomTableDetails[tablename].geomFieldname
-			layer.geomType = geomTableDetails[tablename].geomType
+			layer.geomFieldname = details.geomFieldname
+			layer.geomType = details.geomType
 			layer.idFieldname = idFieldname
-			layer.srid = geomTableDetails[tablename].srid
-			layer.bbox = geomTableDetails[tablename].bbox
+			layer.srid = details.srid
+			layer.bbox = details.bbox
+
+			//	views and tables without a spatial index are registered in gpkg_contents too
+			if layer.spatialIndex, err = hasSpatialIndex(p.db, tablename, layer.geomFieldname); err != nil {
+				return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+			}
+
+			if layerConf[ConfigKeyJoin] != nil {
+				if layer.join, err = attributesJoinConfig(p.db, layerName, layerConf); err != nil {
+					return nil, err
+				}
+			}
 
 		} else {
 			var customSQL string
@@ -188,8 +290,12 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 			// TODO(arolek): this assumes WGS84. should be more flexible
 			customSQL = replaceTokens(customSQL, 0, geom.BoundingBox{{180.0, 85.0511}, {-180.0, -85.0511}})
 
+			if geomFieldname == "" {
+				geomFieldname = DefaultGeomFieldName
+			}
+
 			// Get geometry type & srid from geometry of first row.
-			qtext := fmt.Sprintf("SELECT geom FROM (%v) LIMIT 1;", customSQL)
+			qtext := fmt.Sprintf("SELECT `%v` FROM (%v) LIMIT 1;", geomFieldname, customSQL)
 
 			log.Debugf("qtext: %v", qtext)
 
@@ -207,8 +313,12 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 			}
 
 			layer.geomType = geo
-			layer.srid = uint64(h.SRSId())
-			layer.geomFieldname = DefaultGeomFieldName
+			// an undefined SRS (-1 or 0) falls back to the default SRID
+			layer.srid = DefaultSRID
+			if h.SRSDefined() {
+				layer.srid = uint64(h.SRSId())
+			}
+			layer.geomFieldname = geomFieldname
 			layer.idFieldname = DefaultIDFieldName
 		}
 
@@ -221,6 +331,54 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 	return &p, err
 }
 
+//	attributesJoinConfig reads the layer's join of an attributes table. the attributes table must be
+//	registered in gpkg_contents with the attributes data type
+func attributesJoinConfig(db *sql.DB, layerName string, layerConf dict.M) (*attributesJoin, error) {
+	joinConf, err := layerConf.Dict(ConfigKeyJoin)
+	if err != nil {
+		return nil, fmt.Errorf("for layer %v %v: %v", layerName, ConfigKeyJoin, err)
+	}
+
+	tablename, err := joinConf.String(ConfigKeyTableName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("for layer %v %v: %v", layerName, ConfigKeyJoin, err)
+	}
+
+	key, err := joinConf.String(ConfigKeyJoinKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("for layer %v %v: %v", layerName, ConfigKeyJoin, err)
+	}
+
+	//	the attributes table is joined on a column of the same name by default
+	attributesKey, err := joinConf.String(ConfigKeyAttrKey, &key)
+	if err != nil {
+		return nil, fmt.Errorf("for layer %v %v: %v", layerName, ConfigKeyJoin, err)
+	}
+
+	fieldnames, err := joinConf.StringSlice(ConfigKeyFields)
+	if err != nil {
+		return nil, fmt.Errorf("for layer %v %v: %v", layerName, ConfigKeyJoin, err)
+	}
+
+	ok, err := isAttributesTable(db, tablename)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAttributesTableNotFound{
+			LayerName: layerName,
+			Tablename: tablename,
+		}
+	}
+
+	return &attributesJoin{
+		tablename:     tablename,
+		key:           key,
+		attributesKey: attributesKey,
+		fieldnames:    fieldnames,
+	}, nil
+}
+
 // reference to all instantiated proivders
 var providers []Provider
 
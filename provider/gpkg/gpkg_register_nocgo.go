@@ -9,3 +9,7 @@ func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
 }
 
 func Cleanup() {}
+
+func ValidateGeoPackage(path string) error {
+	return provider.ErrUnsupported
+}
@@ -3,12 +3,26 @@
 package gpkg_test
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/geom/encoding/wkb"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/gpkg"
 )
@@ -17,6 +31,7 @@ const (
 	GPKGAthensFilePath       = "testdata/athens-osm-20170921.gpkg"
 	GPKGNaturalEarthFilePath = "testdata/natural_earth_minimal.gpkg"
 	GPKGPuertoMontFilePath   = "testdata/puerto_mont-osm-20170922.gpkg"
+	GPKGTheGeomFilePath      = "testdata/the_geom.gpkg"
 )
 
 func init() {
@@ -77,6 +92,16 @@ func TestNewTileProvider(t *testing.T) {
 			},
 			expectedLayerCount: 3,
 		},
+		"negative busy timeout": tcase{
+			config: map[string]interface{}{
+				"filepath":     GPKGAthensFilePath,
+				"busy_timeout": int64(-1),
+				"layers": []map[string]interface{}{
+					{"name": "a_points", "tablename": "amenities_points"},
+				},
+			},
+			expectedErr: gpkg.ErrInvalidBusyTimeout{BusyTimeout: -1},
+		},
 	}
 
 	for name, tc := range tests {
@@ -419,3 +444,1178 @@ func TestConfigs(t *testing.T) {
 		})
 	}
 }
+
+func TestAttribution(t *testing.T) {
+	type tcase struct {
+		config      map[string]interface{}
+		contains    string
+		excludes    string
+		expectEmpty bool
+	}
+
+	// add metadata scoped to the rail_lines and roads_lines tables to a copy of the fixture
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(GPKGAthensFilePath)
+	if err != nil {
+		t.Fatalf("err reading fixture: %v", err)
+	}
+	path := filepath.Join(dir, "athens.gpkg")
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err writing fixture copy: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("err opening fixture copy: %v", err)
+	}
+	stmts := []string{
+		"INSERT INTO gpkg_metadata (id, md_scope, md_standard_uri, mime_type, metadata) VALUES (2, 'dataset', 'http://www.isotc211.org/2005/gmd', 'text/plain', 'rail lines by the rail authority')",
+		"INSERT INTO gpkg_metadata_reference (reference_scope, table_name, md_file_id) VALUES ('table', 'rail_lines', 2)",
+		"INSERT INTO gpkg_metadata (id, md_scope, md_standard_uri, mime_type, metadata) VALUES (3, 'dataset', 'http://www.isotc211.org/2005/gmd', 'text/plain', 'roads by the road authority')",
+		"INSERT INTO gpkg_metadata_reference (reference_scope, table_name, md_file_id) VALUES ('table', 'roads_lines', 3)",
+	}
+	for _, stmt := range stmts {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("err executing (%v): %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	fn := func(t *testing.T, tc tcase) {
+		p, err := gpkg.NewTileProvider(tc.config)
+		if err != nil {
+			t.Fatalf("err creating NewTileProvider: %v", err)
+			return
+		}
+
+		attribution, err := p.(*gpkg.Provider).Attribution()
+		if err != nil {
+			t.Errorf("err reading attribution: %v", err)
+			return
+		}
+
+		if tc.expectEmpty {
+			if attribution != "" {
+				t.Errorf("expected empty attribution got %v", attribution)
+			}
+			return
+		}
+
+		if !strings.Contains(attribution, tc.contains) {
+			t.Errorf("expected attribution to contain %v got %v", tc.contains, attribution)
+			return
+		}
+		if tc.excludes != "" && strings.Contains(attribution, tc.excludes) {
+			t.Errorf("expected attribution not to contain %v got %v", tc.excludes, attribution)
+		}
+	}
+
+	tests := map[string]tcase{
+		"dataset metadata": tcase{
+			config: map[string]interface{}{
+				"filepath": GPKGAthensFilePath,
+				"layers": []map[string]interface{}{
+					{"name": "rl_lines", "tablename": "rail_lines"},
+				},
+			},
+			contains: "<gco:CharacterString>Athens-osm-20170921</gco:CharacterString>",
+		},
+		"geopackage metadata with table references": tcase{
+			config: map[string]interface{}{
+				"filepath": path,
+				"layers": []map[string]interface{}{
+					{"name": "rl_lines", "tablename": "rail_lines"},
+				},
+			},
+			contains: "<gco:CharacterString>Athens-osm-20170921</gco:CharacterString>",
+			excludes: "roads by the road authority",
+		},
+		"table metadata": tcase{
+			config: map[string]interface{}{
+				"filepath": path,
+				"layers": []map[string]interface{}{
+					{"name": "rl_lines", "tablename": "rail_lines"},
+				},
+			},
+			contains: "rail lines by the rail authority",
+			excludes: "roads by the road authority",
+		},
+		"no metadata": tcase{
+			config: map[string]interface{}{
+				"filepath": GPKGNaturalEarthFilePath,
+				"layers": []map[string]interface{}{
+					{"name": "land", "tablename": "ne_110m_land"},
+				},
+			},
+			expectEmpty: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestGeomColumnName(t *testing.T) {
+	type tcase struct {
+		tile          MockTile
+		expectedNames []string
+	}
+
+	// the points table stores its geometry in the "the_geom" column
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath": GPKGTheGeomFilePath,
+		"layers": []map[string]interface{}{
+			{"name": "points", "tablename": "points", "fields": []string{"name"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		var names []string
+		err := p.TileFeatures(context.TODO(), "points", &tc.tile, func(f *provider.Feature) error {
+			if _, ok := f.Geometry.(geom.Point); !ok {
+				t.Errorf("feature (%v) geometry, expected geom.Point got %T", f.ID, f.Geometry)
+			}
+			if _, ok := f.Tags["the_geom"]; ok {
+				t.Errorf("feature (%v) expected the geometry column to not be a tag", f.ID)
+			}
+			if f.SRID != tegola.WGS84 {
+				t.Errorf("feature (%v) srid, expected %v got %v", f.ID, tegola.WGS84, f.SRID)
+			}
+
+			names = append(names, f.Tags["name"].(string))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tc.expectedNames) {
+			t.Errorf("feature names, expected %v got %v", tc.expectedNames, names)
+		}
+	}
+
+	tests := map[string]tcase{
+		"athens": {
+			tile: MockTile{
+				srid: tegola.WGS84,
+				bufferedExtent: [2][2]float64{
+					{23.6, 37.8},
+					{23.8, 38.0},
+				},
+			},
+			expectedNames: []string{"a", "b"},
+		},
+		"world": {
+			tile: MockTile{
+				srid: tegola.WGS84,
+				bufferedExtent: [2][2]float64{
+					{-180, -85.0511},
+					{180, 85.0511},
+				},
+			},
+			expectedNames: []string{"a", "b", "far"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestMultipleGeomColumns(t *testing.T) {
+	type tcase struct {
+		geomFieldname string
+		tile          MockTile
+		expectedPoint geom.Point
+		expectedCount int
+		expectedErr   error
+	}
+
+	// add a second geometry column, holding the label point of the feature, to a copy of the fixture
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(GPKGTheGeomFilePath)
+	if err != nil {
+		t.Fatalf("err reading fixture: %v", err)
+	}
+	path := filepath.Join(dir, "the_geom.gpkg")
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err writing fixture copy: %v", err)
+	}
+
+	labelPoint := geom.Point{1, 2}
+	wkbBytes, err := wkb.EncodeBytes(labelPoint)
+	if err != nil {
+		t.Fatalf("err encoding wkb: %v", err)
+	}
+	// the gpkg header of a little endian blob without an envelope and srs_id 4326
+	labelBlob := append([]byte{0x47, 0x50, 0x00, 0x01, 0xE6, 0x10, 0x00, 0x00}, wkbBytes...)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("err opening fixture copy: %v", err)
+	}
+	stmts := []string{
+		"ALTER TABLE points ADD COLUMN label_geom BLOB",
+		"INSERT INTO gpkg_geometry_columns (table_name, column_name, geometry_type_name, srs_id, z, m) VALUES ('points', 'label_geom', 'POINT', 4326, 0, 0)",
+	}
+	for _, stmt := range stmts {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("err executing (%v): %v", stmt, err)
+		}
+	}
+	if _, err = db.Exec("UPDATE points SET label_geom = ?", labelBlob); err != nil {
+		t.Fatalf("err setting the label points: %v", err)
+	}
+	db.Close()
+
+	athens := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{23.6, 37.8},
+			{23.8, 38.0},
+		},
+	}
+	world := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		layer := map[string]interface{}{"name": "points", "tablename": "points", "fields": []string{"name"}}
+		if tc.geomFieldname != "" {
+			layer["geometry_fieldname"] = tc.geomFieldname
+		}
+
+		p, err := gpkg.NewTileProvider(map[string]interface{}{
+			"filepath": path,
+			"layers":   []map[string]interface{}{layer},
+		})
+		if tc.expectedErr != nil {
+			if err != tc.expectedErr {
+				t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("err creating NewTileProvider: %v", err)
+		}
+		defer gpkg.Cleanup()
+
+		var count int
+		err = p.TileFeatures(context.TODO(), "points", &tc.tile, func(f *provider.Feature) error {
+			count++
+			pt, ok := f.Geometry.(geom.Point)
+			if !ok {
+				t.Fatalf("feature (%v) geometry, expected geom.Point got %T", f.ID, f.Geometry)
+			}
+			// the label column is the only one holding the label point
+			if (pt == labelPoint) != (tc.expectedPoint == labelPoint) {
+				t.Errorf("feature (%v) geometry, expected the %v column got %v", f.ID, tc.geomFieldname, pt)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		if count != tc.expectedCount {
+			t.Errorf("feature count, expected %v got %v", tc.expectedCount, count)
+		}
+	}
+
+	tests := map[string]tcase{
+		"default column": {
+			tile:          athens,
+			expectedCount: 2,
+		},
+		"geometry column": {
+			geomFieldname: "the_geom",
+			tile:          athens,
+			expectedCount: 2,
+		},
+		// the label points are outside of athens
+		"label column athens": {
+			geomFieldname: "label_geom",
+			tile:          athens,
+			expectedPoint: labelPoint,
+			expectedCount: 0,
+		},
+		"label column world": {
+			geomFieldname: "label_geom",
+			tile:          world,
+			expectedPoint: labelPoint,
+			expectedCount: 3,
+		},
+		"missing column": {
+			geomFieldname: "missing_geom",
+			expectedErr: gpkg.ErrGeomFieldNotFound{
+				LayerName:     "points",
+				Tablename:     "points",
+				GeomFieldname: "missing_geom",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestDateColumns(t *testing.T) {
+	// add date columns to a copy of the fixture
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(GPKGTheGeomFilePath)
+	if err != nil {
+		t.Fatalf("err reading fixture: %v", err)
+	}
+	path := filepath.Join(dir, "the_geom.gpkg")
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err writing fixture copy: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("err opening fixture copy: %v", err)
+	}
+	stmts := []string{
+		"ALTER TABLE points ADD COLUMN observed DATETIME",
+		"ALTER TABLE points ADD COLUMN surveyed DATE",
+		// the ISO8601 text of the gpkg spec
+		"UPDATE points SET observed = '2017-09-21T10:30:00.500Z', surveyed = '2017-09-21' WHERE name = 'a'",
+		// a unix time and a julian day
+		"UPDATE points SET observed = 1506000000, surveyed = 2458017.5 WHERE name = 'b'",
+		"UPDATE points SET observed = 'not a date' WHERE name = 'far'",
+	}
+	for _, stmt := range stmts {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("err executing (%v): %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath": path,
+		"layers": []map[string]interface{}{
+			{"name": "points", "tablename": "points", "fields": []string{"name", "observed", "surveyed"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+	defer gpkg.Cleanup()
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	tags := map[string]map[string]interface{}{}
+	err = p.TileFeatures(context.TODO(), "points", &tile, func(f *provider.Feature) error {
+		tags[f.Tags["name"].(string)] = f.Tags
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err fetching features: %v", err)
+	}
+
+	// the text which isn't a date is left out
+	expected := map[string]map[string]interface{}{
+		"a":   {"name": "a", "observed": "2017-09-21T10:30:00.5Z", "surveyed": "2017-09-21"},
+		"b":   {"name": "b", "observed": "2017-09-21T13:20:00Z", "surveyed": "2017-09-21"},
+		"far": {"name": "far"},
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("tags, expected %v got %v", expected, tags)
+	}
+}
+
+func TestAttributesJoin(t *testing.T) {
+	type tcase struct {
+		join        map[string]interface{}
+		expected    map[string]map[string]interface{}
+		expectedErr error
+	}
+
+	// add an attributes table to a copy of the fixture
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(GPKGTheGeomFilePath)
+	if err != nil {
+		t.Fatalf("err reading fixture: %v", err)
+	}
+	path := filepath.Join(dir, "the_geom.gpkg")
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err writing fixture copy: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("err opening fixture copy: %v", err)
+	}
+	stmts := []string{
+		"CREATE TABLE point_owners (id INTEGER PRIMARY KEY, point_id INTEGER, owner TEXT, rating INTEGER)",
+		"INSERT INTO gpkg_contents (table_name, data_type, identifier) VALUES ('point_owners', 'attributes', 'point_owners')",
+		// the far point has no owner
+		"INSERT INTO point_owners (point_id, owner, rating) VALUES (1, 'alice', 4), (2, 'bob', 5)",
+	}
+	for _, stmt := range stmts {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("err executing (%v): %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		p, err := gpkg.NewTileProvider(map[string]interface{}{
+			"filepath": path,
+			"layers": []map[string]interface{}{
+				{"name": "points", "tablename": "points", "fields": []string{"name"}, "join": tc.join},
+			},
+		})
+		if err != tc.expectedErr {
+			t.Fatalf("err creating NewTileProvider, expected %v got %v", tc.expectedErr, err)
+		}
+		if err != nil {
+			return
+		}
+		defer gpkg.Cleanup()
+
+		tags := map[string]map[string]interface{}{}
+		err = p.TileFeatures(context.TODO(), "points", &tile, func(f *provider.Feature) error {
+			tags[f.Tags["name"].(string)] = f.Tags
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		if !reflect.DeepEqual(tags, tc.expected) {
+			t.Errorf("tags, expected %v got %v", tc.expected, tags)
+		}
+	}
+
+	tests := map[string]tcase{
+		"join": {
+			join: map[string]interface{}{
+				"tablename":      "point_owners",
+				"key":            "fid",
+				"attributes_key": "point_id",
+				"fields":         []string{"owner", "rating"},
+			},
+			expected: map[string]map[string]interface{}{
+				"a":   {"name": "a", "owner": "alice", "rating": int64(4)},
+				"b":   {"name": "b", "owner": "bob", "rating": int64(5)},
+				"far": {"name": "far"},
+			},
+		},
+		"not an attributes table": {
+			join: map[string]interface{}{
+				"tablename": "point_categories",
+				"key":       "fid",
+				"fields":    []string{"category"},
+			},
+			expectedErr: gpkg.ErrAttributesTableNotFound{
+				LayerName: "points",
+				Tablename: "point_categories",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestEmptyAsPoint(t *testing.T) {
+	type tcase struct {
+		emptyAsPoint bool
+		expected     geom.Geometry
+	}
+
+	// add an empty point with an envelope to a copy of the fixture
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(GPKGTheGeomFilePath)
+	if err != nil {
+		t.Fatalf("err reading fixture: %v", err)
+	}
+	path := filepath.Join(dir, "the_geom.gpkg")
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err writing fixture copy: %v", err)
+	}
+
+	// the header flags the geometry as empty and has an XY envelope. the empty point has NaN coordinates
+	var blob bytes.Buffer
+	blob.Write([]byte{0x47, 0x50, 0x00, 0x13})
+	binary.Write(&blob, binary.LittleEndian, int32(tegola.WGS84))
+	binary.Write(&blob, binary.LittleEndian, []float64{10, 20, 30, 40})
+	blob.Write([]byte{0x01})
+	binary.Write(&blob, binary.LittleEndian, uint32(wkb.Point))
+	binary.Write(&blob, binary.LittleEndian, []float64{math.NaN(), math.NaN()})
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("err opening fixture copy: %v", err)
+	}
+	if _, err = db.Exec("INSERT INTO points (fid, the_geom, name) VALUES (100, ?, 'empty')", blob.Bytes()); err != nil {
+		t.Fatalf("err inserting row: %v", err)
+	}
+	if _, err = db.Exec("INSERT INTO rtree_points_the_geom VALUES (100, 10, 20, 30, 40)"); err != nil {
+		t.Fatalf("err inserting rtree row: %v", err)
+	}
+	db.Close()
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		p, err := gpkg.NewTileProvider(map[string]interface{}{
+			"filepath": path,
+			"layers": []map[string]interface{}{
+				{"name": "points", "tablename": "points", "fields": []string{"name"}, "empty_as_point": tc.emptyAsPoint},
+			},
+		})
+		if err != nil {
+			t.Fatalf("err creating NewTileProvider: %v", err)
+		}
+		defer gpkg.Cleanup()
+
+		var got geom.Geometry
+		err = p.TileFeatures(context.TODO(), "points", &tile, func(f *provider.Feature) error {
+			if f.ID == 100 {
+				got = f.Geometry
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		if tc.expected == nil {
+			if pt, ok := got.(geom.Point); ok && pt == (geom.Point{15, 35}) {
+				t.Errorf("geometry, expected the empty geometry got %v", got)
+			}
+			return
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("geometry, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"empty as point": {
+			emptyAsPoint: true,
+			expected:     geom.Point{15, 35},
+		},
+		"empty geometry": {},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	type tcase struct {
+		tile             MockTile
+		expectedFeatures map[string]string
+	}
+
+	// points_categories is a view joining the points table with the point_categories table. views have no spatial index
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath": GPKGTheGeomFilePath,
+		"layers": []map[string]interface{}{
+			{"name": "points_categories", "tablename": "points_categories", "fields": []string{"name", "category"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		features := map[string]string{}
+		err := p.TileFeatures(context.TODO(), "points_categories", &tc.tile, func(f *provider.Feature) error {
+			if _, ok := f.Geometry.(geom.Point); !ok {
+				t.Errorf("feature (%v) geometry, expected geom.Point got %T", f.ID, f.Geometry)
+			}
+
+			features[f.Tags["name"].(string)] = f.Tags["category"].(string)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		if !reflect.DeepEqual(features, tc.expectedFeatures) {
+			t.Errorf("features, expected %v got %v", tc.expectedFeatures, features)
+		}
+	}
+
+	tests := map[string]tcase{
+		"athens": {
+			tile: MockTile{
+				srid: tegola.WGS84,
+				bufferedExtent: [2][2]float64{
+					{23.6, 37.8},
+					{23.8, 38.0},
+				},
+			},
+			expectedFeatures: map[string]string{"a": "cafe", "b": "park"},
+		},
+		"world": {
+			tile: MockTile{
+				srid: tegola.WGS84,
+				bufferedExtent: [2][2]float64{
+					{-180, -85.0511},
+					{180, 85.0511},
+				},
+			},
+			expectedFeatures: map[string]string{"a": "cafe", "b": "park", "far": "cafe"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestIDHash(t *testing.T) {
+	type tcase struct {
+		idHash      bool
+		expectedErr bool
+	}
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	// places is keyed on a text code column
+	readIDs := func(idHash bool) (map[string]uint64, error) {
+		p, err := gpkg.NewTileProvider(map[string]interface{}{
+			"filepath": GPKGTheGeomFilePath,
+			"layers": []map[string]interface{}{
+				{"name": "places", "tablename": "places", "id_fieldname": "code", "id_hash": idHash, "fields": []string{"name"}},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		ids := map[string]uint64{}
+		err = p.TileFeatures(context.TODO(), "places", &tile, func(f *provider.Feature) error {
+			ids[f.Tags["name"].(string)] = f.ID
+			return nil
+		})
+
+		return ids, err
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		ids, err := readIDs(tc.idHash)
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("expected an error got ids %v", ids)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		if len(ids) != 3 {
+			t.Fatalf("features, expected 3 got %v", ids)
+		}
+
+		// each row has its own id
+		seen := map[uint64]string{}
+		for name, id := range ids {
+			if other, ok := seen[id]; ok {
+				t.Errorf("features (%v) and (%v) share the id %v", name, other, id)
+			}
+			seen[id] = name
+		}
+
+		// the same rows get the same ids from another provider
+		again, err := readIDs(tc.idHash)
+		if err != nil {
+			t.Fatalf("err fetching features again: %v", err)
+		}
+		if !reflect.DeepEqual(ids, again) {
+			t.Errorf("ids, expected %v got %v", ids, again)
+		}
+	}
+
+	tests := map[string]tcase{
+		"hashed": {
+			idHash: true,
+		},
+		"not hashed": {
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestMeasures(t *testing.T) {
+	type tcase struct {
+		measures     string
+		expectedTags map[string]interface{}
+		expectedErr  bool
+	}
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{23.6, 37.8},
+			{23.8, 38.0},
+		},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		layerConf := map[string]interface{}{"name": "mileposts", "tablename": "mileposts", "fields": []string{"name"}}
+		if tc.measures != "" {
+			layerConf["measures"] = tc.measures
+		}
+
+		p, err := gpkg.NewTileProvider(map[string]interface{}{
+			"filepath": GPKGTheGeomFilePath,
+			"layers":   []map[string]interface{}{layerConf},
+		})
+		if tc.expectedErr {
+			if _, ok := err.(gpkg.ErrInvalidMeasures); !ok {
+				t.Errorf("expected ErrInvalidMeasures got %v", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("err creating NewTileProvider: %v", err)
+		}
+
+		var count int
+		err = p.TileFeatures(context.TODO(), "mileposts", &tile, func(f *provider.Feature) error {
+			count++
+
+			expected := geom.LineString{{23.70, 37.90}, {23.72, 37.91}, {23.74, 37.92}}
+			if !reflect.DeepEqual(f.Geometry, expected) {
+				t.Errorf("geometry, expected %v got %v", expected, f.Geometry)
+			}
+
+			if !reflect.DeepEqual(f.Tags, tc.expectedTags) {
+				t.Errorf("tags, expected %v got %v", tc.expectedTags, f.Tags)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("err fetching features: %v", err)
+		}
+
+		if count != 1 {
+			t.Errorf("feature count, expected 1 got %v", count)
+		}
+	}
+
+	tests := map[string]tcase{
+		"dropped": {
+			expectedTags: map[string]interface{}{
+				"name": "route 1",
+			},
+		},
+		"minmax": {
+			measures: gpkg.MeasuresMinMax,
+			expectedTags: map[string]interface{}{
+				"name":  "route 1",
+				"m_min": 0.0,
+				"m_max": 3.25,
+			},
+		},
+		"vertices": {
+			measures: gpkg.MeasuresVertices,
+			expectedTags: map[string]interface{}{
+				"name": "route 1",
+				"m":    "0,1.5,3.25",
+			},
+		},
+		"invalid": {
+			measures:    "average",
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestFeatureCount(t *testing.T) {
+	type tcase struct {
+		layer       string
+		bbox        *geom.BoundingBox
+		expected    uint64
+		expectedErr error
+	}
+
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath": GPKGTheGeomFilePath,
+		"layers": []map[string]interface{}{
+			// points has a spatial index, places does not
+			{"name": "points", "tablename": "points"},
+			{"name": "places", "tablename": "places", "id_fieldname": "code", "id_hash": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+
+	// the points and places near Athens, leaving out the third row at 10, 10
+	athens := &geom.BoundingBox{{23, 37}, {24, 38}}
+
+	fn := func(t *testing.T, tc tcase) {
+		// the second read of a full count is cached
+		for i := 0; i < 2; i++ {
+			count, err := p.(*gpkg.Provider).FeatureCount(context.Background(), tc.layer, tc.bbox)
+			if err != tc.expectedErr {
+				t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+			}
+			if count != tc.expected {
+				t.Errorf("count, expected %v got %v", tc.expected, count)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"all points": {
+			layer:    "points",
+			expected: 3,
+		},
+		"points in bbox": {
+			layer:    "points",
+			bbox:     athens,
+			expected: 2,
+		},
+		"all places": {
+			layer:    "places",
+			expected: 3,
+		},
+		"places in bbox": {
+			layer:    "places",
+			bbox:     athens,
+			expected: 2,
+		},
+		"missing layer": {
+			layer:       "missing",
+			expectedErr: gpkg.ErrLayerNotFound{LayerName: "missing"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestValidateGeoPackage(t *testing.T) {
+	type tcase struct {
+		path        string
+		expectedErr error
+	}
+
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a plain sqlite database without the gpkg tables
+	plain := filepath.Join(dir, "plain.sqlite")
+	db, err := sql.Open("sqlite3", plain)
+	if err != nil {
+		t.Fatalf("err opening plain db: %v", err)
+	}
+	if _, err = db.Exec("CREATE TABLE places (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("err creating table: %v", err)
+	}
+	db.Close()
+
+	// a sqlite database with the gpkg tables but without the gpkg application_id
+	unidentified := filepath.Join(dir, "unidentified.sqlite")
+	if db, err = sql.Open("sqlite3", unidentified); err != nil {
+		t.Fatalf("err opening unidentified db: %v", err)
+	}
+	for _, table := range []string{"gpkg_spatial_ref_sys", "gpkg_contents", "gpkg_geometry_columns"} {
+		if _, err = db.Exec("CREATE TABLE " + table + " (id INTEGER PRIMARY KEY)"); err != nil {
+			t.Fatalf("err creating table: %v", err)
+		}
+	}
+	db.Close()
+
+	fn := func(t *testing.T, tc tcase) {
+		err := gpkg.ValidateGeoPackage(tc.path)
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+	}
+
+	tests := map[string]tcase{
+		"version 1.0": {
+			path: GPKGAthensFilePath,
+		},
+		"version 1.2": {
+			path: GPKGTheGeomFilePath,
+		},
+		"plain sqlite": {
+			path:        plain,
+			expectedErr: gpkg.ErrMissingTable{Table: "gpkg_spatial_ref_sys"},
+		},
+		"missing application_id": {
+			path:        unidentified,
+			expectedErr: gpkg.ErrInvalidApplicationID{ApplicationID: 0},
+		},
+		"missing file": {
+			path:        filepath.Join(dir, "missing.gpkg"),
+			expectedErr: gpkg.ErrInvalidFilePath{FilePath: filepath.Join(dir, "missing.gpkg")},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath": GPKGAthensFilePath,
+		"layers": []map[string]interface{}{
+			{"name": "rl_lines", "tablename": "rail_lines"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+
+	hc, ok := p.(provider.HealthChecker)
+	if !ok {
+		t.Fatalf("expected the provider to implement provider.HealthChecker")
+	}
+
+	if err = hc.HealthCheck(context.Background()); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath": GPKGAthensFilePath,
+		"layers": []map[string]interface{}{
+			{"name": "rl_lines", "tablename": "rail_lines"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+
+	c, ok := p.(io.Closer)
+	if !ok {
+		t.Fatalf("expected the provider to implement io.Closer")
+	}
+	if err = c.Close(); err != nil {
+		t.Fatalf("err closing provider: %v", err)
+	}
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	// the queries of a closed provider fail
+	err = p.TileFeatures(context.Background(), "rl_lines", &tile, func(f *provider.Feature) error {
+		return nil
+	})
+	if err == nil || err.Error() != "sql: database is closed" {
+		t.Errorf("error, expected sql: database is closed got %v", err)
+	}
+}
+
+func TestConcurrentReads(t *testing.T) {
+	const readers = 16
+
+	// the journal mode is persisted in the file so read a copy of the fixture
+	dir, err := ioutil.TempDir("", "tegola-gpkg")
+	if err != nil {
+		t.Fatalf("err creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(GPKGTheGeomFilePath)
+	if err != nil {
+		t.Fatalf("err reading fixture: %v", err)
+	}
+	path := filepath.Join(dir, "the_geom.gpkg")
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err writing fixture copy: %v", err)
+	}
+
+	p, err := gpkg.NewTileProvider(map[string]interface{}{
+		"filepath":     path,
+		"wal":          true,
+		"busy_timeout": int64(10000),
+		"layers": []map[string]interface{}{
+			{"name": "places", "tablename": "places", "id_fieldname": "code", "id_hash": true, "fields": []string{"name"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating NewTileProvider: %v", err)
+	}
+
+	// another connection writes to the file while it is read
+	writer, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("err opening writer: %v", err)
+	}
+	defer writer.Close()
+
+	tile := MockTile{
+		srid: tegola.WGS84,
+		bufferedExtent: [2][2]float64{
+			{-180, -85.0511},
+			{180, 85.0511},
+		},
+	}
+
+	done := make(chan struct{})
+	writeErr := make(chan error, 1)
+	go func() {
+		defer close(writeErr)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := writer.Exec("UPDATE places SET name = name"); err != nil {
+				writeErr <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make([]error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				var count int
+				err := p.TileFeatures(context.Background(), "places", &tile, func(f *provider.Feature) error {
+					count++
+					return nil
+				})
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				if count != 3 {
+					errs[i] = fmt.Errorf("features, expected 3 got %v", count)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("reader (%v) err: %v", i, err)
+		}
+	}
+	if err := <-writeErr; err != nil {
+		t.Errorf("writer err: %v", err)
+	}
+}
@@ -1,6 +1,10 @@
 package gpkg
 
-import "github.com/go-spatial/tegola/geom"
+import (
+	"sync"
+
+	"github.com/go-spatial/tegola/geom"
+)
 
 type Layer struct {
 	name          string
@@ -13,6 +17,41 @@ type Layer struct {
 	srid          uint64
 	bbox          geom.BoundingBox
 	sql           string
+	//	if the table has an rtree spatial index. views and tables without one are read without it
+	spatialIndex bool
+	//	how the M values of measured geometries are added to the feature tags. empty to drop the M values
+	measures string
+	//	if ids which are not integers (i.e. text primary keys) are hashed to feature ids
+	idHash bool
+	//	if empty geometries with an envelope are read as a point at the center of the envelope
+	emptyAsPoint bool
+	//	the cached bounds of the features without an envelope. nil disables caching
+	bounds *boundsCache
+	//	the cached number of features of the layer. nil disables caching
+	count *featureCount
+	//	the prepared statements of the layer's tile queries. nil disables preparing the queries
+	stmts *stmtCache
+	//	the attributes table joined to the layer's table. nil if there is no join
+	join *attributesJoin
+}
+
+//	attributesJoin merges the columns of an attributes table's rows into the tags of the features
+//	they are joined to
+type attributesJoin struct {
+	tablename string
+	//	the column of the layer's table the rows are joined on
+	key string
+	//	the column of the attributes table the rows are joined on
+	attributesKey string
+	//	the columns of the attributes table added as tags
+	fieldnames []string
+}
+
+//	featureCount caches the number of features of a layer
+type featureCount struct {
+	sync.Mutex
+	count uint64
+	ok    bool
 }
 
 func (l Layer) Name() string            { return l.name }
@@ -0,0 +1,60 @@
+package gpkg
+
+import (
+	"strconv"
+	"strings"
+)
+
+//	supported values for the layer measures config
+const (
+	//	MeasuresMinMax adds the minimum and maximum M values of the geometry as the m_min and m_max tags
+	MeasuresMinMax = "minmax"
+	//	MeasuresVertices adds the M value of each vertex, comma separated and in vertex order, as the m tag
+	MeasuresVertices = "vertices"
+)
+
+//	tag names the M values are projected into
+const (
+	TagMeasureMin      = "m_min"
+	TagMeasureMax      = "m_max"
+	TagMeasureVertices = "m"
+)
+
+func isValidMeasures(measures string) bool {
+	switch measures {
+	case "", MeasuresMinMax, MeasuresVertices:
+		return true
+	default:
+		return false
+	}
+}
+
+//	addMeasureTags projects the M values of a geometry into the feature tags. tags are not
+//	added for geometries without M values
+func addMeasureTags(tags map[string]interface{}, measures string, ms []float64) {
+	if len(ms) == 0 {
+		return
+	}
+
+	switch measures {
+	case MeasuresMinMax:
+		min, max := ms[0], ms[0]
+		for _, m := range ms[1:] {
+			if m < min {
+				min = m
+			}
+			if m > max {
+				max = m
+			}
+		}
+		tags[TagMeasureMin] = min
+		tags[TagMeasureMax] = max
+
+	case MeasuresVertices:
+		vals := make([]string, len(ms))
+		for i, m := range ms {
+			vals[i] = strconv.FormatFloat(m, 'f', -1, 64)
+		}
+		tags[TagMeasureVertices] = strings.Join(vals, ",")
+	}
+}
@@ -0,0 +1,77 @@
+// +build cgo
+
+package gpkg
+
+import (
+	"strings"
+
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+// Attribution reads the dataset scoped metadata from the gpkg_metadata table and returns it as a string
+// suitable for populating a map's attribution. The metadata is matched to the provider through the
+// gpkg_metadata_reference table: metadata referencing the whole geopackage, or one of the tables of the
+// provider's layers, is included. Metadata without a reference applies to the whole geopackage. If the
+// gpkg_metadata table does not exist or contains no matching metadata an empty string is returned.
+func (p *Provider) Attribution() (string, error) {
+	// the metadata tables are optional as part of the spec. check that they exist before querying them
+	var metadataTables, referenceTables int
+	qtext := "SELECT count(*), (SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'gpkg_metadata_reference') FROM sqlite_master WHERE type = 'table' AND name = 'gpkg_metadata';"
+	if err := p.db.QueryRow(qtext).Scan(&metadataTables, &referenceTables); err != nil {
+		log.Errorf("error during query: %v - %v", qtext, err)
+		return "", err
+	}
+
+	var args []interface{}
+	switch {
+	case metadataTables == 0:
+		return "", nil
+	case referenceTables == 0:
+		// without references all the metadata applies to the whole geopackage
+		qtext = "SELECT metadata FROM gpkg_metadata WHERE md_scope = 'dataset' ORDER BY id;"
+	default:
+		// the tables of the layers configured with a tablename. custom SQL layers have no table
+		for _, l := range p.layers {
+			if l.tablename != "" {
+				args = append(args, l.tablename)
+			}
+		}
+
+		tableClause := ""
+		if len(args) > 0 {
+			tableClause = " OR r.table_name IN (?" + strings.Repeat(", ?", len(args)-1) + ")"
+		}
+
+		qtext = "SELECT metadata FROM gpkg_metadata m WHERE m.md_scope = 'dataset' AND (" +
+			"NOT EXISTS (SELECT 1 FROM gpkg_metadata_reference r WHERE r.md_file_id = m.id) OR " +
+			"EXISTS (SELECT 1 FROM gpkg_metadata_reference r WHERE r.md_file_id = m.id AND (r.reference_scope = 'geopackage'" + tableClause + "))" +
+			") ORDER BY m.id;"
+	}
+
+	rows, err := p.db.Query(qtext, args...)
+	if err != nil {
+		log.Errorf("error during query: %v - %v", qtext, err)
+		return "", err
+	}
+	defer rows.Close()
+
+	var metadata []string
+	for rows.Next() {
+		var md string
+		if err = rows.Scan(&md); err != nil {
+			return "", err
+		}
+
+		md = strings.TrimSpace(md)
+		if md == "" {
+			continue
+		}
+
+		metadata = append(metadata, md)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(metadata, "\n"), nil
+}
@@ -0,0 +1,42 @@
+package gpkg
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+//	stmtCache holds the prepared statements of a layer's queries, keyed by the query text, so the tile
+//	queries, which only differ by their bound parameters, are parsed by the database once
+type stmtCache struct {
+	sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStmtCache() *stmtCache {
+	return &stmtCache{
+		stmts: map[string]*sql.Stmt{},
+	}
+}
+
+//	query runs the query with the args through the statement prepared for the query text, which is
+//	prepared on first use. a nil cache runs the query without preparing it
+func (c *stmtCache) query(ctx context.Context, db *sql.DB, qtext string, args ...interface{}) (*sql.Rows, error) {
+	if c == nil {
+		return db.QueryContext(ctx, qtext, args...)
+	}
+
+	c.Lock()
+	stmt, ok := c.stmts[qtext]
+	if !ok {
+		var err error
+		if stmt, err = db.PrepareContext(ctx, qtext); err != nil {
+			c.Unlock()
+			return nil, err
+		}
+		c.stmts[qtext] = stmt
+	}
+	c.Unlock()
+
+	return stmt.QueryContext(ctx, args...)
+}
@@ -0,0 +1,149 @@
+// +build cgo
+
+package gpkg
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// countingDriver is a database driver which counts the statements it prepares and records the args of
+// each query. its queries return no rows
+type countingDriver struct {
+	sync.Mutex
+	prepares int
+	args     [][]driver.Value
+}
+
+func (d *countingDriver) Open(name string) (driver.Conn, error) { return countingConn{d}, nil }
+
+type countingConn struct {
+	d *countingDriver
+}
+
+func (c countingConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.Lock()
+	defer c.d.Unlock()
+
+	c.d.prepares++
+	return countingStmt(c), nil
+}
+
+func (c countingConn) Close() error { return nil }
+
+func (c countingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type countingStmt struct {
+	d *countingDriver
+}
+
+func (s countingStmt) Close() error  { return nil }
+func (s countingStmt) NumInput() int { return -1 }
+
+func (s countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s countingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+
+	s.d.args = append(s.d.args, args)
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return []string{DefaultIDFieldName, DefaultGeomFieldName} }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestReadFeaturesPreparedStatements(t *testing.T) {
+	type tcase struct {
+		stmts            *stmtCache
+		expectedPrepares int
+	}
+
+	extents := []geom.BoundingBox{
+		{{0, 0}, {10, 10}},
+		{{10, 0}, {20, 10}},
+		{{10, 10}, {20, 20}},
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		d := &countingDriver{}
+		db := sql.OpenDB(connector{d})
+		defer db.Close()
+
+		layer := Layer{
+			name:          "points",
+			tablename:     "points",
+			idFieldname:   DefaultIDFieldName,
+			geomFieldname: DefaultGeomFieldName,
+			geomType:      geom.Point{},
+			srid:          tegola.WGS84,
+			spatialIndex:  true,
+			stmts:         tc.stmts,
+		}
+
+		// a tile request for each extent
+		for _, extent := range extents {
+			err := readFeatures(context.Background(), db, layer, 5, extent, "", func(f *provider.Feature) error {
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("err reading features: %v", err)
+			}
+		}
+
+		if d.prepares != tc.expectedPrepares {
+			t.Errorf("prepares, expected %v got %v", tc.expectedPrepares, d.prepares)
+		}
+
+		// the extents are bound as the query parameters
+		var expectedArgs [][]driver.Value
+		for _, extent := range extents {
+			expectedArgs = append(expectedArgs, []driver.Value{extent.MaxX(), extent.MinX(), extent.MaxY(), extent.MinY()})
+		}
+		if !reflect.DeepEqual(d.args, expectedArgs) {
+			t.Errorf("args, expected %v got %v", expectedArgs, d.args)
+		}
+	}
+
+	tests := map[string]tcase{
+		"prepared once": {
+			stmts:            newStmtCache(),
+			expectedPrepares: 1,
+		},
+		"without a statement cache": {
+			expectedPrepares: len(extents),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// connector opens the connections of a countingDriver for sql.OpenDB
+type connector struct {
+	d *countingDriver
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                            { return c.d }
@@ -2,10 +2,14 @@ package gpkg
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-spatial/tegola/geom"
+	"github.com/go-spatial/tegola/provider"
 )
 
 const (
@@ -40,3 +44,103 @@ func replaceTokens(qtext string, zoom uint64, extent geom.BoundingBox) string {
 
 	return tokenReplacer.Replace(qtext)
 }
+
+//	parameterizeTokens replaces the tokens of the query with placeholders, so the query text is the same
+//	for every tile, and returns the values bound to the placeholders in order. the BBOX token binds the
+//	extent as [maxx, minx, maxy, miny] and the ZOOM token binds the zoom
+func parameterizeTokens(qtext string, zoom uint64, extent geom.BoundingBox) (string, []interface{}) {
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+
+	for {
+		i, j := strings.Index(qtext, bboxToken), strings.Index(qtext, zoomToken)
+		switch {
+		case i >= 0 && (j < 0 || i < j):
+			b.WriteString(qtext[:i])
+			b.WriteString("minx <= ? AND maxx >= ? AND miny <= ? AND maxy >= ?")
+			args = append(args, extent.MaxX(), extent.MinX(), extent.MaxY(), extent.MinY())
+			qtext = qtext[i+len(bboxToken):]
+		case j >= 0:
+			b.WriteString(qtext[:j])
+			b.WriteString("?")
+			args = append(args, int64(zoom))
+			qtext = qtext[j+len(zoomToken):]
+		default:
+			b.WriteString(qtext)
+			return b.String(), args
+		}
+	}
+}
+
+//	featureID converts the value of a layer's id column to a feature id. when hash is set, values
+//	which are not integers (i.e. text primary keys) and negative integers are hashed (64 bit FNV-1a
+//	of the value's text) to an id which is the same for the same value across queries
+func featureID(v interface{}, hash bool) (uint64, error) {
+	if !hash {
+		return provider.ConvertFeatureID(v)
+	}
+
+	var text []byte
+	switch id := v.(type) {
+	case int64:
+		if id >= 0 {
+			return uint64(id), nil
+		}
+		//	a negative id would wrap to a large id, which another row could have
+		text = []byte(fmt.Sprint(id))
+	case []byte:
+		text = id
+	case string:
+		text = []byte(id)
+	default:
+		text = []byte(fmt.Sprint(id))
+	}
+
+	h := fnv.New64a()
+	h.Write(text)
+
+	return h.Sum64(), nil
+}
+
+//	the declared types of gpkg date columns
+const (
+	declTypeDate     = "DATE"
+	declTypeDateTime = "DATETIME"
+)
+
+//	julianUnixEpoch is the julian day number of the unix epoch
+const julianUnixEpoch = 2440587.5
+
+//	dateTag formats the value of a DATE or DATETIME column, as declared in the table schema, as an
+//	RFC3339 string. DATE values are formatted as a full-date (2006-01-02) and DATETIME values as a
+//	UTC date-time. the sqlite driver parses the ISO8601 text and unix time integers of these columns
+//	to a time.Time. sqlite stores dates as real numbers of julian days too. false is returned if the
+//	value is not a date, i.e. text the driver couldn't parse
+func dateTag(v interface{}, declType string) (string, bool) {
+	var t time.Time
+	switch d := v.(type) {
+	case time.Time:
+		t = d
+	case float64:
+		if math.IsNaN(d) || math.IsInf(d, 0) {
+			return "", false
+		}
+		ms := math.Round((d - julianUnixEpoch) * 86400 * 1000)
+		t = time.Unix(0, int64(ms)*int64(time.Millisecond))
+	default:
+		return "", false
+	}
+
+	//	the driver parses text which isn't a date to the zero time
+	if t.IsZero() {
+		return "", false
+	}
+
+	t = t.UTC()
+	if declType == declTypeDate {
+		return t.Format("2006-01-02"), true
+	}
+	return t.Format(time.RFC3339Nano), true
+}
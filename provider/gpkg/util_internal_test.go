@@ -1,7 +1,11 @@
 package gpkg
 
 import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-spatial/tegola/geom"
 )
@@ -92,3 +96,175 @@ func TestReplaceTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestParameterizeTokens(t *testing.T) {
+	type tcase struct {
+		qtext        string
+		zoom         uint64
+		extent       geom.BoundingBox
+		expected     string
+		expectedArgs []interface{}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		output, args := parameterizeTokens(tc.qtext, tc.zoom, tc.extent)
+
+		if tc.expected != output {
+			t.Errorf("expected %v\n got\n %v", tc.expected, output)
+		}
+		if !reflect.DeepEqual(tc.expectedArgs, args) {
+			t.Errorf("args, expected %v got %v", tc.expectedArgs, args)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no tokens": {
+			qtext:    "SELECT fid, geom FROM points",
+			expected: "SELECT fid, geom FROM points",
+		},
+		"zoom bbox zoom": {
+			qtext:        "SELECT fid, geom FROM points WHERE min_zoom <= !ZOOM! AND !BBOX! AND max_zoom >= !ZOOM!",
+			zoom:         9,
+			extent:       geom.BoundingBox{{-180, -85.0511}, {180, 85.0511}},
+			expected:     "SELECT fid, geom FROM points WHERE min_zoom <= ? AND minx <= ? AND maxx >= ? AND miny <= ? AND maxy >= ? AND max_zoom >= ?",
+			expectedArgs: []interface{}{int64(9), 180.0, -180.0, 85.0511, -85.0511, int64(9)},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestDateTag(t *testing.T) {
+	type tcase struct {
+		value    interface{}
+		declType string
+		expected string
+		// false if the value is not a date
+		expectedOK bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		got, ok := dateTag(tc.value, tc.declType)
+		if ok != tc.expectedOK {
+			t.Fatalf("ok, expected %v got %v", tc.expectedOK, ok)
+		}
+		if got != tc.expected {
+			t.Errorf("date, expected %v got %v", tc.expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"datetime": {
+			value:      time.Date(2017, 9, 21, 10, 30, 0, 500000000, time.UTC),
+			declType:   declTypeDateTime,
+			expected:   "2017-09-21T10:30:00.5Z",
+			expectedOK: true,
+		},
+		"datetime with a time zone": {
+			value:      time.Date(2017, 9, 21, 12, 30, 0, 0, time.FixedZone("", 2*60*60)),
+			declType:   declTypeDateTime,
+			expected:   "2017-09-21T10:30:00Z",
+			expectedOK: true,
+		},
+		"date": {
+			value:      time.Date(2017, 9, 21, 0, 0, 0, 0, time.UTC),
+			declType:   declTypeDate,
+			expected:   "2017-09-21",
+			expectedOK: true,
+		},
+		"julian day": {
+			value:      2458017.5,
+			declType:   declTypeDateTime,
+			expected:   "2017-09-21T00:00:00Z",
+			expectedOK: true,
+		},
+		// the driver parses text which isn't a date to the zero time
+		"zero time": {
+			value:    time.Time{},
+			declType: declTypeDateTime,
+		},
+		"text": {
+			value:    []byte("not a date"),
+			declType: declTypeDate,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestFeatureID(t *testing.T) {
+	type tcase struct {
+		value    interface{}
+		hash     bool
+		expected uint64
+		// when set, the expected id is the hash of its text
+		hashOf      interface{}
+		expectedErr bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		got, err := featureID(tc.value, tc.hash)
+		if tc.expectedErr {
+			if err == nil {
+				t.Fatalf("expected err, got id %v", got)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		expected := tc.expected
+		if tc.hashOf != nil {
+			h := fnv.New64a()
+			h.Write([]byte(fmt.Sprint(tc.hashOf)))
+			expected = h.Sum64()
+		}
+		if got != expected {
+			t.Errorf("id, expected %v got %v", expected, got)
+		}
+	}
+
+	tests := map[string]tcase{
+		"integer": {
+			value:    int64(42),
+			expected: 42,
+		},
+		"text not hashed": {
+			value:       "abc",
+			expectedErr: true,
+		},
+		"hashed integer": {
+			value:    int64(42),
+			hash:     true,
+			expected: 42,
+		},
+		"hashed text": {
+			value:  []byte("abc"),
+			hash:   true,
+			hashOf: "abc",
+		},
+		"hashed negative integer": {
+			value:  int64(-1),
+			hash:   true,
+			hashOf: "-1",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
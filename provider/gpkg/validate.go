@@ -0,0 +1,76 @@
+// +build cgo
+
+package gpkg
+
+import (
+	"database/sql"
+	"os"
+)
+
+//	the application_id of GeoPackage files, the ASCII of "GP10", "GP11" and "GPKG"
+const (
+	ApplicationIDGP10 = 0x47503130
+	ApplicationIDGP11 = 0x47503131
+	ApplicationIDGPKG = 0x47504B47
+)
+
+//	MinUserVersion is the lowest user_version of a GeoPackage with the "GPKG" application_id, version 1.2.0.
+//	files of earlier versions are identified by their application_id alone
+const MinUserVersion = 10200
+
+//	requiredTables are the tables every GeoPackage must have
+var requiredTables = []string{
+	"gpkg_spatial_ref_sys",
+	"gpkg_contents",
+	"gpkg_geometry_columns",
+}
+
+//	ValidateGeoPackage checks the file at path is a GeoPackage. the required gpkg_spatial_ref_sys,
+//	gpkg_contents and gpkg_geometry_columns tables must exist, and the application_id and
+//	user_version pragmas must identify a GeoPackage. the first missing requirement is returned
+func ValidateGeoPackage(path string) error {
+	//	opening a missing file would create it
+	if _, err := os.Stat(path); err != nil {
+		return ErrInvalidFilePath{path}
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, table := range requiredTables {
+		var count int
+		qtext := "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?;"
+		if err := db.QueryRow(qtext, table).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrMissingTable{Table: table}
+		}
+	}
+
+	var applicationID int64
+	if err := db.QueryRow("PRAGMA application_id;").Scan(&applicationID); err != nil {
+		return err
+	}
+
+	switch applicationID {
+	case ApplicationIDGP10, ApplicationIDGP11:
+		return nil
+	case ApplicationIDGPKG:
+	default:
+		return ErrInvalidApplicationID{ApplicationID: applicationID}
+	}
+
+	var userVersion int64
+	if err := db.QueryRow("PRAGMA user_version;").Scan(&userVersion); err != nil {
+		return err
+	}
+	if userVersion < MinUserVersion {
+		return ErrInvalidUserVersion{UserVersion: userVersion}
+	}
+
+	return nil
+}
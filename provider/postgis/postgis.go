@@ -353,8 +353,19 @@ func (p Provider) Layers() ([]provider.LayerInfo, error) {
 	return ls, nil
 }
 
+//	SRID returns the SRID of the layer's geometry field. implements provider.SRIDer
+func (p Provider) SRID(layer string) uint64 {
+	return p.layers[layer].srid
+}
+
 //	TileFeatures adheres to the provider.Tiler interface
 func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	return p.TileFeaturesWithFilter(ctx, layer, "", tile, fn)
+}
+
+//	TileFeaturesWithFilter streams the layer's features matching the SQL filter to fn. an empty filter matches all
+//	features. implements provider.SQLFilterer
+func (p Provider) TileFeaturesWithFilter(ctx context.Context, layer string, filter string, tile provider.Tile, fn func(f *provider.Feature) error) error {
 	//	fetch the provider layer
 	plyr, ok := p.Layer(layer)
 	if !ok {
@@ -366,6 +377,10 @@ func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.
 		return fmt.Errorf("error replacing layer tokens for layer (%v) SQL (%v): %v", layer, sql, err)
 	}
 
+	if filter != "" {
+		sql = filterSQL(sql, filter)
+	}
+
 	if strings.Contains(os.Getenv("SQL_DEBUG"), "EXECUTE_SQL") {
 		log.Printf("SQL_DEBUG:EXECUTE_SQL for layer (%v): %v", layer, sql)
 	}
@@ -427,3 +442,17 @@ func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.
 
 	return nil
 }
+
+//	HealthCheck verifies a connection to the database can be made and a query executed
+func (p Provider) HealthCheck(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var one int
+	if err := p.pool.QueryRow("SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("postgis: health check failed: %v", err)
+	}
+
+	return nil
+}
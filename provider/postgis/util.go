@@ -104,6 +104,13 @@ func replaceTokens(sql string, srid uint64, tile provider.Tile) (string, error)
 	return tokenReplacer.Replace(sql), nil
 }
 
+//	filterSQL wraps the layer's SQL so only the rows matching the filter predicate are returned
+func filterSQL(sql string, filter string) string {
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+
+	return fmt.Sprintf("SELECT * FROM (%v) AS tegola_filtered WHERE (%v)", sql, filter)
+}
+
 func transformVal(valType pgx.Oid, val interface{}) (interface{}, error) {
 	switch valType {
 	default:
@@ -41,3 +41,29 @@ func TestReplaceTokens(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterSQL(t *testing.T) {
+	testcases := []struct {
+		sql      string
+		filter   string
+		expected string
+	}{
+		{
+			sql:      "SELECT gid, geom FROM foo WHERE geom && !BBOX!",
+			filter:   "type = 'road'",
+			expected: "SELECT * FROM (SELECT gid, geom FROM foo WHERE geom && !BBOX!) AS tegola_filtered WHERE (type = 'road')",
+		},
+		{
+			sql:      " SELECT gid, geom FROM foo; ",
+			filter:   "population > 1000 OR capital",
+			expected: "SELECT * FROM (SELECT gid, geom FROM foo) AS tegola_filtered WHERE (population > 1000 OR capital)",
+		},
+	}
+
+	for i, tc := range testcases {
+		sql := filterSQL(tc.sql, tc.filter)
+		if sql != tc.expected {
+			t.Errorf("[%v] incorrect sql, Expected (%v) Got (%v)", i, tc.expected, sql)
+		}
+	}
+}
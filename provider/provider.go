@@ -26,6 +26,27 @@ type Tiler interface {
 	Layers() ([]LayerInfo, error)
 }
 
+// HealthChecker is an optional interface a Tiler can implement to report if its data source is reachable.
+type HealthChecker interface {
+	// HealthCheck returns an error if the provider is unable to serve features
+	HealthCheck(ctx context.Context) error
+}
+
+// SRIDer is an optional interface a Tiler can implement to declare the native SRID of a layer's geometries.
+// The declared SRID is used as the source SRID when reprojecting the layer's features, in place of the SRID
+// each feature is decoded with.
+type SRIDer interface {
+	// SRID returns the SRID of the named layer's geometries, 0 if the layer is unknown
+	SRID(layer string) uint64
+}
+
+// MVTTiler is an optional interface a Tiler can implement to serve a layer's tiles already encoded as MVT.
+// The encoded tile is included in the map's tile as it is, bypassing feature decoding and encoding.
+type MVTTiler interface {
+	// MVTTile returns the encoded MVT of the named layer for the tile. an empty slice is returned for an empty tile
+	MVTTile(ctx context.Context, layer string, t Tile) ([]byte, error)
+}
+
 type LayerInfo interface {
 	Name() string
 	GeomType() geom.Geometry
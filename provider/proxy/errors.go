@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingLayerName = errors.New("proxy: layer is missing 'name'")
+)
+
+type ErrInvalidURL struct {
+	URL string
+}
+
+func (e ErrInvalidURL) Error() string {
+	return fmt.Sprintf("proxy: invalid url template, expected {z}, {x} and {y} placeholders: %v", e.URL)
+}
+
+type ErrInvalidTimeout struct {
+	Timeout int64
+}
+
+func (e ErrInvalidTimeout) Error() string {
+	return fmt.Sprintf("proxy: invalid timeout (%v), expected a positive number of seconds", e.Timeout)
+}
+
+type ErrLayerNotFound struct {
+	LayerName string
+}
+
+func (e ErrLayerNotFound) Error() string {
+	return fmt.Sprintf("proxy: layer (%v) not found", e.LayerName)
+}
+
+type ErrFeaturesUnsupported struct {
+	LayerName string
+}
+
+func (e ErrFeaturesUnsupported) Error() string {
+	return fmt.Sprintf("proxy: layer (%v) tiles are passed through, reading their features is not supported", e.LayerName)
+}
+
+type ErrUpstreamStatus struct {
+	URL        string
+	StatusCode int
+}
+
+func (e ErrUpstreamStatus) Error() string {
+	return fmt.Sprintf("proxy: upstream tile (%v) responded with status %v", e.URL, e.StatusCode)
+}
@@ -0,0 +1,26 @@
+package proxy
+
+import (
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
+)
+
+type Layer struct {
+	name string
+	//	the upstream tile URL template with {z}, {x} and {y} placeholders
+	url string
+}
+
+func (l Layer) Name() string {
+	return l.name
+}
+
+//	GeomType returns nil as the geometries of the upstream tiles are not known
+func (l Layer) GeomType() geom.Geometry {
+	return nil
+}
+
+//	SRID returns WebMercator, the projection of XYZ tiles
+func (l Layer) SRID() uint64 {
+	return tegola.WebMercator
+}
@@ -0,0 +1,180 @@
+//	Package proxy implements a provider which serves the tiles of an upstream XYZ tile server, such as
+//	another tegola instance. The upstream tiles are passed through as they are, without decoding their
+//	features, so a map of proxy layers acts as a caching reverse proxy for the upstream server.
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/util/dict"
+)
+
+const Name = "proxy"
+
+//	config keys
+const (
+	ConfigKeyLayers    = "layers"
+	ConfigKeyLayerName = "name"
+	ConfigKeyURL       = "url"
+	ConfigKeyHeaders   = "headers"
+	ConfigKeyTimeout   = "timeout"
+)
+
+//	DefaultTimeout is the number of seconds an upstream tile request may take when no timeout is configured
+const DefaultTimeout = 10
+
+func init() {
+	provider.Register(Name, NewTileProvider, nil)
+}
+
+//	NewTileProvider sets up a proxy provider. each layer is configured with the URL template of its
+//	upstream tiles. the headers are sent with every upstream request
+func NewTileProvider(config map[string]interface{}) (provider.Tiler, error) {
+	conf := dict.M(config)
+
+	timeout := int64(DefaultTimeout)
+	timeout, err := conf.Int64(ConfigKeyTimeout, &timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		return nil, ErrInvalidTimeout{timeout}
+	}
+
+	headers := http.Header{}
+	if _, ok := config[ConfigKeyHeaders]; ok {
+		headersConf, err := conf.Dict(ConfigKeyHeaders)
+		if err != nil {
+			return nil, err
+		}
+		for k := range headersConf {
+			v, err := headersConf.String(k, nil)
+			if err != nil {
+				return nil, fmt.Errorf("for header (%v): %v", k, err)
+			}
+			headers.Set(k, v)
+		}
+	}
+
+	layers, ok := config[ConfigKeyLayers].([]map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected %v to be a []map[string]interface{}", ConfigKeyLayers)
+	}
+
+	p := Provider{
+		layers:  make(map[string]Layer, len(layers)),
+		headers: headers,
+		client: &http.Client{
+			Timeout: time.Duration(timeout) * time.Second,
+		},
+	}
+
+	for i, v := range layers {
+		layerConf := dict.M(v)
+
+		layerName, err := layerConf.String(ConfigKeyLayerName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) we got the following error trying to get the layer's name field: %v", i, err)
+		}
+		if layerName == "" {
+			return nil, ErrMissingLayerName
+		}
+
+		if _, ok := p.layers[layerName]; ok {
+			return nil, fmt.Errorf("layer name (%v) is duplicated", layerName)
+		}
+
+		url, err := layerConf.String(ConfigKeyURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+		//	the template must address the tiles
+		for _, token := range []string{"{z}", "{x}", "{y}"} {
+			if !strings.Contains(url, token) {
+				return nil, ErrInvalidURL{url}
+			}
+		}
+
+		p.layers[layerName] = Layer{
+			name: layerName,
+			url:  url,
+		}
+	}
+
+	return &p, nil
+}
+
+//	Provider passes the tiles of upstream tile servers through
+type Provider struct {
+	//	map of layer name and corresponding layer
+	layers map[string]Layer
+	//	sent with every upstream request
+	headers http.Header
+	client  *http.Client
+}
+
+func (p *Provider) Layers() ([]provider.LayerInfo, error) {
+	ls := make([]provider.LayerInfo, 0, len(p.layers))
+	for _, l := range p.layers {
+		ls = append(ls, l)
+	}
+
+	return ls, nil
+}
+
+//	TileFeatures is not supported as the upstream tiles are not decoded. the tiles are read with MVTTile
+func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	return ErrFeaturesUnsupported{layer}
+}
+
+//	MVTTile fetches the layer's tile from the upstream server. a missing upstream tile (404 or 204) is an empty tile
+func (p *Provider) MVTTile(ctx context.Context, layer string, tile provider.Tile) ([]byte, error) {
+	l, ok := p.layers[layer]
+	if !ok {
+		return nil, ErrLayerNotFound{layer}
+	}
+
+	z, x, y := tile.ZXY()
+	url := strings.NewReplacer(
+		"{z}", strconv.FormatUint(z, 10),
+		"{x}", strconv.FormatUint(x, 10),
+		"{y}", strconv.FormatUint(y, 10),
+	).Replace(l.url)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	//	use the request context so the upstream request is canceled with the tile request
+	req = req.WithContext(ctx)
+
+	for k, v := range p.headers {
+		req.Header[k] = v
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusNoContent:
+		return []byte{}, nil
+	default:
+		return nil, ErrUpstreamStatus{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,214 @@
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider/proxy"
+)
+
+// upstream serves a tile with a single layer named after the requested tile
+type upstream struct {
+	sync.Mutex
+	// the paths of the requests
+	requests []string
+	// the value of the X-Api-Key header of each request
+	apiKeys []string
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.Lock()
+	u.requests = append(u.requests, r.URL.Path)
+	u.apiKeys = append(u.apiKeys, r.Header.Get("X-Api-Key"))
+	u.Unlock()
+
+	if r.URL.Path == "/tiles/0/0/0.pbf" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Write(upstreamTile(r.URL.Path))
+}
+
+func upstreamTile(path string) []byte {
+	version := uint32(2)
+	extent := uint32(4096)
+	name := "upstream " + path
+
+	b, err := proto.Marshal(&vectorTile.Tile{
+		Layers: []*vectorTile.Tile_Layer{
+			{
+				Version: &version,
+				Name:    &name,
+				Extent:  &extent,
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+func TestNewTileProvider(t *testing.T) {
+	type tcase struct {
+		config      map[string]interface{}
+		expectedErr error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		_, err := proxy.NewTileProvider(tc.config)
+		if err != tc.expectedErr {
+			t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+		}
+	}
+
+	tests := map[string]tcase{
+		"valid": {
+			config: map[string]interface{}{
+				"timeout": int64(5),
+				"headers": map[string]interface{}{"X-Api-Key": "secret"},
+				"layers": []map[string]interface{}{
+					{"name": "tiles", "url": "https://upstream/{z}/{x}/{y}.pbf"},
+				},
+			},
+		},
+		"missing placeholder": {
+			config: map[string]interface{}{
+				"layers": []map[string]interface{}{
+					{"name": "tiles", "url": "https://upstream/{z}/{x}.pbf"},
+				},
+			},
+			expectedErr: proxy.ErrInvalidURL{URL: "https://upstream/{z}/{x}.pbf"},
+		},
+		"missing layer name": {
+			config: map[string]interface{}{
+				"layers": []map[string]interface{}{
+					{"name": "", "url": "https://upstream/{z}/{x}/{y}.pbf"},
+				},
+			},
+			expectedErr: proxy.ErrMissingLayerName,
+		},
+		"invalid timeout": {
+			config: map[string]interface{}{
+				"timeout": int64(0),
+				"layers": []map[string]interface{}{
+					{"name": "tiles", "url": "https://upstream/{z}/{x}/{y}.pbf"},
+				},
+			},
+			expectedErr: proxy.ErrInvalidTimeout{Timeout: 0},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestProxyTile(t *testing.T) {
+	u := &upstream{}
+	server := httptest.NewServer(u)
+	defer server.Close()
+
+	p, err := proxy.NewTileProvider(map[string]interface{}{
+		"headers": map[string]interface{}{"X-Api-Key": "secret"},
+		"layers": []map[string]interface{}{
+			{"name": "tiles", "url": server.URL + "/tiles/{z}/{x}/{y}.pbf"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err creating provider: %v", err)
+	}
+
+	a := &atlas.Atlas{}
+	a.SetCache(memory.New())
+	a.RegisterProvider("upstream", p)
+
+	m := atlas.NewWebMercatorMap("proxied")
+	m.Layers = []atlas.Layer{
+		{
+			Name:              "tiles",
+			ProviderLayerName: "tiles",
+			ProviderName:      "upstream",
+		},
+	}
+	if err = a.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	if m, err = a.Map("proxied"); err != nil {
+		t.Fatalf("err fetching map: %v", err)
+	}
+
+	type tcase struct {
+		z, x, y  uint64
+		expected []byte
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		if err := a.SeedMapTile(context.Background(), m, tc.z, tc.x, tc.y); err != nil {
+			t.Fatalf("err seeding tile: %v", err)
+		}
+
+		key := cache.Key{MapName: "proxied", Z: int(tc.z), X: int(tc.x), Y: int(tc.y)}
+		b, hit, err := a.GetCache().Get(&key)
+		if err != nil {
+			t.Fatalf("err reading cache: %v", err)
+		}
+		if !hit {
+			t.Fatalf("expected the tile to be cached")
+		}
+
+		// the upstream tile is passed through as it is
+		if !bytes.Equal(b, tc.expected) {
+			t.Errorf("tile, expected %v got %v", tc.expected, b)
+		}
+	}
+
+	tests := map[string]tcase{
+		"tile": {
+			z:        2,
+			x:        1,
+			y:        3,
+			expected: upstreamTile("/tiles/2/1/3.pbf"),
+		},
+		"missing upstream tile": {
+			z:        0,
+			x:        0,
+			y:        0,
+			expected: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+
+	// each tile is requested from upstream once with the configured headers
+	u.Lock()
+	defer u.Unlock()
+	if len(u.requests) != len(tests) {
+		t.Errorf("upstream requests, expected %v got %v", len(tests), u.requests)
+	}
+	for i := range u.apiKeys {
+		if u.apiKeys[i] != "secret" {
+			t.Errorf("request (%v) header, expected secret got %v", u.requests[i], u.apiKeys[i])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"strings"
+)
+
+// SQLFilterer is an optional interface a SQL backed Tiler can implement to limit the features of a layer
+// with a predicate which is added to the WHERE clause of the tile query (i.e. "highway IS NOT NULL")
+type SQLFilterer interface {
+	// TileFeaturesWithFilter is TileFeatures with only the features matching the filter streamed to fn
+	TileFeaturesWithFilter(ctx context.Context, layer string, filter string, t Tile, fn func(f *Feature) error) error
+}
+
+// ValidateSQLFilter checks a filter is a single SQL predicate. Filters containing statement terminators,
+// comments, unterminated quotes or unbalanced parentheses are rejected so a filter can't alter the tile
+// query beyond its WHERE clause.
+func ValidateSQLFilter(filter string) error {
+	if strings.TrimSpace(filter) == "" {
+		return ErrInvalidSQLFilter{Filter: filter, Reason: "empty filter"}
+	}
+
+	var (
+		// the quote character of the current string literal or quoted identifier, 0 when not quoted
+		quote rune
+		depth int
+		prev  rune
+	)
+	for _, r := range filter {
+		if quote != 0 {
+			// a doubled quote is an escaped quote which toggles out and back in
+			if r == quote {
+				quote = 0
+			}
+			prev = r
+			continue
+		}
+
+		switch {
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ';':
+			return ErrInvalidSQLFilter{Filter: filter, Reason: "statement terminator"}
+		case r == '-' && prev == '-', r == '*' && prev == '/', r == '/' && prev == '*':
+			return ErrInvalidSQLFilter{Filter: filter, Reason: "comment"}
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return ErrInvalidSQLFilter{Filter: filter, Reason: "unbalanced parentheses"}
+			}
+		}
+
+		prev = r
+	}
+
+	if quote != 0 {
+		return ErrInvalidSQLFilter{Filter: filter, Reason: "unterminated quote"}
+	}
+	if depth != 0 {
+		return ErrInvalidSQLFilter{Filter: filter, Reason: "unbalanced parentheses"}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestValidateSQLFilter(t *testing.T) {
+	type tcase struct {
+		filter string
+		// expected is the reason of the ErrInvalidSQLFilter, empty for a valid filter
+		expected string
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		err := provider.ValidateSQLFilter(tc.filter)
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			return
+		}
+
+		e, ok := err.(provider.ErrInvalidSQLFilter)
+		if !ok {
+			t.Fatalf("error, expected ErrInvalidSQLFilter got %v", err)
+		}
+		if e.Reason != tc.expected {
+			t.Errorf("reason, expected %v got %v", tc.expected, e.Reason)
+		}
+	}
+
+	tests := map[string]tcase{
+		"comparison":         {filter: "type = 'road'"},
+		"grouped":            {filter: "(population > 1000 OR capital) AND name IS NOT NULL"},
+		"quoted terminator":  {filter: "name = 'a;b--c'"},
+		"escaped quote":      {filter: "name = 'O''Brien'"},
+		"quoted identifier":  {filter: `"road type" = 'primary'`},
+		"empty":              {filter: " ", expected: "empty filter"},
+		"terminator":         {filter: "1 = 1; DROP TABLE roads", expected: "statement terminator"},
+		"line comment":       {filter: "type = 'road' -- comment", expected: "comment"},
+		"block comment":      {filter: "type = /* x */ 'road'", expected: "comment"},
+		"unterminated quote": {filter: "type = 'road", expected: "unterminated quote"},
+		"unbalanced close":   {filter: "type = 'road')", expected: "unbalanced parentheses"},
+		"unbalanced open":    {filter: "(type = 'road'", expected: "unbalanced parentheses"},
+		"closing a subquery": {filter: "1 = 1) UNION (SELECT 1", expected: "unbalanced parentheses"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -63,6 +63,17 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		Data:        make([]string, 0),
 	}
 
+	//	advertise the scheme the map's tiles are addressed with
+	if m.Scheme == atlas.TileSchemeTMS {
+		tileJSON.Scheme = tilejson.SchemeTMLS
+	}
+
+	//	the base URLs the tiles are served from. multiple URLs let clients shard their requests
+	baseURLs := m.TileURLs
+	if len(baseURLs) == 0 {
+		baseURLs = []string{fmt.Sprintf("%v://%v", scheme(r), hostName(r))}
+	}
+
 	//	parse our query string
 	var query = r.URL.Query()
 
@@ -123,9 +134,9 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 			Name:    m.Layers[i].MVTName(),
 			MinZoom: m.Layers[i].MinZoom,
 			MaxZoom: m.Layers[i].MaxZoom,
-			Tiles: []string{
-				fmt.Sprintf("%v://%v/maps/%v/%v/{z}/{x}/{y}.pbf%v", scheme(r), hostName(r), req.mapName, m.Layers[i].MVTName(), debugQuery),
-			},
+		}
+		for _, baseURL := range baseURLs {
+			layer.Tiles = append(layer.Tiles, fmt.Sprintf("%v/maps/%v/%v/{z}/{x}/{y}.pbf%v", strings.TrimSuffix(baseURL, "/"), req.mapName, m.Layers[i].MVTName(), debugQuery))
 		}
 
 		switch m.Layers[i].GeomType.(type) {
@@ -144,10 +155,11 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		tileJSON.VectorLayers = append(tileJSON.VectorLayers, layer)
 	}
 
-	tileURL := fmt.Sprintf("%v://%v/maps/%v/{z}/{x}/{y}.pbf%v", scheme(r), hostName(r), req.mapName, debugQuery)
-
 	//	build our URL scheme for the tile grid
-	tileJSON.Tiles = append(tileJSON.Tiles, tileURL)
+	for _, baseURL := range baseURLs {
+		tileURL := fmt.Sprintf("%v/maps/%v/{z}/{x}/{y}.pbf%v", strings.TrimSuffix(baseURL, "/"), req.mapName, debugQuery)
+		tileJSON.Tiles = append(tileJSON.Tiles, tileURL)
+	}
 
 	//	content type
 	w.Header().Add("Content-Type", "application/json")
@@ -206,3 +206,63 @@ func TestHandleMapCapabilities(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleMapCapabilitiesTileURLs(t *testing.T) {
+	m := atlas.NewWebMercatorMap("sharded-map")
+	m.Scheme = atlas.TileSchemeTMS
+	m.TileURLs = []string{
+		"https://a.tiles.example.com",
+		"https://b.tiles.example.com/",
+	}
+	m.Layers = append(m.Layers, testLayer2)
+	if err := atlas.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+
+	router := httptreemux.New()
+	group := router.NewGroup("/")
+	group.UsingContext().Handler("GET", "/capabilities/:map_name", server.HandleMapCapabilities{})
+
+	r, err := http.NewRequest("GET", "http://localhost:8080/capabilities/sharded-map.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got (%v) expected (%v)", w.Code, http.StatusOK)
+	}
+
+	var tileJSON tilejson.TileJSON
+	if err := json.NewDecoder(w.Body).Decode(&tileJSON); err != nil {
+		t.Fatalf("unable to decode JSON response body: %v", err)
+	}
+
+	if tileJSON.Scheme != tilejson.SchemeTMLS {
+		t.Errorf("scheme, expected %v got %v", tilejson.SchemeTMLS, tileJSON.Scheme)
+	}
+	if tileJSON.Format != "pbf" {
+		t.Errorf("format, expected pbf got %v", tileJSON.Format)
+	}
+
+	expected := []string{
+		"https://a.tiles.example.com/maps/sharded-map/{z}/{x}/{y}.pbf",
+		"https://b.tiles.example.com/maps/sharded-map/{z}/{x}/{y}.pbf",
+	}
+	if !reflect.DeepEqual(tileJSON.Tiles, expected) {
+		t.Errorf("tiles, expected %v got %v", expected, tileJSON.Tiles)
+	}
+
+	expectedLayer := []string{
+		fmt.Sprintf("https://a.tiles.example.com/maps/sharded-map/%v/{z}/{x}/{y}.pbf", testLayer2.MVTName()),
+		fmt.Sprintf("https://b.tiles.example.com/maps/sharded-map/%v/{z}/{x}/{y}.pbf", testLayer2.MVTName()),
+	}
+	if len(tileJSON.VectorLayers) != 1 {
+		t.Fatalf("vector layers, expected 1 got %v", len(tileJSON.VectorLayers))
+	}
+	if !reflect.DeepEqual(tileJSON.VectorLayers[0].Tiles, expectedLayer) {
+		t.Errorf("layer tiles, expected %v got %v", expectedLayer, tileJSON.VectorLayers[0].Tiles)
+	}
+}
@@ -31,6 +31,10 @@ type HandleMapLayerZXY struct {
 	extension string
 	//	debug
 	debug bool
+	//	the raw time query string (i.e. 2018-01-01T00:00:00Z/2018-02-01T00:00:00Z). empty if not set
+	timeParam string
+	//	the window the features of layers with a TimeAttribute are filtered to
+	timeWindow *atlas.TimeWindow
 }
 
 //	parseURI reads the request URI and extracts the various values for the request
@@ -79,6 +83,16 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 		req.debug = true
 	}
 
+	//	check for a time window
+	if t := r.URL.Query().Get("time"); t != "" {
+		w, err := atlas.ParseTimeWindow(t)
+		if err != nil {
+			log.Warnf("invalid time value (%v)", t)
+			return err
+		}
+		req.timeParam, req.timeWindow = t, &w
+	}
+
 	return nil
 }
 
@@ -105,7 +119,10 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tile := slippy.NewTile(uint64(req.z), uint64(req.x), uint64(req.y), TileBuffer, tegola.WebMercator)
+	//	normalize the tile coordinates for the map's tile scheme
+	z, x, y := m.ToXYZ(uint64(req.z), uint64(req.x), uint64(req.y))
+
+	tile := slippy.NewTile(z, x, y, TileBuffer, tegola.WebMercator)
 
 	//	filter down the layers we need for this zoom
 	m = m.FilterLayersByZoom(req.z).FilterLayersByName(req.layerName)
@@ -115,10 +132,18 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		m = m.AddDebugLayers()
 	}
 
-	pbyte, err := m.Encode(r.Context(), tile)
+	//	filter the features of layers with a time attribute to the requested window
+	if req.timeWindow != nil {
+		m = m.FilterFeaturesByTime(*req.timeWindow)
+	}
+
+	//	concurrent requests for the same tile share a single render
+	key := renderKey(req.mapName, req.layerName, z, x, y, req.debug, req.timeParam)
+
+	pbyte, err := renderTile(r.Context(), key, m, tile)
 	if err != nil {
 		switch err {
-		case context.Canceled:
+		case context.Canceled, context.DeadlineExceeded:
 			//	TODO: add debug logs
 			return
 		default:
@@ -129,6 +154,12 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	//	the map omits tiles without features (see atlas.Map.OmitEmptyTiles)
+	if len(pbyte) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	//	mimetype for protocol buffers
 	w.Header().Add("Content-Type", "application/x-protobuf")
 	w.WriteHeader(http.StatusOK)
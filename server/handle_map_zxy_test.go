@@ -1,17 +1,25 @@
 package server_test
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/dimfeld/httptreemux"
 	"github.com/golang/protobuf/proto"
 
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/geom"
 	"github.com/go-spatial/tegola/mvt/vector_tile"
+	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/server"
 )
 
@@ -126,3 +134,81 @@ func TestHandleMapZXY(t *testing.T) {
 		}
 	}
 }
+
+// blockingCountProvider counts TileFeatures calls and blocks each call until release is closed
+type blockingCountProvider struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (*blockingCountProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (p *blockingCountProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	atomic.AddInt32(&p.calls, 1)
+	<-p.release
+
+	return fn(&provider.Feature{
+		ID:       1,
+		Geometry: geom.Point{0, 0},
+		SRID:     tegola.WebMercator,
+	})
+}
+
+func TestHandleMapZXYCoalescing(t *testing.T) {
+	const requests = 10
+
+	p := &blockingCountProvider{release: make(chan struct{})}
+
+	m := atlas.NewWebMercatorMap("coalesce-map")
+	m.Layers = append(m.Layers, atlas.Layer{
+		Name:     "counting",
+		Provider: p,
+	})
+	if err := atlas.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+
+	router := httptreemux.New()
+	group := router.NewGroup("/")
+	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", server.HandleMapZXY{})
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, requests)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r, err := http.NewRequest("GET", "/maps/coalesce-map/0/0/0.pbf", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			responses[i] = httptest.NewRecorder()
+			router.ServeHTTP(responses[i], r)
+		}(i)
+	}
+
+	// give the requests time to join the in-flight render before the provider returns
+	time.Sleep(100 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&p.calls); calls != 1 {
+		t.Errorf("provider calls, expected 1 got %v", calls)
+	}
+
+	for i, w := range responses {
+		if w == nil {
+			continue
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("[%v] status code, expected %v got %v", i, http.StatusOK, w.Code)
+			continue
+		}
+		if w.Body.Len() == 0 {
+			t.Errorf("[%v] expected a tile got an empty body", i)
+		}
+	}
+}
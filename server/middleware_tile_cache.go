@@ -2,11 +2,40 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/fnv"
 	"io"
+	"math"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/internal/singleflight"
+)
+
+//	cacheSetErrors counts the cache writes which failed while serving tiles
+var cacheSetErrors uint64
+
+//	CacheSetErrors returns the number of cache writes which have failed while serving tiles. a failed
+//	cache write is logged and does not fail the response, the rendered tile is still returned to the client.
+//	seeding, on the other hand, returns cache write errors to the caller
+func CacheSetErrors() uint64 {
+	return atomic.LoadUint64(&cacheSetErrors)
+}
+
+var (
+	//	now returns the current time. replaced by tests to age cached tiles
+	now = time.Now
+	//	revalidations tracks the background renders of stale tiles
+	revalidations sync.WaitGroup
+	//	revalidateGroup coalesces the background renders of the same stale tile
+	revalidateGroup singleflight.Group
+	//	fillGroup coalesces the renders and cache writes of the same missing tile
+	fillGroup singleflight.Group
 )
 
 //	TileCacheHandler implements a request cache for tiles on requests when the URLs
@@ -23,6 +52,12 @@ func TileCacheHandler(next http.Handler) http.Handler {
 			return
 		}
 
+		//	the tiles of time filtered requests are not cached as the cache key has no time window
+		if r.URL.Query().Get("time") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		//	parse our URI into a cache key structure (pop off the "maps/" prefix)
 		//	5 is the value of len("maps/")
 		key, err := cache.ParseKey(r.URL.Path[5:])
@@ -32,8 +67,15 @@ func TileCacheHandler(next http.Handler) http.Handler {
 			return
 		}
 
+		//	normalize the key for the map's tile scheme so tiles are cached by their XYZ coordinates
+		if m, err := Atlas.Map(key.MapName); err == nil {
+			z, x, y := m.ToXYZ(uint64(key.Z), uint64(key.X), uint64(key.Y))
+			key.Z, key.X, key.Y = int(z), int(x), int(y)
+			key.Namespace = m.CacheNamespace()
+		}
+
 		//	use the URL path as the key
-		cachedTile, hit, err := cacher.Get(key)
+		cachedTile, hit, stale, err := getCachedTile(cacher, key)
 		if err != nil {
 			log.Errorf("cache middleware: error reading from cache: %v", err)
 			next.ServeHTTP(w, r)
@@ -42,33 +84,45 @@ func TileCacheHandler(next http.Handler) http.Handler {
 
 		//	cache miss
 		if !hit {
-			//	buffer which will hold a copy of the response for writing to the cache
-			var buff bytes.Buffer
-
-			//	ovewrite our current responseWriter with a tileCacheResponseWriter
-			w = newTileCacheResponseWriter(w, &buff)
-
-			next.ServeHTTP(w, r)
+			Atlas.Metrics().CacheMiss(key.MapName, uint64(key.Z))
 
-			//	check if our request context has been canceled
-			if r.Context().Err() != nil {
+			//	concurrent misses of the same tile share a single render and cache write. the request
+			//	which renders the tile is written to as it's rendered, the others are written the tile
+			v, _, shared := fillGroup.Do(key.String(), func() (interface{}, error) {
+				return fillTile(cacher, key, next, w, r), nil
+			})
+			if !shared {
 				return
 			}
 
-			//	if nothing has been written to the buffer, don't write to the cache
-			if buff.Len() == 0 {
+			tile, _ := v.([]byte)
+
+			//	the shared render didn't produce a tile (i.e. it was canceled or failed). render the tile for this request
+			if len(tile) == 0 {
+				fillTile(cacher, key, next, w, r)
 				return
 			}
 
-			if err := cacher.Set(key, buff.Bytes()); err != nil {
-				log.Warnf("cache response writer err: %v", err)
-			}
+			w.Header().Add("Content-Type", "application/x-protobuf")
+			w.Header().Set("Tegola-Cache", "MISS")
+			w.Write(tile)
 			return
 		}
 
+		Atlas.Metrics().CacheHit(key.MapName, uint64(key.Z))
+
 		//	mimetype for protocol buffers
 		w.Header().Add("Content-Type", "application/x-protobuf")
 
+		//	the stale tile is returned right away and refreshed in the background
+		if stale {
+			w.Header().Add("Tegola-Cache", "STALE")
+			w.Write(cachedTile)
+
+			revalidateTile(cacher, key, next, r)
+			return
+		}
+
 		//	communicate the cache is being used
 		w.Header().Add("Tegola-Cache", "HIT")
 
@@ -77,6 +131,137 @@ func TileCacheHandler(next http.Handler) http.Handler {
 	})
 }
 
+//	fillTile serves the request with next and writes the rendered tile to the cache. the rendered tile
+//	is returned, nil if the request was canceled or no tile was rendered
+func fillTile(cacher cache.Interface, key *cache.Key, next http.Handler, w http.ResponseWriter, r *http.Request) []byte {
+	//	buffer which will hold a copy of the response for writing to the cache
+	var buff bytes.Buffer
+
+	//	ovewrite our current responseWriter with a tileCacheResponseWriter
+	w = newTileCacheResponseWriter(w, &buff)
+
+	next.ServeHTTP(w, r)
+
+	//	check if our request context has been canceled
+	if r.Context().Err() != nil {
+		return nil
+	}
+
+	//	if nothing has been written to the buffer, don't write to the cache
+	if buff.Len() == 0 {
+		return nil
+	}
+
+	//	the tile has already been written to the client so a failed cache write is not fatal
+	if err := cacher.Set(key, buff.Bytes()); err != nil {
+		atomic.AddUint64(&cacheSetErrors, 1)
+		log.Warnf("cache response writer err: %v", err)
+	}
+
+	return buff.Bytes()
+}
+
+//	getCachedTile reads the tile from the cache. when TileCacheTTL is set and the cache backend records
+//	when tiles were set (cache.ModTimer), tiles set more than their TTL (see tileTTL) ago are reported
+//	as stale
+func getCachedTile(cacher cache.Interface, key *cache.Key) (val []byte, hit bool, stale bool, err error) {
+	mt, ok := cacher.(cache.ModTimer)
+	if TileCacheTTL <= 0 || !ok {
+		val, hit, err = cacher.Get(key)
+		return val, hit, false, err
+	}
+
+	val, modTime, hit, err := mt.GetWithModTime(key)
+	if err != nil || !hit {
+		return val, hit, false, err
+	}
+
+	return val, true, now().Sub(modTime) > tileTTL(key, modTime), nil
+}
+
+//	tileTTL returns the TTL of the tile set at modTime, TileCacheTTL varied by up to TileCacheTTLJitter
+//	either way. the variation is derived from the key and modTime so it's fixed when the tile is set,
+//	and changes each time the tile is set again
+func tileTTL(key *cache.Key, modTime time.Time) time.Duration {
+	if TileCacheTTLJitter <= 0 {
+		return TileCacheTTL
+	}
+
+	h := fnv.New64a()
+	io.WriteString(h, key.String())
+	binary.Write(h, binary.LittleEndian, modTime.UnixNano())
+
+	//	a variation between -1 and 1
+	v := float64(h.Sum64())/math.MaxUint64*2 - 1
+
+	return TileCacheTTL + time.Duration(v*TileCacheTTLJitter*float64(TileCacheTTL))
+}
+
+//	revalidateTile renders the tile in the background and writes it to the cache. concurrent
+//	revalidations of the same key share a single render
+func revalidateTile(cacher cache.Interface, key *cache.Key, next http.Handler, r *http.Request) {
+	//	the request's context is canceled once the stale tile has been returned. the render keeps
+	//	the context's values, which hold the URL params, without its cancelation
+	req := r.WithContext(detachedContext{r.Context()})
+
+	revalidations.Add(1)
+	go func() {
+		defer revalidations.Done()
+
+		revalidateGroup.Do(key.String(), func() (interface{}, error) {
+			w := newTileBufferResponseWriter()
+			next.ServeHTTP(w, req)
+
+			if w.status != http.StatusOK || w.buff.Len() == 0 {
+				log.Warnf("cache revalidation of %v returned status %v, keeping the stale tile", key, w.status)
+				return nil, nil
+			}
+
+			if err := cacher.Set(key, w.buff.Bytes()); err != nil {
+				atomic.AddUint64(&cacheSetErrors, 1)
+				log.Warnf("cache revalidation err: %v", err)
+			}
+			return nil, nil
+		})
+	}()
+}
+
+//	detachedContext keeps the values of the wrapped context but is never canceled
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func newTileBufferResponseWriter() *tileBufferResponseWriter {
+	return &tileBufferResponseWriter{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+//	tileBufferResponseWriter implements http.ResponseWriter (https://golang.org/pkg/net/http/#ResponseWriter)
+//	by buffering the response. used to render tiles which are not returned to a client
+type tileBufferResponseWriter struct {
+	header http.Header
+	status int
+	buff   bytes.Buffer
+}
+
+func (w *tileBufferResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *tileBufferResponseWriter) Write(b []byte) (int, error) {
+	return w.buff.Write(b)
+}
+
+func (w *tileBufferResponseWriter) WriteHeader(i int) {
+	w.status = i
+}
+
 func newTileCacheResponseWriter(resp http.ResponseWriter, w io.Writer) http.ResponseWriter {
 	return &tileCacheResponseWriter{
 		resp:  resp,
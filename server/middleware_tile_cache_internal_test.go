@@ -2,10 +2,17 @@ package server
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
 )
 
 func TestTileCacheResponseWriter(t *testing.T) {
@@ -48,3 +55,139 @@ func TestTileCacheResponseWriter(t *testing.T) {
 		}
 	}
 }
+
+func TestTileCacheHandlerStale(t *testing.T) {
+	cacher := memory.New()
+	a := &atlas.Atlas{}
+	a.SetCache(cacher)
+
+	//	swap the server's atlas, tile TTL and clock
+	defaultAtlas, defaultTTL, defaultNow := Atlas, TileCacheTTL, now
+	Atlas, TileCacheTTL = a, time.Minute
+	defer func() { Atlas, TileCacheTTL, now = defaultAtlas, defaultTTL, defaultNow }()
+
+	//	each render returns a new version of the tile
+	var renders int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := atomic.AddInt32(&renders, 1)
+		w.Header().Add("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "tile v%v", v)
+	})
+	handler := TileCacheHandler(next)
+
+	request := func(expectedCache, expectedBody string) {
+		t.Helper()
+
+		r, err := http.NewRequest("GET", "/maps/test-map/10/2/3.pbf", nil)
+		if err != nil {
+			t.Fatalf("error, expected nil got %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get("Tegola-Cache"); got != expectedCache {
+			t.Errorf("header Tegola-Cache, expected %v got %v", expectedCache, got)
+		}
+		if got := w.Body.String(); got != expectedBody {
+			t.Errorf("body, expected %v got %v", expectedBody, got)
+		}
+	}
+
+	request("MISS", "tile v1")
+	request("HIT", "tile v1")
+
+	//	move the clock past the tile's TTL
+	now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	//	the stale tile is returned while the tile is rendered in the background
+	request("STALE", "tile v1")
+	revalidations.Wait()
+
+	key := &cache.Key{MapName: "test-map", Z: 10, X: 2, Y: 3}
+	val, hit, err := cacher.Get(key)
+	if err != nil {
+		t.Fatalf("error reading cache, expected nil got %v", err)
+	}
+	if !hit || string(val) != "tile v2" {
+		t.Errorf("cached tile, expected tile v2 got %q (hit: %v)", val, hit)
+	}
+
+	//	the refreshed tile is fresh
+	now = time.Now
+	request("HIT", "tile v2")
+}
+
+func TestTileTTLJitter(t *testing.T) {
+	type tcase struct {
+		jitter float64
+	}
+
+	//	swap the server's tile TTL and jitter
+	defaultTTL, defaultJitter := TileCacheTTL, TileCacheTTLJitter
+	defer func() { TileCacheTTL, TileCacheTTLJitter = defaultTTL, defaultJitter }()
+
+	fn := func(t *testing.T, tc tcase) {
+		TileCacheTTL, TileCacheTTLJitter = time.Hour, tc.jitter
+
+		//	seed the tiles of a zoom
+		cacher := memory.New()
+		var keys []*cache.Key
+		for x := 0; x < 16; x++ {
+			for y := 0; y < 16; y++ {
+				key := &cache.Key{MapName: "test-map", Z: 4, X: x, Y: y}
+				if err := cacher.Set(key, []byte("tile")); err != nil {
+					t.Fatalf("error setting tile, expected nil got %v", err)
+				}
+				keys = append(keys, key)
+			}
+		}
+
+		minTTL := time.Duration(float64(TileCacheTTL) * (1 - tc.jitter))
+		maxTTL := time.Duration(float64(TileCacheTTL) * (1 + tc.jitter))
+
+		var shortest, longest time.Duration
+		for i, key := range keys {
+			_, modTime, _, err := cacher.GetWithModTime(key)
+			if err != nil {
+				t.Fatalf("error reading tile, expected nil got %v", err)
+			}
+
+			ttl := tileTTL(key, modTime)
+			if ttl < minTTL || ttl > maxTTL {
+				t.Errorf("tile (%v) ttl, expected between %v and %v got %v", key, minTTL, maxTTL, ttl)
+			}
+
+			if i == 0 || ttl < shortest {
+				shortest = ttl
+			}
+			if i == 0 || ttl > longest {
+				longest = ttl
+			}
+		}
+
+		//	the expiries are spread across most of the jitter window
+		window := maxTTL - minTTL
+		if spread := longest - shortest; spread < window*3/4 {
+			t.Errorf("ttl spread, expected at least %v got %v", window*3/4, spread)
+		}
+	}
+
+	tests := map[string]tcase{
+		"no jitter": {},
+		"10 percent": {
+			jitter: 0.1,
+		},
+		"50 percent": {
+			jitter: 0.5,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -1,11 +1,20 @@
 package server_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/dimfeld/httptreemux"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/server"
 )
 
@@ -70,3 +79,188 @@ func TestMiddlewareTileCacheHandler(t *testing.T) {
 		}
 	}
 }
+
+//	setErrCache is a cache which always misses and fails to write
+type setErrCache struct{}
+
+func (setErrCache) Get(key *cache.Key) ([]byte, bool, error) { return nil, false, nil }
+func (setErrCache) Set(key *cache.Key, val []byte) error     { return errors.New("set failed") }
+func (setErrCache) Purge(key *cache.Key) error               { return nil }
+
+func TestMiddlewareTileCacheHandlerSetError(t *testing.T) {
+	a := &atlas.Atlas{}
+	a.SetCache(setErrCache{})
+
+	//	swap the server's atlas for one with the failing cache
+	defaultAtlas := server.Atlas
+	server.Atlas = a
+	defer func() { server.Atlas = defaultAtlas }()
+
+	router := httptreemux.New()
+	group := router.NewGroup("/")
+	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", server.TileCacheHandler(server.HandleMapZXY{}))
+
+	r, err := http.NewRequest("GET", "/maps/test-map/10/2/3.pbf", nil)
+	if err != nil {
+		t.Fatalf("error, expected nil got %v", err)
+	}
+
+	setErrors := server.CacheSetErrors()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+
+	if w.Body.Len() == 0 {
+		t.Errorf("expected the rendered tile to be returned")
+	}
+
+	if got := server.CacheSetErrors(); got != setErrors+1 {
+		t.Errorf("cache set errors, expected %v got %v", setErrors+1, got)
+	}
+}
+
+//	emptyProvider is a provider that never returns any features
+type emptyProvider struct{}
+
+func (emptyProvider) Layers() ([]provider.LayerInfo, error) { return nil, nil }
+
+func (emptyProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return nil
+}
+
+func TestMiddlewareTileCacheOmittedTile(t *testing.T) {
+	m := atlas.NewWebMercatorMap("omit-empty-map")
+	m.OmitEmptyTiles = true
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "empty",
+			Provider: emptyProvider{},
+		},
+	}
+	if err := server.Atlas.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	defer server.Atlas.RemoveMap(m.Name)
+
+	router := httptreemux.New()
+	group := router.NewGroup("/")
+	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", server.TileCacheHandler(server.HandleMapZXY{}))
+
+	//	the omitted tile is never cached, so both requests miss
+	for i := 0; i < 2; i++ {
+		r, err := http.NewRequest("GET", "/maps/omit-empty-map/10/2/3.pbf", nil)
+		if err != nil {
+			t.Fatalf("[%v] error, expected nil got %v", i, err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("[%v] status code, expected %v got %v", i, http.StatusNoContent, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("[%v] body, expected none got %v bytes", i, w.Body.Len())
+		}
+		if w.Header().Get("Tegola-Cache") == "HIT" {
+			t.Errorf("[%v] header Tegola-Cache, expected a miss got HIT", i)
+		}
+	}
+}
+
+//	setCountCache counts the writes to the wrapped cache
+type setCountCache struct {
+	cache.Interface
+	sets int32
+}
+
+func (c *setCountCache) Set(key *cache.Key, val []byte) error {
+	atomic.AddInt32(&c.sets, 1)
+	return c.Interface.Set(key, val)
+}
+
+func TestMiddlewareTileCacheCoalescing(t *testing.T) {
+	const requests = 10
+
+	c := &setCountCache{Interface: memory.New()}
+
+	a := &atlas.Atlas{}
+	a.SetCache(c)
+
+	p := &blockingCountProvider{release: make(chan struct{})}
+
+	m := atlas.NewWebMercatorMap("coalesce-cache-map")
+	m.Layers = []atlas.Layer{
+		{
+			Name:     "counting",
+			Provider: p,
+		},
+	}
+	//	the handlers render the maps of the default atlas
+	if err := atlas.AddMap(m); err != nil {
+		t.Fatalf("err adding map: %v", err)
+	}
+	defer atlas.RemoveMap(m.Name)
+
+	//	swap the server's atlas for one with the counting cache
+	defaultAtlas := server.Atlas
+	server.Atlas = a
+	defer func() { server.Atlas = defaultAtlas }()
+
+	router := httptreemux.New()
+	group := router.NewGroup("/")
+	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", server.TileCacheHandler(server.HandleMapZXY{}))
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, requests)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r, err := http.NewRequest("GET", "/maps/coalesce-cache-map/0/0/0.pbf", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			responses[i] = httptest.NewRecorder()
+			router.ServeHTTP(responses[i], r)
+		}(i)
+	}
+
+	//	give the requests time to join the in-flight render before the provider returns
+	time.Sleep(100 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&p.calls); calls != 1 {
+		t.Errorf("provider calls, expected 1 got %v", calls)
+	}
+	if sets := atomic.LoadInt32(&c.sets); sets != 1 {
+		t.Errorf("cache writes, expected 1 got %v", sets)
+	}
+
+	for i, w := range responses {
+		if w == nil {
+			continue
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("[%v] status code, expected %v got %v", i, http.StatusOK, w.Code)
+			continue
+		}
+		if w.Body.Len() == 0 {
+			t.Errorf("[%v] expected a tile got an empty body", i)
+		}
+		if got := w.Header().Get("Tegola-Cache"); got != "MISS" {
+			t.Errorf("[%v] header Tegola-Cache, expected MISS got %v", i, got)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/x-protobuf" {
+			t.Errorf("[%v] header Content-Type, expected application/x-protobuf got %v", i, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/geom/slippy"
+	"github.com/go-spatial/tegola/internal/singleflight"
+)
+
+//	renderGroup coalesces concurrent renders of the same tile so identical requests share a single render
+var renderGroup singleflight.Group
+
+//	renderKey uniquely identifies a tile render. layerName is empty when rendering all of the map's layers
+//	and timeWindow is empty when the features are not filtered by time
+func renderKey(mapName, layerName string, z, x, y uint64, debug bool, timeWindow string) string {
+	if layerName == "" {
+		return fmt.Sprintf("%v/%v/%v/%v?debug=%v&time=%v", mapName, z, x, y, debug, timeWindow)
+	}
+	return fmt.Sprintf("%v/%v/%v/%v/%v?debug=%v&time=%v", mapName, layerName, z, x, y, debug, timeWindow)
+}
+
+//	renderTile encodes the tile for the map. concurrent calls with the same key share a single render.
+func renderTile(ctx context.Context, key string, m atlas.Map, tile *slippy.Tile) ([]byte, error) {
+	v, err, shared := renderGroup.Do(key, func() (interface{}, error) {
+		return m.Encode(ctx, tile)
+	})
+
+	//	the shared render was canceled by the request which started it. render the tile for this request
+	if shared && ctx.Err() == nil && (err == context.Canceled || err == context.DeadlineExceeded) {
+		return m.Encode(ctx, tile)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	pbyte, _ := v.([]byte)
+
+	return pbyte, nil
+}
@@ -4,6 +4,7 @@ package server
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dimfeld/httptreemux"
 
@@ -32,6 +33,14 @@ var (
 	Atlas *atlas.Atlas
 	//	tile buffer to use. can be overwritten in the config file
 	TileBuffer float64 = tegola.DefaultTileBuffer
+	//	cached tiles older than TileCacheTTL are served stale while they are rendered again in the
+	//	background. only applies to cache backends implementing cache.ModTimer. 0 disables expiry
+	//	configurable via the tegola config.toml file (set in main.go)
+	TileCacheTTL time.Duration
+	//	the fraction (0 to 1) by which the TTL of each cached tile is varied, up or down, so tiles cached
+	//	at the same time, i.e. by a seed, don't expire together and are not all rendered again at once.
+	//	0 disables the jitter. configurable via the tegola config.toml file (set in main.go)
+	TileCacheTTLJitter float64
 )
 
 //	Start starts the tile server binding to the provided port
@@ -72,7 +72,9 @@ func init() {
 	atlas.SetCache(memory.New())
 
 	//	register a map with atlas
-	atlas.AddMap(testMap)
+	if err := atlas.AddMap(testMap); err != nil {
+		panic(err)
+	}
 
 	server.Atlas = atlas.DefaultAtlas
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/go-spatial/tegola/geom"
 	"github.com/go-spatial/tegola/maths/webmercator"
 )
 
@@ -160,6 +161,34 @@ func (t *Tile) ToPixel(srid int, pt [2]float64) (npt [2]float64, err error) {
 	return [2]float64{float64(nx), float64(ny)}, nil
 }
 
+// ToPixelNearest is ToPixel with the pixel coordinates rounded to the nearest point of the tile's grid,
+// instead of truncated towards zero. Coordinates an insignificant distance apart land on the same
+// grid point unless they straddle the middle of a pixel.
+func (t *Tile) ToPixelNearest(srid int, pt [2]float64) (npt [2]float64, err error) {
+	spt, err := toWebMercator(srid, pt)
+	if err != nil {
+		return npt, err
+	}
+
+	nx := math.Floor((spt[0]-t.extent[0][0])*t.Extent/t.xspan + 0.5)
+	ny := math.Floor((spt[1]-t.extent[0][1])*t.Extent/t.yspan + 0.5)
+	return [2]float64{nx, ny}, nil
+}
+
+// TileTransform returns a function mapping projected coordinates inside of the extent to the tile's integer
+// grid of tileSize units along each edge. The y axis is flipped so the top left corner of the extent maps to
+// (0, 0) and the bottom right corner to (tileSize, tileSize). Coordinates are truncated towards zero, as
+// with ToPixel.
+func TileTransform(extent *geom.BoundingBox, tileSize uint) func(x, y float64) (int, int) {
+	minx, maxy := extent.MinX(), extent.MaxY()
+	xscale := float64(tileSize) / (extent.MaxX() - minx)
+	yscale := float64(tileSize) / (maxy - extent.MinY())
+
+	return func(x, y float64) (int, int) {
+		return int((x - minx) * xscale), int((maxy - y) * yscale)
+	}
+}
+
 func (t *Tile) FromPixel(srid int, pt [2]float64) (npt [2]float64, err error) {
 
 	x := float64(int64(pt[0]))
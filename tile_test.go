@@ -5,6 +5,7 @@ import (
 
 	"github.com/gdey/tbltest"
 	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/geom"
 )
 
 func TestTileNum2Deg(t *testing.T) {
@@ -147,3 +148,76 @@ func TestTileToFromPixel(t *testing.T) {
 		[2]float64{4000, 4000},
 	).Run(fn)
 }
+
+func TestTileTransform(t *testing.T) {
+	type tcase struct {
+		extent   geom.BoundingBox
+		tileSize uint
+		pt       [2]float64
+		expected [2]int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		x, y := tegola.TileTransform(&tc.extent, tc.tileSize)(tc.pt[0], tc.pt[1])
+		if [2]int{x, y} != tc.expected {
+			t.Errorf("pixel, expected %v got %v", tc.expected, [2]int{x, y})
+		}
+	}
+
+	tile := tegola.NewTile(3, 2, 5)
+	tileBBox := tile.BoundingBox()
+	tileExtent := geom.BoundingBox{{tileBBox.Minx, tileBBox.Maxy}, {tileBBox.Maxx, tileBBox.Miny}}
+
+	// a point inside of the tile maps to the same pixel as with ToPixel
+	inside := [2]float64{tileBBox.Minx + 1234567.8, tileBBox.Miny - 2345678.9}
+	insidePixel, err := tile.ToPixel(tegola.WebMercator, inside)
+	if err != nil {
+		t.Fatalf("err converting to pixel: %v", err)
+	}
+
+	tests := map[string]tcase{
+		"top left": {
+			extent:   geom.BoundingBox{{0, 0}, {100, 100}},
+			tileSize: 4096,
+			pt:       [2]float64{0, 100},
+			expected: [2]int{0, 0},
+		},
+		"bottom right": {
+			extent:   geom.BoundingBox{{0, 0}, {100, 100}},
+			tileSize: 4096,
+			pt:       [2]float64{100, 0},
+			expected: [2]int{4096, 4096},
+		},
+		"center": {
+			extent:   geom.BoundingBox{{-50, -50}, {50, 50}},
+			tileSize: 256,
+			pt:       [2]float64{0, 0},
+			expected: [2]int{128, 128},
+		},
+		"tile top left": {
+			extent:   tileExtent,
+			tileSize: 4096,
+			pt:       [2]float64{tileBBox.Minx, tileBBox.Miny},
+			expected: [2]int{0, 0},
+		},
+		"tile bottom right": {
+			extent:   tileExtent,
+			tileSize: 4096,
+			pt:       [2]float64{tileBBox.Maxx, tileBBox.Maxy},
+			expected: [2]int{4096, 4096},
+		},
+		"tile matches ToPixel": {
+			extent:   tileExtent,
+			tileSize: 4096,
+			pt:       inside,
+			expected: [2]int{int(insidePixel[0]), int(insidePixel[1])},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
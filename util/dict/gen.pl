@@ -3,7 +3,7 @@ use strict;
 use warnings;
 use 5.10.0;
 
-my @types = qw(string int uint);
+my @types = qw(string bool int uint);
 push @types, "int$_", "uint$_" for (qw(8 16 32 64));
 
 say <<GOCODE;
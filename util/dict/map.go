@@ -65,6 +65,50 @@ func (m M) StringSlice(key string) (v []string, err error) {
 	return v, nil
 }
 
+// Bool returns the value as a bool type, if it is unable to convert the value it will error. If the default value is not provided, and it can not find the value, it will return the zero value, and an error.
+func (m M) Bool(key string, def *bool) (v bool, err error) {
+	var val interface{}
+	var ok bool
+	if val, ok = m[key]; !ok {
+		if def != nil {
+			return *def, nil
+		}
+		return v, fmt.Errorf("%v value is required.", key)
+	}
+	if v, ok = val.(bool); !ok {
+		if def == nil {
+			return v, nil
+		}
+		return *def, fmt.Errorf("%v value needs to be of type bool. Value is of type %T", key, val)
+	}
+	return v, nil
+}
+
+func (m M) BoolSlice(key string) (v []bool, err error) {
+	var val interface{}
+	var ok bool
+	if val, ok = m[key]; !ok {
+		return v, nil
+	}
+	if v, ok = val.([]bool); !ok {
+		// It's possible that the value is of type []interface and not of our type, so we need to convert each element to the appropriate
+		// type first, and then into the this type.
+		var iv []interface{}
+		if iv, ok = val.([]interface{}); !ok {
+			// Could not convert to the generic type, so we don't have the correct thing.
+			return v, fmt.Errorf("%v value needs to be of type []bool. Value is of type %T", key, val)
+		}
+		for _, value := range iv {
+			vt, ok := value.(bool)
+			if !ok {
+				return v, fmt.Errorf("%v value needs to be of type []bool. Value is of type %T", key, val)
+			}
+			v = append(v, vt)
+		}
+	}
+	return v, nil
+}
+
 // Int returns the value as a int type, if it is unable to convert the value it will error. If the default value is not provided, and it can not find the value, it will return the zero value, and an error.
 func (m M) Int(key string, def *int) (v int, err error) {
 	var val interface{}